@@ -0,0 +1,387 @@
+package app
+
+import (
+	"context"
+	"crypto/rand"
+	"net"
+	"net/http"
+	"time"
+
+	dbmigration "go02/db-migration"
+	"go02/interface/handler"
+	"go02/interface/router"
+	"go02/middleware"
+	"go02/packages/auth"
+	"go02/packages/config"
+	"go02/packages/db"
+	"go02/packages/errreport"
+	"go02/packages/health"
+	"go02/packages/logging"
+	"go02/packages/mail"
+	"go02/packages/metrics"
+	"go02/packages/tracer"
+	"go02/repository"
+	"go02/usecase"
+
+	"github.com/cockroachdb/errors"
+	"github.com/labstack/echo/v4"
+	"github.com/uptrace/bun"
+	"go.opentelemetry.io/contrib/instrumentation/github.com/labstack/echo/otelecho"
+	"go.opentelemetry.io/otel/propagation"
+)
+
+type Options struct {
+	Config *config.Config
+	// Source enables hot reload of the configuration it was loaded from.
+	Source *config.Source
+	// DB replaces the database described by Config.DB, e.g. with a test
+	// container. The caller keeps ownership and closes it.
+	DB *bun.DB
+}
+
+// App is one instance of the api server. It owns its configuration, logger,
+// telemetry providers and database instead of sharing them through package
+// globals, so several instances can run side by side in one process.
+type App struct {
+	config    *config.Store
+	logger    *logging.Logger
+	db        *bun.DB
+	server    *http.Server
+	lifecycle Lifecycle
+
+	// ctx carries the logger to background work and request handling and is
+	// cancelled once the app has stopped.
+	ctx      context.Context
+	cancel   context.CancelFunc
+	listener net.Listener
+	serveErr chan error
+}
+
+// New builds the dependency graph. Nothing connects or listens until Start.
+func New(ctx context.Context, opts Options) (*App, error) {
+	cfg := opts.Config
+	a := &App{
+		config:   config.NewStore(cfg),
+		serveErr: make(chan error, 1),
+	}
+
+	logger, err := logging.New(logging.Options{
+		Level:          cfg.Logging.Level,
+		Redaction:      cfg.Logging.Redaction,
+		RedactHashKey:  cfg.Logging.RedactKey,
+		Format:         cfg.Logging.Format,
+		Profile:        cfg.Logging.Profile,
+		File:           cfg.Logging.File,
+		FileMaxSizeMB:  cfg.Logging.FileMaxSize,
+		FileMaxBackups: cfg.Logging.FileBackups,
+	})
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to initialize logging")
+	}
+	a.logger = logger
+	// appended first so that it is stopped last
+	a.lifecycle.Append(Hook{Name: "logger", OnStop: func(context.Context) error { return logger.Close() }})
+	a.ctx, a.cancel = context.WithCancel(logging.NewContext(context.WithoutCancel(ctx), logger.Logger))
+	ctx = a.ctx
+
+	tp, err := tracer.InitializeTracer(ctx, cfg)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to initialize tracer")
+	}
+	a.lifecycle.Append(Hook{Name: "tracer", OnStop: tp.Shutdown})
+
+	mp, metricsHandler, err := metrics.InitializeMeter(ctx, cfg)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to initialize meter")
+	}
+	a.lifecycle.Append(Hook{Name: "meter", OnStop: mp.Shutdown})
+
+	conn := opts.DB
+	if conn == nil {
+		conn, err = db.Open(db.Options{
+			Config: cfg.DB,
+			// plans cost a connection each, keep them out of production
+			Explain:        cfg.DB.SlowQueryExplain && cfg.Env != "production",
+			TracerProvider: tp,
+			MeterProvider:  mp,
+		})
+		if err != nil {
+			return nil, errors.Wrap(err, "failed to initialize a new database")
+		}
+		a.lifecycle.Append(Hook{
+			Name: "db",
+			OnStart: func(ctx context.Context) error {
+				if err := db.WaitReady(ctx, conn, cfg.DB); err != nil {
+					return err
+				}
+				if cfg.DB.MigrateOnStart {
+					return db.MigrateOnStart(a.ctx, conn, db.URL(cfg.DB))
+				}
+				return nil
+			},
+			OnStop: func(ctx context.Context) error {
+				return conn.Close()
+			},
+		})
+	}
+	a.db = conn
+
+	if err := db.RegisterStatsMetrics(conn, mp); err != nil {
+		return nil, errors.Wrap(err, "failed to register db stats metrics")
+	}
+	db.PublishStats(conn)
+
+	dbMonitor := db.NewMonitor(conn, cfg.DB.HealthCheckInterval, cfg.DB.PingTimeout)
+	if err := dbMonitor.RegisterMetrics(mp); err != nil {
+		return nil, errors.Wrap(err, "failed to register db monitor metrics")
+	}
+	a.lifecycle.Append(Hook{
+		Name: "db monitor",
+		OnStart: func(context.Context) error {
+			dbMonitor.Start(a.ctx)
+			return nil
+		},
+	})
+
+	reporter, err := errreport.New(errreport.Options{
+		Reporter:    cfg.ErrorReport.Reporter,
+		DSN:         cfg.ErrorReport.SentryDSN,
+		File:        cfg.ErrorReport.File,
+		Environment: cfg.Env,
+		Release:     cfg.AppVersion,
+		SampleRate:  cfg.ErrorReport.SampleRate,
+		Burst:       cfg.ErrorReport.Burst,
+		Window:      cfg.ErrorReport.Window,
+	})
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to initialize error reporter")
+	}
+	a.lifecycle.Append(Hook{
+		Name: "error reporter",
+		OnStop: func(context.Context) error {
+			reporter.Flush(2 * time.Second)
+			return nil
+		},
+	})
+
+	if opts.Source != nil {
+		watcher := config.NewWatcher(*opts.Source, a.config)
+		watcher.Subscribe(func(old, new *config.Config) {
+			if new.Logging.Level != old.Logging.Level {
+				if l, err := logging.ParseLevel(new.Logging.Level); err == nil {
+					logger.SetLevel(l)
+				}
+			}
+			db.SetPool(conn, new.DB)
+			errreport.SetLimits(reporter, new.ErrorReport.SampleRate, new.ErrorReport.Burst, new.ErrorReport.Window)
+		})
+		a.lifecycle.Append(Hook{
+			Name: "config watcher",
+			OnStart: func(context.Context) error {
+				watcher.Start(a.ctx)
+				return nil
+			},
+		})
+	}
+
+	mailer, err := mail.New(mail.Options{
+		Mailer:             cfg.Mail.Mailer,
+		From:               cfg.Mail.From,
+		File:               cfg.Mail.File,
+		SMTPHost:           cfg.Mail.SMTPHost,
+		SMTPPort:           cfg.Mail.SMTPPort,
+		SMTPUser:           cfg.Mail.SMTPUser,
+		SMTPPassword:       cfg.Mail.SMTPPassword,
+		SMTPAllowPlaintext: cfg.Mail.SMTPAllowPlaintext,
+	})
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to initialize mailer")
+	}
+	a.lifecycle.Append(Hook{Name: "mailer", OnStop: func(context.Context) error { return mail.Close(mailer) }})
+
+	tokenKey := []byte(cfg.Auth.TokenSecret)
+	if len(tokenKey) == 0 {
+		tokenKey = make([]byte, 32)
+		if _, err := rand.Read(tokenKey); err != nil {
+			return nil, errors.Wrap(err, "failed to generate a token key")
+		}
+		logging.Warn(ctx, "AUTH_TOKEN_SECRET is not set, access tokens won't survive a restart")
+	}
+
+	e := echo.New()
+	e.HTTPErrorHandler = handler.NewErrorHandler(reporter)
+
+	latest, err := dbmigration.LatestVersion()
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to read embedded migrations")
+	}
+
+	registry := health.NewRegistry()
+	timeout := cfg.Health.CheckTimeout
+	registry.Register("db", timeout, db.PingCheck(conn), health.Readiness, health.Startup)
+	registry.Register("migration", timeout, db.MigrationCheck(conn, latest), health.Readiness, health.Startup)
+	registry.Register("tracer", timeout, tracer.HealthCheck, health.Readiness)
+
+	e.Use(otelecho.Middleware("go02",
+		otelecho.WithSkipper(middleware.SkipHealthCheck),
+		otelecho.WithTracerProvider(tp),
+		otelecho.WithPropagators(propagation.TraceContext{}),
+	))
+	e.Use(middleware.RequestID())
+	e.Use(middleware.LogLevel(a.config))
+	e.Use(middleware.Logger())
+	e.Use(middleware.Metrics(mp))
+	e.Use(middleware.Recover(mp))
+
+	transactionRepository := repository.NewTransactionRepository(conn)
+	userRepository := repository.NewUserRepository(conn)
+	profileRepository := repository.NewProfileRepository(conn)
+	emailVerificationRepository := repository.NewEmailVerificationRepository(conn)
+	credentialRepository := repository.NewCredentialRepository(conn)
+	sessionRepository := repository.NewSessionRepository(conn)
+	organizationRepository := repository.NewOrganizationRepository(conn)
+	groupRepository := repository.NewGroupRepository(conn)
+	groupMembershipRepository := repository.NewGroupMembershipRepository(conn)
+	followRepository := repository.NewFollowRepository(conn)
+	usecaseMetrics := metrics.NewUsecase(mp)
+	userUsecase := usecase.NewUserUsecase(transactionRepository, userRepository, profileRepository, credentialRepository, sessionRepository, usecaseMetrics)
+	emailUsecase := usecase.NewEmailUsecase(transactionRepository, userRepository, emailVerificationRepository, mailer,
+		cfg.Mail.VerifyURL, cfg.Mail.VerifyTokenTTL, cfg.Mail.VerifyRequestInterval, usecaseMetrics)
+	organizationUsecase := usecase.NewOrganizationUsecase(organizationRepository, usecaseMetrics)
+	groupUsecase := usecase.NewGroupUsecase(transactionRepository, groupRepository, groupMembershipRepository, userRepository, usecaseMetrics)
+	followUsecase := usecase.NewFollowUsecase(transactionRepository, followRepository, userRepository, organizationRepository, usecaseMetrics)
+	signer := auth.NewSigner(tokenKey, cfg.Auth.AccessTokenTTL)
+	authUsecase := usecase.NewAuthUsecase(transactionRepository, userRepository, credentialRepository, sessionRepository,
+		signer,
+		usecase.LoginPolicy{
+			SessionTTL:        cfg.Auth.SessionTTL,
+			MaxFailedAttempts: cfg.Auth.MaxFailedLogins,
+			LockoutDuration:   cfg.Auth.LockoutDuration,
+		},
+		usecaseMetrics,
+	)
+
+	tenant := []echo.MiddlewareFunc{middleware.Tenant(middleware.TenantOptions{
+		Organizations: organizationUsecase,
+		BaseDomain:    cfg.Tenant.BaseDomain,
+		Default:       cfg.Tenant.Default,
+	})}
+	if cfg.DB.RowLevelSecurity {
+		tenant = append(tenant, middleware.RowLevelSecurity(conn))
+	}
+
+	router.Init(e, router.Handlers{
+		User:        handler.NewUserHandler(userUsecase),
+		Debug:       handler.NewDebugHandler(conn, a.config),
+		Health:      handler.NewHealthHandler(registry),
+		Admin:       handler.NewAdminHandler(logger),
+		Email:       handler.NewEmailHandler(emailUsecase),
+		Auth:        handler.NewAuthHandler(authUsecase),
+		Org:         handler.NewOrganizationHandler(organizationUsecase),
+		Group:       handler.NewGroupHandler(groupUsecase),
+		Follow:      handler.NewFollowHandler(followUsecase),
+		Metrics:     metricsHandler,
+		AdminAuth:   middleware.AdminAuth(cfg.Auth.AdminToken),
+		RequireAuth: middleware.RequireAuth(authUsecase, cfg.Auth.AdminToken),
+		Tenant:      tenant,
+	})
+
+	followCounts := usecase.NewFollowCountReconciler(followUsecase, cfg.Follow.CountInterval)
+	a.lifecycle.Append(Hook{
+		Name: "follow count reconciler",
+		OnStart: func(context.Context) error {
+			followCounts.Start(a.ctx)
+			return nil
+		},
+	})
+
+	a.server = &http.Server{
+		Addr:    ":" + cfg.Server.Port,
+		Handler: e,
+		BaseContext: func(net.Listener) context.Context {
+			return a.ctx
+		},
+	}
+	a.lifecycle.Append(Hook{
+		Name:    "http server",
+		OnStart: a.listen,
+		OnStop:  a.server.Shutdown,
+	})
+
+	return a, nil
+}
+
+func (a *App) listen(ctx context.Context) error {
+	ln, err := net.Listen("tcp", a.server.Addr)
+	if err != nil {
+		return err
+	}
+	a.listener = ln
+
+	go func() {
+		if err := a.server.Serve(ln); !errors.Is(err, http.ErrServerClosed) {
+			a.serveErr <- errors.Wrap(err, "failed to serve")
+		}
+	}()
+
+	logging.Infof(a.ctx, "listening on %s", ln.Addr())
+	return nil
+}
+
+// Handler returns the HTTP handler, e.g. for httptest without listening.
+func (a *App) Handler() http.Handler {
+	return a.server.Handler
+}
+
+// Addr returns the address the server listens on once started. It resolves
+// port 0 to the port that was picked.
+func (a *App) Addr() string {
+	if a.listener == nil {
+		return ""
+	}
+	return a.listener.Addr().String()
+}
+
+func (a *App) Config() *config.Config {
+	return a.config.Get()
+}
+
+func (a *App) Logger() *logging.Logger {
+	return a.logger
+}
+
+func (a *App) DB() *bun.DB {
+	return a.db
+}
+
+// Start starts the components in dependency order.
+func (a *App) Start(ctx context.Context) error {
+	return a.lifecycle.Start(ctx)
+}
+
+// Stop stops the started components in reverse order and cancels their
+// background work.
+func (a *App) Stop(ctx context.Context) error {
+	defer a.cancel()
+	return a.lifecycle.Stop(ctx)
+}
+
+// Run starts the app and serves until ctx is cancelled or the server fails,
+// then stops it within SHUTDOWN_TIMEOUT.
+func (a *App) Run(ctx context.Context) error {
+	if err := a.Start(ctx); err != nil {
+		return err
+	}
+
+	var err error
+	select {
+	case <-ctx.Done():
+		logging.Info(a.ctx, "shutting down")
+	case err = <-a.serveErr:
+	}
+
+	stopCtx, cancel := context.WithTimeout(context.WithoutCancel(ctx), a.config.Get().Server.ShutdownTimeout)
+	defer cancel()
+
+	return errors.Join(err, a.Stop(stopCtx))
+}
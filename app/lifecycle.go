@@ -0,0 +1,60 @@
+package app
+
+import (
+	"context"
+
+	"github.com/cockroachdb/errors"
+)
+
+// Hook is the start and stop step of a component. Either function may be nil.
+type Hook struct {
+	Name    string
+	OnStart func(ctx context.Context) error
+	OnStop  func(ctx context.Context) error
+}
+
+// Lifecycle starts hooks in the order they were appended and stops the ones
+// that have started in reverse order.
+type Lifecycle struct {
+	hooks   []Hook
+	started int
+}
+
+func (l *Lifecycle) Append(h Hook) {
+	l.hooks = append(l.hooks, h)
+}
+
+// Start runs the OnStart hooks. When one fails, the hooks started so far are
+// stopped again before the error is returned.
+func (l *Lifecycle) Start(ctx context.Context) error {
+	for l.started < len(l.hooks) {
+		h := l.hooks[l.started]
+		if h.OnStart != nil {
+			if err := h.OnStart(ctx); err != nil {
+				err = errors.Wrapf(err, "failed to start %s", h.Name)
+				return errors.Join(err, l.Stop(ctx))
+			}
+		}
+		l.started++
+	}
+
+	return nil
+}
+
+// Stop runs the OnStop hooks of the started components and reports all of
+// their errors.
+func (l *Lifecycle) Stop(ctx context.Context) error {
+	var errs []error
+	for l.started > 0 {
+		l.started--
+		h := l.hooks[l.started]
+		if h.OnStop == nil {
+			continue
+		}
+		if err := h.OnStop(ctx); err != nil {
+			errs = append(errs, errors.Wrapf(err, "failed to stop %s", h.Name))
+		}
+	}
+
+	return errors.Join(errs...)
+}
@@ -0,0 +1,54 @@
+package app_test
+
+import (
+	"context"
+	"errors"
+	"go02/app"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestLifecycle(t *testing.T) {
+	var calls []string
+	hook := func(name string, startErr error) app.Hook {
+		return app.Hook{
+			Name: name,
+			OnStart: func(context.Context) error {
+				calls = append(calls, "start "+name)
+				return startErr
+			},
+			OnStop: func(context.Context) error {
+				calls = append(calls, "stop "+name)
+				return nil
+			},
+		}
+	}
+
+	t.Run("正常系: 登録順に開始し逆順に停止する", func(t *testing.T) {
+		calls = nil
+		var l app.Lifecycle
+		l.Append(hook("a", nil))
+		l.Append(hook("b", nil))
+
+		assert.NoError(t, l.Start(context.Background()))
+		assert.NoError(t, l.Stop(context.Background()))
+		assert.Equal(t, []string{"start a", "start b", "stop b", "stop a"}, calls)
+	})
+
+	t.Run("異常系: 開始に失敗した場合は開始済みのものだけを停止する", func(t *testing.T) {
+		calls = nil
+		var l app.Lifecycle
+		l.Append(hook("a", nil))
+		l.Append(hook("b", errors.New("boom")))
+		l.Append(hook("c", nil))
+
+		err := l.Start(context.Background())
+		assert.ErrorContains(t, err, "failed to start b: boom")
+		assert.Equal(t, []string{"start a", "start b", "stop a"}, calls)
+
+		// already stopped
+		assert.NoError(t, l.Stop(context.Background()))
+		assert.Len(t, calls, 3)
+	})
+}
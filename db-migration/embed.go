@@ -0,0 +1,56 @@
+// Package dbmigration embeds the SQL migrations and seed data so that they
+// ship with the go02 binary.
+package dbmigration
+
+import (
+	"embed"
+	"fmt"
+	"io/fs"
+
+	"github.com/golang-migrate/migrate/v4/source"
+)
+
+//go:embed migrations/*.sql
+var migrations embed.FS
+
+//go:embed seeds/*.sql
+var seeds embed.FS
+
+// Migrations returns the golang-migrate files at the root of the FS.
+func Migrations() fs.FS {
+	sub, err := fs.Sub(migrations, "migrations")
+	if err != nil {
+		panic(err)
+	}
+	return sub
+}
+
+// LatestVersion returns the highest version among the embedded migrations,
+// which is the version a fully migrated database is at.
+func LatestVersion() (uint, error) {
+	entries, err := fs.ReadDir(Migrations(), ".")
+	if err != nil {
+		return 0, err
+	}
+
+	var latest uint
+	for _, entry := range entries {
+		m, err := source.Parse(entry.Name())
+		if err != nil {
+			return 0, fmt.Errorf("parse migration %s: %w", entry.Name(), err)
+		}
+		latest = max(latest, m.Version)
+	}
+
+	return latest, nil
+}
+
+// Seeds returns the SQL files that fill a development database with sample
+// data. They are applied in name order.
+func Seeds() fs.FS {
+	sub, err := fs.Sub(seeds, "seeds")
+	if err != nil {
+		panic(err)
+	}
+	return sub
+}
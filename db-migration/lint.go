@@ -0,0 +1,315 @@
+package dbmigration
+
+import (
+	"fmt"
+	"io/fs"
+	"regexp"
+	"sort"
+	"strings"
+)
+
+// Lint rules. A statement can opt out of a rule with a comment on the lines
+// right above it:
+//
+//	-- lint:ignore drop-column the column has been unused since v1.4
+const (
+	RuleIndexNotConcurrent   = "index-not-concurrent"
+	RuleConcurrentlyNotAlone = "concurrently-not-alone"
+	RuleNotNullNoDefault     = "not-null-without-default"
+	RuleSetNotNull           = "set-not-null"
+	RuleRename               = "rename"
+	RuleDropColumn           = "drop-column"
+	RuleDropTable            = "drop-table"
+	RuleTypeChange           = "type-change"
+	RuleConstraintNotValid   = "constraint-not-valid"
+	RuleMissingDown          = "missing-down"
+)
+
+// Finding is an operation that is unsafe while old and new pods run side by
+// side during a rolling update.
+type Finding struct {
+	File    string `json:"file"`
+	Line    int    `json:"line"`
+	Rule    string `json:"rule"`
+	Message string `json:"message"`
+}
+
+func (f Finding) String() string {
+	return fmt.Sprintf("%s:%d: %s: %s", f.File, f.Line, f.Rule, f.Message)
+}
+
+// LintMigrations lints the embedded migrations.
+func LintMigrations() ([]Finding, error) {
+	return Lint(Migrations())
+}
+
+// Lint checks the *.up.sql files at the root of fsys. Down migrations are
+// rollbacks and are expected to be destructive, so they are only checked for
+// existence.
+func Lint(fsys fs.FS) ([]Finding, error) {
+	ups, err := fs.Glob(fsys, "*.up.sql")
+	if err != nil {
+		return nil, err
+	}
+	sort.Strings(ups)
+
+	var findings []Finding
+	for _, name := range ups {
+		down := strings.TrimSuffix(name, ".up.sql") + ".down.sql"
+		if _, err := fs.Stat(fsys, down); err != nil {
+			findings = append(findings, Finding{
+				File:    name,
+				Line:    1,
+				Rule:    RuleMissingDown,
+				Message: fmt.Sprintf("%s is missing, the migration can't be rolled back", down),
+			})
+		}
+
+		b, err := fs.ReadFile(fsys, name)
+		if err != nil {
+			return nil, err
+		}
+		findings = append(findings, lintFile(name, string(b))...)
+	}
+
+	return findings, nil
+}
+
+var (
+	createTablePattern = regexp.MustCompile(`^create\s+(?:unlogged\s+)?table\s+(?:if\s+not\s+exists\s+)?([\w."]+)`)
+	createIndexPattern = regexp.MustCompile(`^create\s+(?:unique\s+)?index\s+(concurrently\s+)?(?:if\s+not\s+exists\s+)?(?:[\w"]+\s+)?on\s+(?:only\s+)?([\w."]+)`)
+	dropIndexPattern   = regexp.MustCompile(`^drop\s+index\s+(concurrently\s+)?`)
+	dropTablePattern   = regexp.MustCompile(`^drop\s+table\b`)
+	alterTablePattern  = regexp.MustCompile(`^alter\s+table\s+(?:if\s+exists\s+)?(?:only\s+)?([\w."]+)\s+(.*)$`)
+
+	addColumnPattern     = regexp.MustCompile(`^add\s+(?:column\s+)?(?:if\s+not\s+exists\s+)?[\w"]+\s`)
+	addConstraintPattern = regexp.MustCompile(`^add\s+(?:constraint|primary|unique|foreign|check|exclude)\b`)
+	notNullPattern       = regexp.MustCompile(`\bnot\s+null\b`)
+	defaultPattern       = regexp.MustCompile(`\bdefault\b`)
+	setNotNullPattern    = regexp.MustCompile(`^alter\s+(?:column\s+)?[\w"]+\s+set\s+not\s+null\b`)
+	typeChangePattern    = regexp.MustCompile(`^alter\s+(?:column\s+)?[\w"]+\s+(?:set\s+data\s+)?type\b`)
+	renamePattern        = regexp.MustCompile(`^rename\s+(?:column\s+|constraint\s+)?(?:[\w"]+\s+)?to\b`)
+	dropPattern          = regexp.MustCompile(`^drop\s+(?:column\s+)?(?:if\s+exists\s+)?([\w"]+)`)
+	constraintPattern    = regexp.MustCompile(`^add\s+(?:constraint\s+[\w"]+\s+)?(?:foreign\s+key|check)\b`)
+	notValidPattern      = regexp.MustCompile(`\bnot\s+valid\b`)
+)
+
+func lintFile(name, sql string) []Finding {
+	stmts := splitStatements(sql)
+
+	// tables created in this migration are empty and not yet used by the old
+	// pods, so anything goes for them
+	created := map[string]bool{}
+	for _, s := range stmts {
+		if m := createTablePattern.FindStringSubmatch(s.text); m != nil {
+			created[tableName(m[1])] = true
+		}
+	}
+
+	var findings []Finding
+	for _, s := range stmts {
+		report := func(rule, format string, args ...any) {
+			if s.ignore[rule] {
+				return
+			}
+			findings = append(findings, Finding{
+				File:    name,
+				Line:    s.line,
+				Rule:    rule,
+				Message: fmt.Sprintf(format, args...),
+			})
+		}
+
+		switch {
+		case createIndexPattern.MatchString(s.text):
+			m := createIndexPattern.FindStringSubmatch(s.text)
+			concurrent, table := m[1] != "", tableName(m[2])
+			if !concurrent && !created[table] {
+				report(RuleIndexNotConcurrent, "CREATE INDEX on %s blocks writes while it builds, use CREATE INDEX CONCURRENTLY", table)
+			}
+			if concurrent && len(stmts) > 1 {
+				report(RuleConcurrentlyNotAlone, "CREATE INDEX CONCURRENTLY can't run in a transaction, move it to a migration of its own")
+			}
+
+		case dropIndexPattern.MatchString(s.text):
+			if dropIndexPattern.FindStringSubmatch(s.text)[1] == "" {
+				report(RuleIndexNotConcurrent, "DROP INDEX blocks the table, use DROP INDEX CONCURRENTLY")
+			} else if len(stmts) > 1 {
+				report(RuleConcurrentlyNotAlone, "DROP INDEX CONCURRENTLY can't run in a transaction, move it to a migration of its own")
+			}
+
+		case dropTablePattern.MatchString(s.text):
+			report(RuleDropTable, "the old pods still query the table; stop using it in one release and drop it in a later one")
+
+		case alterTablePattern.MatchString(s.text):
+			m := alterTablePattern.FindStringSubmatch(s.text)
+			table := tableName(m[1])
+			if created[table] {
+				continue
+			}
+			for _, action := range splitTopLevel(m[2]) {
+				lintAlterAction(table, action, report)
+			}
+		}
+	}
+
+	return findings
+}
+
+func lintAlterAction(table, action string, report func(rule, format string, args ...any)) {
+	switch {
+	case constraintPattern.MatchString(action):
+		if !notValidPattern.MatchString(action) {
+			report(RuleConstraintNotValid, "adding a constraint to %s scans it under lock; add it NOT VALID and VALIDATE CONSTRAINT in a separate migration", table)
+		}
+	case addColumnPattern.MatchString(action) && !addConstraintPattern.MatchString(action):
+		if notNullPattern.MatchString(action) && !defaultPattern.MatchString(action) {
+			report(RuleNotNullNoDefault, "adding a NOT NULL column without a default to %s fails for existing rows and for inserts from the old pods", table)
+		}
+	case setNotNullPattern.MatchString(action):
+		report(RuleSetNotNull, "SET NOT NULL scans %s under an ACCESS EXCLUSIVE lock; add a CHECK (... IS NOT NULL) NOT VALID constraint and validate it first", table)
+	case typeChangePattern.MatchString(action):
+		report(RuleTypeChange, "changing a column type of %s rewrites the table under an ACCESS EXCLUSIVE lock and may break the old pods", table)
+	case renamePattern.MatchString(action):
+		report(RuleRename, "the old pods still use the old name; add the new column or table, backfill and drop the old one in a later release")
+	case dropPattern.MatchString(action):
+		switch dropPattern.FindStringSubmatch(action)[1] {
+		case "constraint", "default", "not", "identity", "expression":
+		default:
+			report(RuleDropColumn, "the old pods still read the column; stop using it in one release and drop it in a later one")
+		}
+	}
+}
+
+func tableName(name string) string {
+	name = strings.ReplaceAll(name, `"`, "")
+	if i := strings.LastIndex(name, "."); i >= 0 {
+		name = name[i+1:]
+	}
+	return name
+}
+
+type statement struct {
+	// text is lower case with whitespace collapsed
+	text   string
+	line   int
+	ignore map[string]bool
+}
+
+var ignorePattern = regexp.MustCompile(`^--\s*lint:ignore\s+([\w,-]+)`)
+
+// splitStatements splits sql on semicolons outside of quotes, dollar quotes
+// and comments. lint:ignore comments apply to the statement that follows.
+func splitStatements(sql string) []statement {
+	var (
+		stmts   []statement
+		buf     strings.Builder
+		line    = 1
+		start   = 0
+		ignore  = map[string]bool{}
+		dollar  string
+		inQuote byte
+	)
+
+	flush := func() {
+		text := strings.ToLower(strings.Join(strings.Fields(buf.String()), " "))
+		if text != "" {
+			stmts = append(stmts, statement{text: text, line: start, ignore: ignore})
+		}
+		buf.Reset()
+		ignore = map[string]bool{}
+		start = 0
+	}
+
+	for i := 0; i < len(sql); i++ {
+		c := sql[i]
+		if c == '\n' {
+			line++
+		}
+
+		switch {
+		case dollar != "":
+			if strings.HasPrefix(sql[i:], dollar) {
+				buf.WriteString(dollar)
+				i += len(dollar) - 1
+				dollar = ""
+				continue
+			}
+		case inQuote != 0:
+			if c == inQuote {
+				inQuote = 0
+			}
+		case c == '-' && strings.HasPrefix(sql[i:], "--"):
+			end := strings.IndexByte(sql[i:], '\n')
+			if end < 0 {
+				end = len(sql) - i
+			}
+			if m := ignorePattern.FindStringSubmatch(sql[i : i+end]); m != nil {
+				for _, rule := range strings.Split(m[1], ",") {
+					ignore[rule] = true
+				}
+			}
+			i += end - 1
+			continue
+		case c == '/' && strings.HasPrefix(sql[i:], "/*"):
+			end := strings.Index(sql[i:], "*/")
+			if end < 0 {
+				end = len(sql) - i - 2
+			}
+			line += strings.Count(sql[i:i+end+2], "\n")
+			i += end + 1
+			buf.WriteByte(' ')
+			continue
+		case c == '\'' || c == '"':
+			inQuote = c
+		case c == '$':
+			if m := dollarTag.FindString(sql[i:]); m != "" {
+				dollar = m
+				buf.WriteString(m)
+				i += len(m) - 1
+				continue
+			}
+		case c == ';':
+			flush()
+			continue
+		}
+
+		if start == 0 && !isSpace(c) {
+			start = line
+		}
+		buf.WriteByte(c)
+	}
+	flush()
+
+	return stmts
+}
+
+var dollarTag = regexp.MustCompile(`^\$[A-Za-z_]*\$`)
+
+func isSpace(c byte) bool {
+	return c == ' ' || c == '\t' || c == '\n' || c == '\r'
+}
+
+// splitTopLevel splits the actions of an ALTER TABLE on commas outside of
+// parentheses.
+func splitTopLevel(s string) []string {
+	var (
+		parts []string
+		depth int
+		last  int
+	)
+	for i, c := range s {
+		switch c {
+		case '(':
+			depth++
+		case ')':
+			depth--
+		case ',':
+			if depth == 0 {
+				parts = append(parts, strings.TrimSpace(s[last:i]))
+				last = i + 1
+			}
+		}
+	}
+	return append(parts, strings.TrimSpace(s[last:]))
+}
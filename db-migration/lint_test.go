@@ -0,0 +1,110 @@
+package dbmigration
+
+import (
+	"fmt"
+	"testing"
+	"testing/fstest"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestMigrationsAreSafe(t *testing.T) {
+	findings, err := LintMigrations()
+	require.NoError(t, err)
+
+	for _, f := range findings {
+		t.Error(f)
+	}
+}
+
+func TestLint(t *testing.T) {
+	file := func(s string) *fstest.MapFile { return &fstest.MapFile{Data: []byte(s)} }
+
+	tests := []struct {
+		name string
+		up   string
+		want []string
+	}{
+		{
+			name: "正常系: 新しいテーブルへの操作は許可",
+			up: `CREATE TABLE groups (id BIGSERIAL PRIMARY KEY, name TEXT NOT NULL);
+CREATE INDEX groups_name_idx ON groups (name);
+ALTER TABLE groups ADD COLUMN owner_id BIGINT NOT NULL;`,
+		},
+		{
+			name: "正常系: CONCURRENTLY は単独のファイルなら許可",
+			up:   `CREATE UNIQUE INDEX CONCURRENTLY IF NOT EXISTS users_name_idx ON users (lower(name));`,
+		},
+		{
+			name: "正常系: デフォルト付きの NOT NULL カラム追加は許可",
+			up:   `ALTER TABLE users ADD COLUMN active BOOLEAN NOT NULL DEFAULT true, ALTER COLUMN name DROP DEFAULT;`,
+		},
+		{
+			name: "正常系: lint:ignore で除外",
+			up: `-- lint:ignore drop-column,rename the column has been unused since v1.4
+ALTER TABLE users DROP COLUMN age, RENAME COLUMN name TO full_name;`,
+		},
+		{
+			name: "正常系: コメントと文字列の中は無視",
+			up: `/* DROP TABLE users; */
+-- ALTER TABLE users DROP COLUMN age;
+INSERT INTO users (name) VALUES ('DROP TABLE users;');`,
+		},
+		{
+			name: "異常系: CONCURRENTLY でないインデックス",
+			up:   "ALTER TABLE users ADD COLUMN email TEXT;\n\nCREATE INDEX users_email_idx ON public.users (email);",
+			want: []string{"3 " + RuleIndexNotConcurrent},
+		},
+		{
+			name: "異常系: CONCURRENTLY が他の文と同じファイル",
+			up:   "ALTER TABLE users ADD COLUMN email TEXT;\nCREATE INDEX CONCURRENTLY users_email_idx ON users (email);",
+			want: []string{"2 " + RuleConcurrentlyNotAlone},
+		},
+		{
+			name: "異常系: 危険な ALTER TABLE",
+			up: `ALTER TABLE users
+  ADD COLUMN email TEXT NOT NULL,
+  ALTER COLUMN age TYPE INTEGER,
+  ALTER COLUMN name SET NOT NULL,
+  ADD CONSTRAINT users_age_check CHECK (age >= 0),
+  DROP COLUMN deleted_at;
+ALTER TABLE profiles RENAME TO user_profiles;
+DROP TABLE IF EXISTS legacy;`,
+			want: []string{
+				"1 " + RuleNotNullNoDefault,
+				"1 " + RuleTypeChange,
+				"1 " + RuleSetNotNull,
+				"1 " + RuleConstraintNotValid,
+				"1 " + RuleDropColumn,
+				"7 " + RuleRename,
+				"8 " + RuleDropTable,
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			findings, err := Lint(fstest.MapFS{
+				"000001_test.up.sql":   file(tt.up),
+				"000001_test.down.sql": file(""),
+			})
+			require.NoError(t, err)
+
+			var got []string
+			for _, f := range findings {
+				got = append(got, fmt.Sprintf("%d %s", f.Line, f.Rule))
+			}
+			assert.Equal(t, tt.want, got)
+		})
+	}
+
+	t.Run("異常系: down がない", func(t *testing.T) {
+		findings, err := Lint(fstest.MapFS{
+			"000001_test.up.sql": file("CREATE TABLE groups (id BIGSERIAL PRIMARY KEY);"),
+		})
+		require.NoError(t, err)
+		require.Len(t, findings, 1)
+		assert.Equal(t, RuleMissingDown, findings[0].Rule)
+	})
+}
@@ -0,0 +1,15 @@
+-- The app and the database both run in UTC, so the existing values are UTC
+-- wall clock times. They are converted explicitly as UTC, whatever the time
+-- zone of the session, which makes PostgreSQL rewrite the tables.
+-- Old pods keep working because bun writes time.Time with its offset.
+
+-- lint:ignore type-change existing values are converted as UTC
+ALTER TABLE users
+  ALTER COLUMN created_at TYPE TIMESTAMP WITH TIME ZONE USING created_at AT TIME ZONE 'UTC',
+  ALTER COLUMN updated_at TYPE TIMESTAMP WITH TIME ZONE USING updated_at AT TIME ZONE 'UTC',
+  ALTER COLUMN deleted_at TYPE TIMESTAMP WITH TIME ZONE USING deleted_at AT TIME ZONE 'UTC';
+
+-- lint:ignore type-change existing values are converted as UTC
+ALTER TABLE profiles
+  ALTER COLUMN created_at TYPE TIMESTAMP WITH TIME ZONE USING created_at AT TIME ZONE 'UTC',
+  ALTER COLUMN updated_at TYPE TIMESTAMP WITH TIME ZONE USING updated_at AT TIME ZONE 'UTC';
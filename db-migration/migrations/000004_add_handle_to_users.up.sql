@@ -0,0 +1,2 @@
+-- nullable: users created before handles existed have none yet
+ALTER TABLE users ADD COLUMN handle VARCHAR(30);
@@ -0,0 +1 @@
+DROP INDEX CONCURRENTLY IF EXISTS users_handle_key;
@@ -0,0 +1,2 @@
+-- handles are unique regardless of case, and looked up with lower(handle)
+CREATE UNIQUE INDEX CONCURRENTLY users_handle_key ON users (lower(handle));
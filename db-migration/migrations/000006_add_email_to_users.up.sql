@@ -0,0 +1,4 @@
+-- nullable: existing users have no email yet
+ALTER TABLE users
+  ADD COLUMN email VARCHAR(254),
+  ADD COLUMN email_verified_at TIMESTAMP WITH TIME ZONE;
@@ -0,0 +1,3 @@
+-- emails are stored normalized, lower() keeps them unique regardless of case
+-- for rows written before normalization or by hand
+CREATE UNIQUE INDEX CONCURRENTLY users_email_key ON users (lower(email));
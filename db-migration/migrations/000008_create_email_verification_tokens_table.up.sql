@@ -0,0 +1,15 @@
+-- only the sha256 of a token is stored, the token itself is in the email
+CREATE TABLE email_verification_tokens (
+  id BIGSERIAL NOT NULL,
+  user_id BIGINT NOT NULL,
+  email VARCHAR(254) NOT NULL,
+  token_hash BYTEA NOT NULL,
+  expires_at TIMESTAMP WITH TIME ZONE NOT NULL,
+  used_at TIMESTAMP WITH TIME ZONE NULL DEFAULT NULL,
+  created_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT CURRENT_TIMESTAMP,
+  PRIMARY KEY (id),
+  FOREIGN KEY (user_id) REFERENCES users(id) ON DELETE CASCADE
+);
+
+CREATE UNIQUE INDEX email_verification_tokens_token_hash_key ON email_verification_tokens (token_hash);
+CREATE INDEX email_verification_tokens_user_id_idx ON email_verification_tokens (user_id);
@@ -0,0 +1,11 @@
+-- password_hash is an argon2id hash in the PHC string format
+CREATE TABLE credentials (
+  user_id BIGINT NOT NULL,
+  password_hash TEXT NOT NULL,
+  failed_attempts BIGINT NOT NULL DEFAULT 0,
+  locked_until TIMESTAMP WITH TIME ZONE NULL DEFAULT NULL,
+  created_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT CURRENT_TIMESTAMP,
+  updated_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT CURRENT_TIMESTAMP,
+  PRIMARY KEY (user_id),
+  FOREIGN KEY (user_id) REFERENCES users(id) ON DELETE CASCADE
+);
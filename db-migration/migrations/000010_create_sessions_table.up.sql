@@ -0,0 +1,18 @@
+-- refresh tokens are stored as sha256 hashes. The previous one is kept to
+-- detect a rotated token being used again, which revokes the session.
+CREATE TABLE sessions (
+  id BIGSERIAL NOT NULL,
+  user_id BIGINT NOT NULL,
+  refresh_token_hash BYTEA NOT NULL,
+  previous_refresh_token_hash BYTEA NULL DEFAULT NULL,
+  expires_at TIMESTAMP WITH TIME ZONE NOT NULL,
+  revoked_at TIMESTAMP WITH TIME ZONE NULL DEFAULT NULL,
+  created_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT CURRENT_TIMESTAMP,
+  updated_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT CURRENT_TIMESTAMP,
+  PRIMARY KEY (id),
+  FOREIGN KEY (user_id) REFERENCES users(id) ON DELETE CASCADE
+);
+
+CREATE UNIQUE INDEX sessions_refresh_token_hash_key ON sessions (refresh_token_hash);
+CREATE INDEX sessions_previous_refresh_token_hash_idx ON sessions (previous_refresh_token_hash);
+CREATE INDEX sessions_user_id_idx ON sessions (user_id);
@@ -0,0 +1,15 @@
+-- slug names the organization in subdomains and the X-Organization header
+CREATE TABLE organizations (
+  id BIGSERIAL NOT NULL,
+  slug VARCHAR(63) NOT NULL,
+  name VARCHAR(255) NOT NULL,
+  created_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT CURRENT_TIMESTAMP,
+  updated_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT CURRENT_TIMESTAMP,
+  PRIMARY KEY (id)
+);
+
+CREATE UNIQUE INDEX organizations_slug_key ON organizations (slug);
+
+-- the rows that exist today belong to the default organization
+INSERT INTO organizations (id, slug, name) VALUES (1, 'default', 'Default');
+SELECT setval('organizations_id_seq', 1);
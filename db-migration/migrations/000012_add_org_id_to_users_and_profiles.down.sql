@@ -0,0 +1,2 @@
+ALTER TABLE profiles DROP COLUMN org_id;
+ALTER TABLE users DROP COLUMN org_id;
@@ -0,0 +1,10 @@
+-- the default keeps inserts from pods that don't know about organizations
+-- working during the rollout
+ALTER TABLE users ADD COLUMN org_id BIGINT NOT NULL DEFAULT 1;
+ALTER TABLE profiles ADD COLUMN org_id BIGINT NOT NULL DEFAULT 1;
+
+-- validated in the next migration, without blocking writes
+ALTER TABLE users ADD CONSTRAINT users_org_id_fkey
+  FOREIGN KEY (org_id) REFERENCES organizations(id) NOT VALID;
+ALTER TABLE profiles ADD CONSTRAINT profiles_org_id_fkey
+  FOREIGN KEY (org_id) REFERENCES organizations(id) NOT VALID;
@@ -0,0 +1 @@
+-- nothing to undo, 000012 drops the constraints with the columns
@@ -0,0 +1,2 @@
+ALTER TABLE users VALIDATE CONSTRAINT users_org_id_fkey;
+ALTER TABLE profiles VALIDATE CONSTRAINT profiles_org_id_fkey;
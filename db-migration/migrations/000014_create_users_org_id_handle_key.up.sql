@@ -0,0 +1,3 @@
+-- handles are unique within an organization; org_id first also serves the
+-- tenant filter on every query and the foreign key
+CREATE UNIQUE INDEX CONCURRENTLY users_org_id_handle_key ON users (org_id, lower(handle));
@@ -0,0 +1 @@
+DROP INDEX CONCURRENTLY IF EXISTS users_org_id_email_key;
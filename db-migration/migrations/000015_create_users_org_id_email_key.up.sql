@@ -0,0 +1,2 @@
+-- emails are unique within an organization
+CREATE UNIQUE INDEX CONCURRENTLY users_org_id_email_key ON users (org_id, lower(email));
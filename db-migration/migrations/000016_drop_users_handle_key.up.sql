@@ -0,0 +1,2 @@
+-- replaced by users_org_id_handle_key
+DROP INDEX CONCURRENTLY IF EXISTS users_handle_key;
@@ -0,0 +1,2 @@
+-- replaced by users_org_id_email_key
+DROP INDEX CONCURRENTLY IF EXISTS users_email_key;
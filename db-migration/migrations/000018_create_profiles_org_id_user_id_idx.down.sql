@@ -0,0 +1 @@
+DROP INDEX CONCURRENTLY IF EXISTS profiles_org_id_user_id_idx;
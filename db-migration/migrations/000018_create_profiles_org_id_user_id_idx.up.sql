@@ -0,0 +1,2 @@
+-- serves the tenant filter and the foreign key to organizations
+CREATE INDEX CONCURRENTLY profiles_org_id_user_id_idx ON profiles (org_id, user_id);
@@ -0,0 +1,5 @@
+DROP POLICY IF EXISTS profiles_tenant_isolation ON profiles;
+ALTER TABLE profiles DISABLE ROW LEVEL SECURITY;
+
+DROP POLICY IF EXISTS users_tenant_isolation ON users;
+ALTER TABLE users DISABLE ROW LEVEL SECURITY;
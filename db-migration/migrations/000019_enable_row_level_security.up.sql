@@ -0,0 +1,11 @@
+-- Defence in depth behind the tenant scoping of the repositories. The owner
+-- of the tables bypasses the policies, so they only apply when the api
+-- connects as another role with DB_ROW_LEVEL_SECURITY=true, which sets
+-- app.org_id for every request. Without the setting no rows are visible.
+ALTER TABLE users ENABLE ROW LEVEL SECURITY;
+CREATE POLICY users_tenant_isolation ON users
+  USING (org_id = NULLIF(current_setting('app.org_id', true), '')::bigint);
+
+ALTER TABLE profiles ENABLE ROW LEVEL SECURITY;
+CREATE POLICY profiles_tenant_isolation ON profiles
+  USING (org_id = NULLIF(current_setting('app.org_id', true), '')::bigint);
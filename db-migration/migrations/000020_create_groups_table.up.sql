@@ -0,0 +1,17 @@
+CREATE TABLE groups (
+  id BIGSERIAL NOT NULL,
+  org_id BIGINT NOT NULL,
+  name VARCHAR(100) NOT NULL,
+  description TEXT NOT NULL DEFAULT '',
+  created_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT CURRENT_TIMESTAMP,
+  updated_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT CURRENT_TIMESTAMP,
+  PRIMARY KEY (id),
+  FOREIGN KEY (org_id) REFERENCES organizations(id)
+);
+
+-- names are unique within an organization regardless of case
+CREATE UNIQUE INDEX groups_org_id_name_key ON groups (org_id, lower(name));
+
+ALTER TABLE groups ENABLE ROW LEVEL SECURITY;
+CREATE POLICY groups_tenant_isolation ON groups
+  USING (org_id = NULLIF(current_setting('app.org_id', true), '')::bigint);
@@ -0,0 +1,21 @@
+CREATE TABLE group_memberships (
+  group_id BIGINT NOT NULL,
+  user_id BIGINT NOT NULL,
+  org_id BIGINT NOT NULL,
+  role VARCHAR(20) NOT NULL DEFAULT 'member',
+  created_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT CURRENT_TIMESTAMP,
+  updated_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT CURRENT_TIMESTAMP,
+  PRIMARY KEY (group_id, user_id),
+  FOREIGN KEY (group_id) REFERENCES groups(id) ON DELETE CASCADE,
+  FOREIGN KEY (user_id) REFERENCES users(id) ON DELETE CASCADE,
+  FOREIGN KEY (org_id) REFERENCES organizations(id),
+  CHECK (role IN ('owner', 'admin', 'member'))
+);
+
+CREATE INDEX group_memberships_user_id_idx ON group_memberships (user_id);
+-- the groups of a user, with the tenant filter
+CREATE INDEX group_memberships_org_id_user_id_idx ON group_memberships (org_id, user_id);
+
+ALTER TABLE group_memberships ENABLE ROW LEVEL SECURITY;
+CREATE POLICY group_memberships_tenant_isolation ON group_memberships
+  USING (org_id = NULLIF(current_setting('app.org_id', true), '')::bigint);
@@ -0,0 +1,20 @@
+CREATE TABLE user_follows (
+  follower_id BIGINT NOT NULL,
+  followee_id BIGINT NOT NULL,
+  org_id BIGINT NOT NULL,
+  created_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT CURRENT_TIMESTAMP,
+  PRIMARY KEY (follower_id, followee_id),
+  FOREIGN KEY (follower_id) REFERENCES users(id) ON DELETE CASCADE,
+  FOREIGN KEY (followee_id) REFERENCES users(id) ON DELETE CASCADE,
+  FOREIGN KEY (org_id) REFERENCES organizations(id),
+  CONSTRAINT user_follows_no_self_follow CHECK (follower_id <> followee_id)
+);
+
+-- the following and followers of a user, newest first, for keyset pagination
+CREATE INDEX user_follows_follower_id_created_at_idx ON user_follows (follower_id, created_at DESC, followee_id DESC);
+CREATE INDEX user_follows_followee_id_created_at_idx ON user_follows (followee_id, created_at DESC, follower_id DESC);
+CREATE INDEX user_follows_org_id_idx ON user_follows (org_id);
+
+ALTER TABLE user_follows ENABLE ROW LEVEL SECURITY;
+CREATE POLICY user_follows_tenant_isolation ON user_follows
+  USING (org_id = NULLIF(current_setting('app.org_id', true), '')::bigint);
@@ -0,0 +1,17 @@
+-- follower and following counts, recomputed from user_follows periodically so
+-- that following a popular user doesn't update a hot row
+CREATE TABLE user_follow_counts (
+  user_id BIGINT PRIMARY KEY,
+  org_id BIGINT NOT NULL,
+  followers_count BIGINT NOT NULL DEFAULT 0,
+  following_count BIGINT NOT NULL DEFAULT 0,
+  updated_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT CURRENT_TIMESTAMP,
+  FOREIGN KEY (user_id) REFERENCES users(id) ON DELETE CASCADE,
+  FOREIGN KEY (org_id) REFERENCES organizations(id)
+);
+
+CREATE INDEX user_follow_counts_org_id_idx ON user_follow_counts (org_id);
+
+ALTER TABLE user_follow_counts ENABLE ROW LEVEL SECURITY;
+CREATE POLICY user_follow_counts_tenant_isolation ON user_follow_counts
+  USING (org_id = NULLIF(current_setting('app.org_id', true), '')::bigint);
@@ -0,0 +1,5 @@
+ALTER TABLE profiles
+  DROP CONSTRAINT IF EXISTS profiles_bio_not_null,
+  DROP CONSTRAINT IF EXISTS profiles_avatar_url_not_null,
+  ALTER COLUMN bio DROP DEFAULT,
+  ALTER COLUMN avatar_url DROP DEFAULT;
@@ -0,0 +1,14 @@
+-- NULL and '' both mean "not set" and the api only ever writes ''. The
+-- columns become NOT NULL in the next migration, which validates the checks
+-- without blocking writes first.
+ALTER TABLE profiles
+  ALTER COLUMN bio SET DEFAULT '',
+  ALTER COLUMN avatar_url SET DEFAULT '';
+
+UPDATE profiles
+SET bio = COALESCE(bio, ''), avatar_url = COALESCE(avatar_url, '')
+WHERE bio IS NULL OR avatar_url IS NULL;
+
+ALTER TABLE profiles
+  ADD CONSTRAINT profiles_bio_not_null CHECK (bio IS NOT NULL) NOT VALID,
+  ADD CONSTRAINT profiles_avatar_url_not_null CHECK (avatar_url IS NOT NULL) NOT VALID;
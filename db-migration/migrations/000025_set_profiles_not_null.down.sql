@@ -0,0 +1,5 @@
+ALTER TABLE profiles
+  ALTER COLUMN bio DROP NOT NULL,
+  ALTER COLUMN avatar_url DROP NOT NULL,
+  ADD CONSTRAINT profiles_bio_not_null CHECK (bio IS NOT NULL) NOT VALID,
+  ADD CONSTRAINT profiles_avatar_url_not_null CHECK (avatar_url IS NOT NULL) NOT VALID;
@@ -0,0 +1,12 @@
+ALTER TABLE profiles
+  VALIDATE CONSTRAINT profiles_bio_not_null,
+  VALIDATE CONSTRAINT profiles_avatar_url_not_null;
+
+-- lint:ignore set-not-null the validated checks spare the scan
+ALTER TABLE profiles
+  ALTER COLUMN bio SET NOT NULL,
+  ALTER COLUMN avatar_url SET NOT NULL;
+
+ALTER TABLE profiles
+  DROP CONSTRAINT profiles_bio_not_null,
+  DROP CONSTRAINT profiles_avatar_url_not_null;
@@ -0,0 +1 @@
+DROP INDEX CONCURRENTLY IF EXISTS profiles_user_id_idx;
@@ -0,0 +1,2 @@
+-- serves the cascade from users, which profiles_org_id_user_id_idx can't
+CREATE INDEX CONCURRENTLY profiles_user_id_idx ON profiles (user_id);
@@ -0,0 +1 @@
+DROP TABLE user_follow_count_changes;
@@ -0,0 +1,21 @@
+-- users whose follows changed since their counts were last reconciled, so
+-- that the reconciliation recounts only them. Rows are only ever appended, a
+-- user once per change, so concurrent follows don't wait on each other.
+CREATE TABLE user_follow_count_changes (
+  id BIGSERIAL PRIMARY KEY,
+  user_id BIGINT NOT NULL,
+  org_id BIGINT NOT NULL,
+  changed_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT CURRENT_TIMESTAMP,
+  FOREIGN KEY (user_id) REFERENCES users(id) ON DELETE CASCADE,
+  FOREIGN KEY (org_id) REFERENCES organizations(id)
+);
+
+CREATE INDEX user_follow_count_changes_org_id_idx ON user_follow_count_changes (org_id);
+
+ALTER TABLE user_follow_count_changes ENABLE ROW LEVEL SECURITY;
+CREATE POLICY user_follow_count_changes_tenant_isolation ON user_follow_count_changes
+  USING (org_id = NULLIF(current_setting('app.org_id', true), '')::bigint);
+
+-- the first reconciliation recounts everyone
+INSERT INTO user_follow_count_changes (user_id, org_id)
+SELECT id, org_id FROM users WHERE deleted_at IS NULL;
@@ -0,0 +1,30 @@
+-- a second organization, try it with the header X-Organization: acme
+INSERT INTO organizations (id, slug, name) VALUES
+  (2, 'acme', 'Acme')
+ON CONFLICT (id) DO NOTHING;
+
+INSERT INTO users (id, org_id, name, handle, age) VALUES
+  (1, 1, 'Alice', 'alice', 30),
+  (2, 1, 'Bob', 'bob', 25),
+  (3, 1, 'Carol', 'carol', 41),
+  (4, 2, 'Alice', 'alice', 28)
+ON CONFLICT (id) DO NOTHING;
+
+INSERT INTO profiles (id, org_id, user_id, bio, avatar_url) VALUES
+  (1, 1, 1, 'Backend engineer', 'https://example.com/avatars/alice.png'),
+  (2, 1, 2, 'Likes Go and Postgres', ''),
+  (3, 1, 3, '', ''),
+  (4, 2, 4, 'Works at Acme', '')
+ON CONFLICT (id) DO NOTHING;
+
+-- every sample user logs in with the password "go02 dev password"
+INSERT INTO credentials (user_id, password_hash) VALUES
+  (1, '$argon2id$v=19$m=19456,t=2,p=1$fHBWwaQPfYOkuiVoL0D3pA$999vQKac4gidYGM0Ye77VHfVxk8NYsT7W/NeEH16xWQ'),
+  (2, '$argon2id$v=19$m=19456,t=2,p=1$fHBWwaQPfYOkuiVoL0D3pA$999vQKac4gidYGM0Ye77VHfVxk8NYsT7W/NeEH16xWQ'),
+  (3, '$argon2id$v=19$m=19456,t=2,p=1$fHBWwaQPfYOkuiVoL0D3pA$999vQKac4gidYGM0Ye77VHfVxk8NYsT7W/NeEH16xWQ'),
+  (4, '$argon2id$v=19$m=19456,t=2,p=1$fHBWwaQPfYOkuiVoL0D3pA$999vQKac4gidYGM0Ye77VHfVxk8NYsT7W/NeEH16xWQ')
+ON CONFLICT (user_id) DO NOTHING;
+
+SELECT setval('organizations_id_seq', (SELECT MAX(id) FROM organizations));
+SELECT setval('users_id_seq', (SELECT MAX(id) FROM users));
+SELECT setval('profiles_id_seq', (SELECT MAX(id) FROM profiles));
@@ -0,0 +1,60 @@
+package handler
+
+import (
+	"net/http"
+
+	"go02/packages/logging"
+
+	"github.com/labstack/echo/v4"
+)
+
+type AdminHandler interface {
+	GetLogLevel(c echo.Context) error
+	UpdateLogLevel(c echo.Context) error
+}
+
+type adminHandler struct {
+	logger *logging.Logger
+}
+
+func NewAdminHandler(logger *logging.Logger) AdminHandler {
+	return &adminHandler{
+		logger: logger,
+	}
+}
+
+func (h *adminHandler) GetLogLevel(c echo.Context) error {
+	return c.JSON(http.StatusOK, map[string]any{
+		"level": h.logger.Level().String(),
+	})
+}
+
+func (h *adminHandler) UpdateLogLevel(c echo.Context) error {
+	ctx := c.Request().Context()
+
+	var params struct {
+		Level string `json:"level"`
+	}
+
+	if err := c.Bind(&params); err != nil {
+		logging.Errorf(ctx, err, "failed to bind request body: %s", err.Error())
+		return echo.NewHTTPError(http.StatusBadRequest, map[string]any{
+			"message": "bad request",
+		})
+	}
+
+	level, err := logging.ParseLevel(params.Level)
+	if err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, map[string]any{
+			"message": "invalid level",
+		})
+	}
+
+	previous := h.logger.Level()
+	h.logger.SetLevel(level)
+	logging.Infof(ctx, "log level changed from %s to %s", previous, level)
+
+	return c.JSON(http.StatusOK, map[string]any{
+		"level": level.String(),
+	})
+}
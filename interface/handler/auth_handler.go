@@ -0,0 +1,108 @@
+package handler
+
+import (
+	"errors"
+	"net/http"
+
+	"go02/packages/apperrors"
+	"go02/packages/logging"
+	"go02/usecase"
+
+	"github.com/labstack/echo/v4"
+)
+
+type AuthHandler interface {
+	Login(c echo.Context) error
+	Refresh(c echo.Context) error
+	Logout(c echo.Context) error
+}
+
+type authHandler struct {
+	authUsecase usecase.AuthUsecase
+}
+
+func NewAuthHandler(authUsecase usecase.AuthUsecase) AuthHandler {
+	return &authHandler{
+		authUsecase: authUsecase,
+	}
+}
+
+func (h *authHandler) Login(c echo.Context) error {
+	ctx := c.Request().Context()
+
+	var params struct {
+		// handle or email
+		Login    string `json:"login"`
+		Password string `json:"password"`
+	}
+
+	if err := c.Bind(&params); err != nil {
+		logging.Errorf(ctx, err, "failed to bind request body: %s", err.Error())
+		return echo.NewHTTPError(http.StatusBadRequest, map[string]any{
+			"message": "bad request",
+		})
+	}
+
+	res, err := h.authUsecase.Login(ctx, params.Login, params.Password)
+	if err != nil {
+		return authError(c, err, "failed to Login")
+	}
+
+	return c.JSON(http.StatusOK, res)
+}
+
+func (h *authHandler) Refresh(c echo.Context) error {
+	ctx := c.Request().Context()
+
+	var params struct {
+		RefreshToken string `json:"refresh_token"`
+	}
+
+	if err := c.Bind(&params); err != nil {
+		logging.Errorf(ctx, err, "failed to bind request body: %s", err.Error())
+		return echo.NewHTTPError(http.StatusBadRequest, map[string]any{
+			"message": "bad request",
+		})
+	}
+
+	res, err := h.authUsecase.Refresh(ctx, params.RefreshToken)
+	if err != nil {
+		return authError(c, err, "failed to Refresh")
+	}
+
+	return c.JSON(http.StatusOK, res)
+}
+
+// Logout ends the session of the access token, see middleware.RequireAuth.
+func (h *authHandler) Logout(c echo.Context) error {
+	ctx := c.Request().Context()
+
+	p, err := userPrincipal(c)
+	if err != nil {
+		return err
+	}
+
+	if err := h.authUsecase.Logout(ctx, p.SessionID); err != nil {
+		return authError(c, err, "failed to Logout")
+	}
+
+	return c.JSON(http.StatusOK, map[string]any{
+		"message": "success",
+	})
+}
+
+func authError(c echo.Context, err error, msg string) error {
+	ctx := c.Request().Context()
+
+	if errors.Is(err, apperrors.ErrUnauthorized) {
+		logging.Info(ctx, msg+": "+err.Error())
+		return echo.NewHTTPError(http.StatusUnauthorized, map[string]any{
+			"message": "invalid login or password",
+		})
+	}
+
+	logging.Errorf(ctx, err, "%s: %s", msg, err.Error())
+	return echo.NewHTTPError(http.StatusInternalServerError, map[string]any{
+		"message": http.StatusText(http.StatusInternalServerError),
+	}).SetInternal(err)
+}
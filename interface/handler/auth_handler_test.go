@@ -0,0 +1,204 @@
+package handler_test
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"go02/interface/handler"
+	"go02/middleware"
+	"go02/model"
+	"go02/packages/auth"
+	"go02/packages/metrics"
+	"go02/repository"
+	"go02/testutils"
+	"go02/usecase"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/labstack/echo/v4"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestAuth(t *testing.T) {
+	// Arrange
+	ctx := testutils.DefaultTenant(context.Background())
+	container := testutils.PrepareContainer(ctx, t)
+	defer container.TearDown()
+
+	db, err := testutils.OpenDBForTest(t, container.DSN)
+	require.NoError(t, err)
+	require.NoError(t, testutils.MigrateUp(t, container.DSN))
+
+	const (
+		password   = "correct horse battery"
+		adminToken = "admin-token"
+	)
+	user, err := model.NewUser("taro", "taro", "taro@example.com", 24)
+	require.NoError(t, err)
+	_, err = db.NewInsert().Model(user).Exec(ctx)
+	require.NoError(t, err)
+	credential, err := model.NewCredential(user, password)
+	require.NoError(t, err)
+	_, err = db.NewInsert().Model(credential).Exec(ctx)
+	require.NoError(t, err)
+	hanako, err := model.NewUser("hanako", "hanako", "", 21)
+	require.NoError(t, err)
+	_, err = db.NewInsert().Model(hanako).Exec(ctx)
+	require.NoError(t, err)
+
+	signer := auth.NewSigner([]byte("0123456789abcdef0123456789abcdef"), time.Minute)
+	authUsecase := usecase.NewAuthUsecase(
+		repository.NewTransactionRepository(db),
+		repository.NewUserRepository(db),
+		repository.NewCredentialRepository(db),
+		repository.NewSessionRepository(db),
+		signer,
+		usecase.LoginPolicy{SessionTTL: time.Hour, MaxFailedAttempts: 3, LockoutDuration: time.Hour},
+		metrics.NewUsecase(nil),
+	)
+	authHandler := handler.NewAuthHandler(authUsecase)
+	userHandler := handler.NewUserHandler(usecase.NewUserUsecase(
+		repository.NewTransactionRepository(db),
+		repository.NewUserRepository(db),
+		repository.NewProfileRepository(db),
+		repository.NewCredentialRepository(db),
+		repository.NewSessionRepository(db),
+		metrics.NewUsecase(nil),
+	))
+
+	tenant := middleware.Tenant(middleware.TenantOptions{
+		Organizations: usecase.NewOrganizationUsecase(repository.NewOrganizationRepository(db), metrics.NewUsecase(nil)),
+		Default:       "default",
+	})
+
+	e := echo.New()
+	public := e.Group("", tenant)
+	public.POST("/auth/login", authHandler.Login)
+	public.POST("/auth/refresh", authHandler.Refresh)
+	authed := e.Group("", middleware.RequireAuth(authUsecase, adminToken), tenant)
+	authed.POST("/auth/logout", authHandler.Logout)
+	authed.PUT("/users/:id", userHandler.UpdateUser)
+	authed.DELETE("/users/:id", userHandler.DeleteUser)
+
+	request := func(method, path, token, body string) (*httptest.ResponseRecorder, usecase.ResToken) {
+		req := httptest.NewRequest(method, path, strings.NewReader(body))
+		req.Header.Set(echo.HeaderContentType, echo.MIMEApplicationJSON)
+		if token != "" {
+			req.Header.Set(echo.HeaderAuthorization, "Bearer "+token)
+		}
+		rec := httptest.NewRecorder()
+		e.ServeHTTP(rec, req)
+
+		var res usecase.ResToken
+		_ = json.Unmarshal(rec.Body.Bytes(), &res)
+		return rec, res
+	}
+	do := func(path, token, body string) (*httptest.ResponseRecorder, usecase.ResToken) {
+		return request(http.MethodPost, path, token, body)
+	}
+
+	t.Run("正常系: handle と email でログインできる", func(t *testing.T) {
+		rec, res := do("/auth/login", "", `{"login":"TARO","password":"`+password+`"}`)
+		assert.Equal(t, http.StatusOK, rec.Code)
+		assert.NotEmpty(t, res.AccessToken)
+		assert.NotEmpty(t, res.RefreshToken)
+
+		rec, _ = do("/auth/login", "", `{"login":"Taro@Example.com","password":"`+password+`"}`)
+		assert.Equal(t, http.StatusOK, rec.Code)
+	})
+
+	t.Run("正常系: リフレッシュトークンはローテーションされ、再利用するとセッションが失効する", func(t *testing.T) {
+		_, login := do("/auth/login", "", `{"login":"taro","password":"`+password+`"}`)
+
+		rec, refreshed := do("/auth/refresh", "", `{"refresh_token":"`+login.RefreshToken+`"}`)
+		require.Equal(t, http.StatusOK, rec.Code)
+		assert.NotEqual(t, login.RefreshToken, refreshed.RefreshToken)
+
+		rec, _ = do("/auth/refresh", "", `{"refresh_token":"`+login.RefreshToken+`"}`)
+		assert.Equal(t, http.StatusUnauthorized, rec.Code)
+
+		rec, _ = do("/auth/refresh", "", `{"refresh_token":"`+refreshed.RefreshToken+`"}`)
+		assert.Equal(t, http.StatusUnauthorized, rec.Code)
+	})
+
+	t.Run("正常系: ログアウトするとアクセストークンが使えなくなる", func(t *testing.T) {
+		_, login := do("/auth/login", "", `{"login":"taro","password":"`+password+`"}`)
+
+		rec, _ := do("/auth/logout", login.AccessToken, "")
+		assert.Equal(t, http.StatusOK, rec.Code)
+
+		rec, _ = do("/auth/logout", login.AccessToken, "")
+		assert.Equal(t, http.StatusUnauthorized, rec.Code)
+	})
+
+	t.Run("異常系: 他のユーザーは変更も削除もできない", func(t *testing.T) {
+		_, login := do("/auth/login", "", `{"login":"taro","password":"`+password+`"}`)
+		path := fmt.Sprintf("/users/%d", hanako.ID)
+
+		rec, _ := request(http.MethodPut, path, "", `{"name":"hanako"}`)
+		assert.Equal(t, http.StatusUnauthorized, rec.Code)
+		rec, _ = request(http.MethodDelete, path, "", "")
+		assert.Equal(t, http.StatusUnauthorized, rec.Code)
+
+		rec, _ = request(http.MethodPut, path, login.AccessToken, `{"name":"hanako"}`)
+		assert.Equal(t, http.StatusForbidden, rec.Code)
+		rec, _ = request(http.MethodDelete, path, login.AccessToken, "")
+		assert.Equal(t, http.StatusForbidden, rec.Code)
+
+		_, err := repository.NewUserRepository(db).GetOne(ctx, hanako.ID)
+		assert.NoError(t, err)
+	})
+
+	t.Run("正常系: 管理者は他のユーザーを削除できるがログアウトはできない", func(t *testing.T) {
+		rec, _ := request(http.MethodDelete, fmt.Sprintf("/users/%d", hanako.ID), adminToken, "")
+		assert.Equal(t, http.StatusOK, rec.Code)
+
+		rec, _ = do("/auth/logout", adminToken, "")
+		assert.Equal(t, http.StatusForbidden, rec.Code)
+	})
+
+	t.Run("異常系: 存在しないユーザー", func(t *testing.T) {
+		rec, _ := do("/auth/login", "", `{"login":"hanako","password":"`+password+`"}`)
+		assert.Equal(t, http.StatusUnauthorized, rec.Code)
+	})
+
+	t.Run("異常系: 失敗が続くとロックされる", func(t *testing.T) {
+		for range 3 {
+			rec, _ := do("/auth/login", "", `{"login":"taro","password":"wrong password"}`)
+			assert.Equal(t, http.StatusUnauthorized, rec.Code)
+		}
+
+		// the same answer as a wrong password, which doesn't tell that the
+		// account exists
+		rec, _ := do("/auth/login", "", `{"login":"taro","password":"`+password+`"}`)
+		assert.Equal(t, http.StatusUnauthorized, rec.Code)
+		assert.JSONEq(t, `{"message":"invalid login or password"}`, rec.Body.String())
+	})
+
+	t.Run("正常系: 削除されたユーザーのトークンは使えず、ログインもできない", func(t *testing.T) {
+		jiro, err := model.NewUser("jiro", "jiro", "", 30)
+		require.NoError(t, err)
+		_, err = db.NewInsert().Model(jiro).Exec(ctx)
+		require.NoError(t, err)
+		credential, err := model.NewCredential(jiro, password)
+		require.NoError(t, err)
+		_, err = db.NewInsert().Model(credential).Exec(ctx)
+		require.NoError(t, err)
+
+		_, login := do("/auth/login", "", `{"login":"jiro","password":"`+password+`"}`)
+		path := fmt.Sprintf("/users/%d", jiro.ID)
+		rec, _ := request(http.MethodDelete, path, login.AccessToken, "")
+		require.Equal(t, http.StatusOK, rec.Code)
+
+		rec, _ = request(http.MethodPut, path, login.AccessToken, `{"name":"jiro"}`)
+		assert.Equal(t, http.StatusUnauthorized, rec.Code)
+		rec, _ = do("/auth/refresh", "", `{"refresh_token":"`+login.RefreshToken+`"}`)
+		assert.Equal(t, http.StatusUnauthorized, rec.Code)
+		rec, _ = do("/auth/login", "", `{"login":"jiro","password":"`+password+`"}`)
+		assert.Equal(t, http.StatusUnauthorized, rec.Code)
+	})
+}
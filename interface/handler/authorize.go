@@ -0,0 +1,50 @@
+package handler
+
+import (
+	"net/http"
+
+	"go02/packages/auth"
+
+	"github.com/labstack/echo/v4"
+)
+
+// principal returns who the request is authenticated as, see
+// middleware.RequireAuth.
+func principal(c echo.Context) (auth.Principal, error) {
+	p, ok := auth.FromContext(c.Request().Context())
+	if !ok {
+		return auth.Principal{}, echo.NewHTTPError(http.StatusUnauthorized, map[string]any{
+			"message": "unauthorized",
+		})
+	}
+	return p, nil
+}
+
+// userPrincipal is principal for the requests that act as a user, which an
+// admin isn't.
+func userPrincipal(c echo.Context) (auth.Principal, error) {
+	p, err := principal(c)
+	if err != nil {
+		return auth.Principal{}, err
+	}
+	if p.Admin {
+		return auth.Principal{}, echo.NewHTTPError(http.StatusForbidden, map[string]any{
+			"message": "the admin token can't act as a user",
+		})
+	}
+	return p, nil
+}
+
+// authorizeUser allows the user with id and admins.
+func authorizeUser(c echo.Context, id int) error {
+	p, err := principal(c)
+	if err != nil {
+		return err
+	}
+	if !p.CanActOn(id) {
+		return echo.NewHTTPError(http.StatusForbidden, map[string]any{
+			"message": "forbidden",
+		})
+	}
+	return nil
+}
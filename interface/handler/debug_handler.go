@@ -0,0 +1,37 @@
+package handler
+
+import (
+	"net/http"
+
+	"go02/packages/config"
+	"go02/packages/db"
+
+	"github.com/labstack/echo/v4"
+	"github.com/uptrace/bun"
+)
+
+type DebugHandler interface {
+	GetDBStats(c echo.Context) error
+	GetConfig(c echo.Context) error
+}
+
+type debugHandler struct {
+	conn   *bun.DB
+	config *config.Store
+}
+
+func NewDebugHandler(conn *bun.DB, config *config.Store) DebugHandler {
+	return &debugHandler{
+		conn:   conn,
+		config: config,
+	}
+}
+
+func (h *debugHandler) GetDBStats(c echo.Context) error {
+	return c.JSON(http.StatusOK, db.GetStats(h.conn))
+}
+
+// GetConfig dumps the effective configuration with secrets redacted.
+func (h *debugHandler) GetConfig(c echo.Context) error {
+	return c.JSON(http.StatusOK, h.config.Get().Redacted())
+}
@@ -0,0 +1,98 @@
+package handler
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+
+	"go02/packages/apperrors"
+	"go02/packages/logging"
+	"go02/usecase"
+
+	"github.com/labstack/echo/v4"
+)
+
+type EmailHandler interface {
+	RequestVerification(c echo.Context) error
+	Verify(c echo.Context) error
+}
+
+type emailHandler struct {
+	emailUsecase usecase.EmailUsecase
+}
+
+func NewEmailHandler(emailUsecase usecase.EmailUsecase) EmailHandler {
+	return &emailHandler{
+		emailUsecase: emailUsecase,
+	}
+}
+
+func (h *emailHandler) RequestVerification(c echo.Context) error {
+	ctx := c.Request().Context()
+
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		logging.Errorf(ctx, err, "failed to parse id: %s", err.Error())
+		return echo.NewHTTPError(http.StatusBadRequest, map[string]any{
+			"message": "invalid id",
+		})
+	}
+	if err := authorizeUser(c, id); err != nil {
+		return err
+	}
+
+	err = h.emailUsecase.RequestVerification(ctx, id)
+	if errors.Is(err, apperrors.ErrNotFound) {
+		return echo.NewHTTPError(http.StatusNotFound, map[string]any{
+			"message": "user not found",
+		})
+	}
+	if errors.Is(err, apperrors.ErrRateLimited) {
+		return echo.NewHTTPError(http.StatusTooManyRequests, map[string]any{
+			"message": "a verification email was sent recently, try again later",
+		})
+	}
+	if he := inputError(err); he != nil {
+		return he
+	}
+	if err != nil {
+		logging.Errorf(ctx, err, "failed to RequestVerification: %s", err.Error())
+		return echo.NewHTTPError(http.StatusInternalServerError, map[string]any{
+			"message": http.StatusText(http.StatusInternalServerError),
+		}).SetInternal(err)
+	}
+
+	return c.JSON(http.StatusAccepted, map[string]any{
+		"message": "verification email sent",
+	})
+}
+
+func (h *emailHandler) Verify(c echo.Context) error {
+	ctx := c.Request().Context()
+
+	var params struct {
+		Token string `json:"token"`
+	}
+
+	if err := c.Bind(&params); err != nil {
+		logging.Errorf(ctx, err, "failed to bind request body: %s", err.Error())
+		return echo.NewHTTPError(http.StatusBadRequest, map[string]any{
+			"message": "bad request",
+		})
+	}
+
+	err := h.emailUsecase.Verify(ctx, params.Token)
+	if he := inputError(err); he != nil {
+		return he
+	}
+	if err != nil {
+		logging.Errorf(ctx, err, "failed to Verify: %s", err.Error())
+		return echo.NewHTTPError(http.StatusInternalServerError, map[string]any{
+			"message": http.StatusText(http.StatusInternalServerError),
+		}).SetInternal(err)
+	}
+
+	return c.JSON(http.StatusOK, map[string]any{
+		"message": "success",
+	})
+}
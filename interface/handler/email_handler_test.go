@@ -0,0 +1,128 @@
+package handler_test
+
+import (
+	"context"
+	"go02/interface/handler"
+	"go02/model"
+	"go02/packages/auth"
+	"go02/packages/mail"
+	"go02/packages/metrics"
+	"go02/repository"
+	"go02/testutils"
+	"go02/usecase"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/labstack/echo/v4"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestEmailVerification(t *testing.T) {
+	// Arrange
+	ctx := testutils.DefaultTenant(context.Background())
+	container := testutils.PrepareContainer(ctx, t)
+	defer container.TearDown()
+
+	db, err := testutils.OpenDBForTest(t, container.DSN)
+	require.NoError(t, err)
+	require.NoError(t, testutils.MigrateUp(t, container.DSN))
+
+	user, err := model.NewUser("taro", "taro", "Taro@Example.com", 24)
+	require.NoError(t, err)
+	_, err = db.NewInsert().Model(user).Exec(ctx)
+	require.NoError(t, err)
+	hanako, err := model.NewUser("hanako", "hanako", "hanako@example.com", 21)
+	require.NoError(t, err)
+	_, err = db.NewInsert().Model(hanako).Exec(ctx)
+	require.NoError(t, err)
+
+	mailer := mail.NewMemory()
+	userRepository := repository.NewUserRepository(db)
+	emailUsecase := usecase.NewEmailUsecase(
+		repository.NewTransactionRepository(db),
+		userRepository,
+		repository.NewEmailVerificationRepository(db),
+		mailer,
+		"https://example.com/verify-email",
+		time.Hour,
+		time.Hour,
+		metrics.NewUsecase(nil),
+	)
+	emailHandler := handler.NewEmailHandler(emailUsecase)
+	e := echo.New()
+
+	verify := func(token string) error {
+		req := httptest.NewRequest(http.MethodPost, "/email/verify", strings.NewReader(`{"token":"`+token+`"}`)).WithContext(ctx)
+		req.Header.Set(echo.HeaderContentType, echo.MIMEApplicationJSON)
+		return emailHandler.Verify(e.NewContext(req, httptest.NewRecorder()))
+	}
+
+	request := func(p *auth.Principal) (int, error) {
+		reqCtx := ctx
+		if p != nil {
+			reqCtx = auth.NewContext(ctx, *p)
+		}
+		req := httptest.NewRequest(http.MethodPost, "/", nil).WithContext(reqCtx)
+		rec := httptest.NewRecorder()
+		c := e.NewContext(req, rec)
+		c.SetPath("/users/:id/email/verify-request")
+		c.SetParamNames("id")
+		c.SetParamValues(strconv.Itoa(user.ID))
+		err := emailHandler.RequestVerification(c)
+		return rec.Code, err
+	}
+	code := func(err error) int {
+		he, ok := err.(*echo.HTTPError)
+		require.True(t, ok)
+		return he.Code
+	}
+
+	// Act & Assert: only the user may ask for a token
+	_, err = request(nil)
+	assert.Equal(t, http.StatusUnauthorized, code(err))
+	_, err = request(&auth.Principal{UserID: hanako.ID, OrgID: model.DefaultOrgID})
+	assert.Equal(t, http.StatusForbidden, code(err))
+
+	// Act: request a token
+	status, err := request(&auth.Principal{UserID: user.ID, OrgID: model.DefaultOrgID})
+	require.NoError(t, err)
+	assert.Equal(t, http.StatusAccepted, status)
+
+	// Assert: asking again right away mails nothing
+	_, err = request(&auth.Principal{UserID: user.ID, OrgID: model.DefaultOrgID})
+	assert.Equal(t, http.StatusTooManyRequests, code(err))
+
+	// Assert: the token was mailed as a link
+	messages := mailer.Messages()
+	require.Len(t, messages, 1)
+	assert.Equal(t, "taro@example.com", messages[0].To)
+
+	start := strings.Index(messages[0].Body, "https://")
+	require.GreaterOrEqual(t, start, 0)
+	link, err := url.Parse(strings.Fields(messages[0].Body[start:])[0])
+	require.NoError(t, err)
+	token := link.Query().Get("token")
+	require.NotEmpty(t, token)
+
+	// Act & Assert: an unknown token is rejected, the mailed one verifies
+	he, ok := verify("unknown").(*echo.HTTPError)
+	require.True(t, ok)
+	assert.Equal(t, http.StatusBadRequest, he.Code)
+
+	require.NoError(t, verify(token))
+
+	got, err := userRepository.GetOne(ctx, user.ID)
+	require.NoError(t, err)
+	assert.True(t, got.EmailVerified())
+
+	// a token can only be used once
+	he, ok = verify(token).(*echo.HTTPError)
+	require.True(t, ok)
+	assert.Equal(t, http.StatusBadRequest, he.Code)
+}
@@ -0,0 +1,150 @@
+package handler
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+	"time"
+
+	"go02/packages/apperrors"
+	"go02/packages/auth"
+	"go02/packages/errreport"
+	"go02/packages/logging"
+	"go02/packages/requestid"
+
+	"github.com/labstack/echo/v4"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// NewErrorHandler returns the centralized echo.HTTPErrorHandler. It keeps the
+// {"message": ...} body shape used by the handlers, adds the request ID and
+// sends 5xx errors to the error reporter.
+func NewErrorHandler(reporter errreport.Reporter) echo.HTTPErrorHandler {
+	return func(err error, c echo.Context) {
+		if c.Response().Committed {
+			return
+		}
+
+		var he *echo.HTTPError
+		if !errors.As(err, &he) {
+			he = echo.NewHTTPError(http.StatusInternalServerError).SetInternal(err)
+		}
+
+		ctx := c.Request().Context()
+
+		if he.Code >= http.StatusInternalServerError {
+			report(c, reporter, he, err)
+		}
+
+		body := map[string]any{}
+		switch m := he.Message.(type) {
+		case map[string]any:
+			for k, v := range m {
+				body[k] = v
+			}
+		case string:
+			body["message"] = m
+		default:
+			body["message"] = http.StatusText(he.Code)
+		}
+
+		if id := requestid.FromContext(ctx); id != "" {
+			body["request_id"] = id
+		}
+
+		if c.Request().Method == http.MethodHead {
+			err = c.NoContent(he.Code)
+		} else {
+			err = c.JSON(he.Code, body)
+		}
+		if err != nil {
+			logging.Errorf(ctx, err, "failed to write error response: %s", err.Error())
+		}
+	}
+}
+
+// inputError maps the errors a client can fix by changing its input to a 4xx
+// naming the offending field. It returns nil for any other error.
+func inputError(err error) *echo.HTTPError {
+	var conflict *apperrors.ConflictError
+	if errors.As(err, &conflict) {
+		return echo.NewHTTPError(http.StatusConflict, map[string]any{
+			"message": conflict.Error(),
+			"field":   conflict.Field,
+		}).SetInternal(err)
+	}
+
+	var invalid *apperrors.ValidationError
+	if errors.As(err, &invalid) {
+		return echo.NewHTTPError(http.StatusBadRequest, map[string]any{
+			"message": invalid.Error(),
+			"field":   invalid.Field,
+		}).SetInternal(err)
+	}
+
+	return nil
+}
+
+func report(c echo.Context, reporter errreport.Reporter, he *echo.HTTPError, err error) {
+	ctx := c.Request().Context()
+
+	cause := err
+	if he.Internal != nil {
+		cause = he.Internal
+	}
+
+	event := errreport.Event{
+		Err:         cause,
+		Fingerprint: errreport.Fingerprint(cause),
+		Time:        time.Now(),
+		Status:      he.Code,
+		Request:     c.Request(),
+		RequestID:   requestid.FromContext(ctx),
+	}
+	if p, ok := auth.FromContext(ctx); ok {
+		event.Principal = p.String()
+	}
+	if s := trace.SpanContextFromContext(ctx); s.IsValid() {
+		event.TraceID = s.TraceID().String()
+		event.SpanID = s.SpanID().String()
+	}
+
+	reporter.Report(ctx, event)
+}
+
+// resourceError maps the errors of a usecase on a resource to responses:
+// apperrors.ErrNotFound is 404 with the notFound message,
+// apperrors.ErrForbidden is 403, input errors are 400 or 409 and anything
+// else is 500.
+func resourceError(c echo.Context, err error, op string, notFound string) error {
+	if errors.Is(err, apperrors.ErrNotFound) {
+		return echo.NewHTTPError(http.StatusNotFound, map[string]any{
+			"message": notFound,
+		})
+	}
+	if errors.Is(err, apperrors.ErrForbidden) {
+		return echo.NewHTTPError(http.StatusForbidden, map[string]any{
+			"message": "forbidden",
+		})
+	}
+	if he := inputError(err); he != nil {
+		return he
+	}
+
+	logging.Errorf(c.Request().Context(), err, "failed to %s: %s", op, err.Error())
+	return echo.NewHTTPError(http.StatusInternalServerError, map[string]any{
+		"message": http.StatusText(http.StatusInternalServerError),
+	}).SetInternal(err)
+}
+
+// paramID parses the :id path param.
+func paramID(c echo.Context) (int, error) {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		logging.Errorf(c.Request().Context(), err, "failed to parse id: %s", err.Error())
+		return 0, echo.NewHTTPError(http.StatusBadRequest, map[string]any{
+			"message": "invalid id",
+		})
+	}
+	return id, nil
+}
@@ -0,0 +1,108 @@
+package handler
+
+import (
+	"context"
+	"net/http"
+
+	"go02/packages/logging"
+	"go02/usecase"
+
+	"github.com/labstack/echo/v4"
+)
+
+type FollowHandler interface {
+	Follow(c echo.Context) error
+	Unfollow(c echo.Context) error
+	GetFollowers(c echo.Context) error
+	GetFollowing(c echo.Context) error
+	GetMutuals(c echo.Context) error
+}
+
+type followHandler struct {
+	followUsecase usecase.FollowUsecase
+}
+
+func NewFollowHandler(followUsecase usecase.FollowUsecase) FollowHandler {
+	return &followHandler{
+		followUsecase: followUsecase,
+	}
+}
+
+// Follow makes the user of the access token follow :id, see
+// middleware.RequireAuth.
+func (h *followHandler) Follow(c echo.Context) error {
+	return h.change(c, "Follow", h.followUsecase.Follow)
+}
+
+// Unfollow makes the user of the access token stop following :id, see
+// middleware.RequireAuth.
+func (h *followHandler) Unfollow(c echo.Context) error {
+	return h.change(c, "Unfollow", h.followUsecase.Unfollow)
+}
+
+func (h *followHandler) change(c echo.Context, op string, f func(ctx context.Context, followerID int, followeeID int) error) error {
+	ctx := c.Request().Context()
+
+	p, err := userPrincipal(c)
+	if err != nil {
+		return err
+	}
+
+	id, err := paramID(c)
+	if err != nil {
+		return err
+	}
+
+	if err := f(ctx, p.UserID, id); err != nil {
+		return resourceError(c, err, op, "user not found")
+	}
+
+	return c.JSON(http.StatusOK, map[string]any{
+		"message": "success",
+	})
+}
+
+func (h *followHandler) GetFollowers(c echo.Context) error {
+	return h.list(c, "GetFollowers", h.followUsecase.GetFollowers)
+}
+
+func (h *followHandler) GetFollowing(c echo.Context) error {
+	return h.list(c, "GetFollowing", h.followUsecase.GetFollowing)
+}
+
+// GetMutuals returns the followers of :id that :id follows back.
+func (h *followHandler) GetMutuals(c echo.Context) error {
+	return h.list(c, "GetMutuals", h.followUsecase.GetMutuals)
+}
+
+func (h *followHandler) list(
+	c echo.Context,
+	op string,
+	f func(ctx context.Context, userID int, cursor string, limit int) (usecase.ResFollowList, error),
+) error {
+	ctx := c.Request().Context()
+
+	id, err := paramID(c)
+	if err != nil {
+		return err
+	}
+
+	var params struct {
+		Cursor string `query:"cursor"`
+		Limit  int    `query:"limit"`
+	}
+
+	if err := c.Bind(&params); err != nil {
+		logging.Errorf(ctx, err, "failed to bind query params: %s", err.Error())
+		return echo.NewHTTPError(http.StatusBadRequest, map[string]any{
+			"message": http.StatusText(http.StatusBadRequest),
+		})
+	}
+
+	res, err := f(ctx, id, params.Cursor, params.Limit)
+	if err != nil {
+		return resourceError(c, err, op, "user not found")
+	}
+
+	return c.JSON(http.StatusOK, res)
+}
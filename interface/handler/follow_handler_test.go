@@ -0,0 +1,206 @@
+package handler_test
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"go02/interface/handler"
+	"go02/model"
+	"go02/packages/auth"
+	"go02/packages/db"
+	"go02/packages/metrics"
+	"go02/repository"
+	"go02/testutils"
+	"go02/usecase"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/labstack/echo/v4"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestFollow(t *testing.T) {
+	// Arrange
+	ctx := testutils.DefaultTenant(context.Background())
+	container := testutils.PrepareContainer(ctx, t)
+	defer container.TearDown()
+
+	conn, err := testutils.OpenDBForTest(t, container.DSN)
+	require.NoError(t, err)
+	require.NoError(t, testutils.MigrateUp(t, container.DSN))
+
+	users := make([]*model.User, 4)
+	for i, handle := range []string{"taro", "hanako", "jiro", "saburo"} {
+		users[i], err = model.NewUser(handle, handle, "", 24)
+		require.NoError(t, err)
+		_, err = conn.NewInsert().Model(users[i]).Exec(ctx)
+		require.NoError(t, err)
+	}
+	taro, hanako, jiro, saburo := users[0], users[1], users[2], users[3]
+
+	userRepository := repository.NewUserRepository(conn)
+	followUsecase := usecase.NewFollowUsecase(
+		repository.NewTransactionRepository(conn),
+		repository.NewFollowRepository(conn),
+		userRepository,
+		repository.NewOrganizationRepository(conn),
+		metrics.NewUsecase(nil),
+	)
+	followHandler := handler.NewFollowHandler(followUsecase)
+
+	do := func(method, target string, as *model.User, id int, h echo.HandlerFunc) *httptest.ResponseRecorder {
+		reqCtx := ctx
+		if as != nil {
+			reqCtx = auth.NewContext(ctx, auth.Principal{UserID: as.ID, OrgID: model.DefaultOrgID})
+		}
+		req := httptest.NewRequest(method, target, nil).WithContext(reqCtx)
+		rec := httptest.NewRecorder()
+		c := echo.New().NewContext(req, rec)
+		c.SetParamNames("id")
+		c.SetParamValues(fmt.Sprint(id))
+		if err := h(c); err != nil {
+			rec.Code = err.(*echo.HTTPError).Code
+		}
+		return rec
+	}
+	list := func(target string, id int, h echo.HandlerFunc) usecase.ResFollowList {
+		rec := do(http.MethodGet, target, nil, id, h)
+		require.Equal(t, http.StatusOK, rec.Code)
+		var res usecase.ResFollowList
+		require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &res))
+		return res
+	}
+	ids := func(res usecase.ResFollowList) []int {
+		var ids []int
+		for _, u := range res.Users {
+			ids = append(ids, u.ID)
+		}
+		return ids
+	}
+
+	// hanako, jiro and saburo follow taro, taro follows hanako and jiro back
+	for _, f := range [][2]*model.User{{hanako, taro}, {jiro, taro}, {saburo, taro}, {taro, hanako}, {taro, jiro}} {
+		require.Equal(t, http.StatusOK, do(http.MethodPost, "/", f[0], f[1].ID, followHandler.Follow).Code)
+	}
+
+	t.Run("正常系: 2回フォローしても1件", func(t *testing.T) {
+		assert.Equal(t, http.StatusOK, do(http.MethodPost, "/", hanako, taro.ID, followHandler.Follow).Code)
+		assert.Len(t, list("/", taro.ID, followHandler.GetFollowers).Users, 3)
+	})
+
+	t.Run("正常系: フォロワーをカーソルでページングできる", func(t *testing.T) {
+		page := list("/?limit=2", taro.ID, followHandler.GetFollowers)
+		assert.Equal(t, []int{saburo.ID, jiro.ID}, ids(page))
+		require.NotEmpty(t, page.NextCursor)
+
+		page = list("/?limit=2&cursor="+page.NextCursor, taro.ID, followHandler.GetFollowers)
+		assert.Equal(t, []int{hanako.ID}, ids(page))
+		assert.Empty(t, page.NextCursor)
+	})
+
+	t.Run("正常系: フォローと相互フォロー", func(t *testing.T) {
+		assert.Equal(t, []int{jiro.ID, hanako.ID}, ids(list("/", taro.ID, followHandler.GetFollowing)))
+		assert.Equal(t, []int{jiro.ID, hanako.ID}, ids(list("/", taro.ID, followHandler.GetMutuals)))
+		assert.Equal(t, []int{taro.ID}, ids(list("/", hanako.ID, followHandler.GetMutuals)))
+	})
+
+	t.Run("正常系: フォロー数は照合後にユーザーに載る", func(t *testing.T) {
+		_, err := followUsecase.ReconcileCounts(ctx)
+		require.NoError(t, err)
+
+		user, err := userRepository.GetOne(ctx, taro.ID)
+		require.NoError(t, err)
+		require.NotNil(t, user.FollowCount)
+		assert.Equal(t, 3, user.FollowCount.FollowersCount)
+		assert.Equal(t, 2, user.FollowCount.FollowingCount)
+
+		assert.Equal(t, http.StatusOK, do(http.MethodDelete, "/", saburo, taro.ID, followHandler.Unfollow).Code)
+		changed, err := followUsecase.ReconcileCounts(ctx)
+		require.NoError(t, err)
+		assert.Equal(t, 2, changed)
+
+		user, err = userRepository.GetOne(ctx, taro.ID)
+		require.NoError(t, err)
+		assert.Equal(t, 2, user.FollowCount.FollowersCount)
+	})
+
+	t.Run("異常系: 自分はフォローできない", func(t *testing.T) {
+		assert.Equal(t, http.StatusBadRequest, do(http.MethodPost, "/", taro, taro.ID, followHandler.Follow).Code)
+	})
+
+	t.Run("異常系: 存在しないユーザー", func(t *testing.T) {
+		assert.Equal(t, http.StatusNotFound, do(http.MethodPost, "/", taro, 0, followHandler.Follow).Code)
+		assert.Equal(t, http.StatusNotFound, do(http.MethodGet, "/", nil, 0, followHandler.GetFollowers).Code)
+	})
+
+	t.Run("異常系: 不正なカーソル", func(t *testing.T) {
+		assert.Equal(t, http.StatusBadRequest, do(http.MethodGet, "/?cursor=xyz", nil, taro.ID, followHandler.GetFollowers).Code)
+	})
+}
+
+func TestReconcileFollowCountsWithRowLevelSecurity(t *testing.T) {
+	// Arrange
+	ctx := context.Background()
+	container := testutils.PrepareContainer(ctx, t)
+	defer container.TearDown()
+
+	owner, err := testutils.OpenDBForTest(t, container.DSN)
+	require.NoError(t, err)
+	require.NoError(t, testutils.MigrateUp(t, container.DSN))
+	conn, err := testutils.OpenTenantRoleForTest(t, owner, container.DSN)
+	require.NoError(t, err)
+
+	acme, err := model.NewOrganization("acme", "Acme")
+	require.NoError(t, err)
+	require.NoError(t, repository.NewOrganizationRepository(owner).Create(ctx, acme))
+
+	followUsecase := usecase.NewFollowUsecase(
+		repository.NewTransactionRepository(conn),
+		repository.NewFollowRepository(conn),
+		repository.NewUserRepository(conn),
+		repository.NewOrganizationRepository(conn),
+		metrics.NewUsecase(nil),
+	)
+
+	// taro follows hanako in both organizations, as RowLevelSecurity runs the
+	// requests
+	for _, orgID := range []int{model.DefaultOrgID, acme.ID} {
+		orgCtx := db.WithTenant(ctx, orgID)
+		users := make([]*model.User, 2)
+		for i, handle := range []string{"taro", "hanako"} {
+			users[i], err = model.NewUser(handle, handle, "", 24)
+			require.NoError(t, err)
+			_, err = owner.NewInsert().Model(users[i]).Exec(orgCtx)
+			require.NoError(t, err)
+		}
+		require.NoError(t, db.WithTenantConn(orgCtx, conn, func(ctx context.Context) error {
+			return followUsecase.Follow(ctx, users[0].ID, users[1].ID)
+		}))
+	}
+
+	// Act: the reconciler runs without an organization
+	changed, err := followUsecase.ReconcileCounts(ctx)
+
+	// Assert
+	require.NoError(t, err)
+	assert.Equal(t, 4, changed)
+
+	var counts []model.UserFollowCount
+	require.NoError(t, owner.NewSelect().Model(&counts).Order("user_id").Scan(db.WithoutTenant(ctx)))
+	require.Len(t, counts, 4)
+	for _, orgID := range []int{model.DefaultOrgID, acme.ID} {
+		followers := 0
+		for _, c := range counts {
+			if c.OrgID == orgID {
+				followers += c.FollowersCount
+			}
+		}
+		assert.Equal(t, 1, followers, orgID)
+	}
+
+	changed, err = followUsecase.ReconcileCounts(ctx)
+	require.NoError(t, err)
+	assert.Zero(t, changed)
+}
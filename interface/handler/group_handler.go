@@ -0,0 +1,240 @@
+package handler
+
+import (
+	"net/http"
+
+	"go02/packages/logging"
+	"go02/usecase"
+
+	"github.com/labstack/echo/v4"
+)
+
+type GroupHandler interface {
+	CreateGroup(c echo.Context) error
+	UpdateGroup(c echo.Context) error
+	DeleteGroup(c echo.Context) error
+	GetGroupList(c echo.Context) error
+	GetGroup(c echo.Context) error
+	AddMembers(c echo.Context) error
+	RemoveMembers(c echo.Context) error
+	GetUserGroups(c echo.Context) error
+}
+
+type groupHandler struct {
+	groupUsecase usecase.GroupUsecase
+}
+
+func NewGroupHandler(groupUsecase usecase.GroupUsecase) GroupHandler {
+	return &groupHandler{
+		groupUsecase: groupUsecase,
+	}
+}
+
+// CreateGroup creates a group owned by the user of the access token, see
+// middleware.RequireAuth.
+func (h *groupHandler) CreateGroup(c echo.Context) error {
+	ctx := c.Request().Context()
+
+	p, err := principal(c)
+	if err != nil {
+		return err
+	}
+
+	var params struct {
+		Name        string `json:"name"`
+		Description string `json:"description"`
+	}
+
+	if err := c.Bind(&params); err != nil {
+		logging.Errorf(ctx, err, "failed to bind request body: %s", err.Error())
+		return echo.NewHTTPError(http.StatusBadRequest, map[string]any{
+			"message": "bad request",
+		})
+	}
+
+	res, err := h.groupUsecase.CreateGroup(ctx, p, params.Name, params.Description)
+	if err != nil {
+		return resourceError(c, err, "CreateGroup", "group not found")
+	}
+
+	return c.JSON(http.StatusCreated, res)
+}
+
+func (h *groupHandler) UpdateGroup(c echo.Context) error {
+	ctx := c.Request().Context()
+
+	p, err := principal(c)
+	if err != nil {
+		return err
+	}
+
+	id, err := paramID(c)
+	if err != nil {
+		return err
+	}
+
+	var params struct {
+		Name        string `json:"name"`
+		Description string `json:"description"`
+	}
+
+	if err := c.Bind(&params); err != nil {
+		logging.Errorf(ctx, err, "failed to bind request body: %s", err.Error())
+		return echo.NewHTTPError(http.StatusBadRequest, map[string]any{
+			"message": "bad request",
+		})
+	}
+
+	if err := h.groupUsecase.UpdateGroup(ctx, p, id, params.Name, params.Description); err != nil {
+		return resourceError(c, err, "UpdateGroup", "group not found")
+	}
+
+	return c.JSON(http.StatusOK, map[string]any{
+		"message": "success",
+	})
+}
+
+func (h *groupHandler) DeleteGroup(c echo.Context) error {
+	ctx := c.Request().Context()
+
+	p, err := principal(c)
+	if err != nil {
+		return err
+	}
+
+	id, err := paramID(c)
+	if err != nil {
+		return err
+	}
+
+	if err := h.groupUsecase.DeleteGroup(ctx, p, id); err != nil {
+		return resourceError(c, err, "DeleteGroup", "group not found")
+	}
+
+	return c.JSON(http.StatusOK, map[string]any{
+		"message": "success",
+	})
+}
+
+func (h *groupHandler) GetGroupList(c echo.Context) error {
+	ctx := c.Request().Context()
+
+	var params struct {
+		Limit  int `query:"limit"`
+		Offset int `query:"offset"`
+	}
+
+	if err := c.Bind(&params); err != nil {
+		logging.Errorf(ctx, err, "failed to bind query params: %s", err.Error())
+		return echo.NewHTTPError(http.StatusBadRequest, map[string]any{
+			"message": http.StatusText(http.StatusBadRequest),
+		})
+	}
+
+	res, err := h.groupUsecase.GetGroupList(ctx, params.Limit, params.Offset)
+	if err != nil {
+		return resourceError(c, err, "GetGroupList", "group not found")
+	}
+
+	return c.JSON(http.StatusOK, res)
+}
+
+func (h *groupHandler) GetGroup(c echo.Context) error {
+	ctx := c.Request().Context()
+
+	id, err := paramID(c)
+	if err != nil {
+		return err
+	}
+
+	res, err := h.groupUsecase.GetGroup(ctx, id)
+	if err != nil {
+		return resourceError(c, err, "GetGroup", "group not found")
+	}
+
+	return c.JSON(http.StatusOK, res)
+}
+
+func (h *groupHandler) AddMembers(c echo.Context) error {
+	ctx := c.Request().Context()
+
+	p, err := principal(c)
+	if err != nil {
+		return err
+	}
+
+	id, err := paramID(c)
+	if err != nil {
+		return err
+	}
+
+	var params struct {
+		Members []usecase.ReqMember `json:"members"`
+	}
+
+	if err := c.Bind(&params); err != nil {
+		logging.Errorf(ctx, err, "failed to bind request body: %s", err.Error())
+		return echo.NewHTTPError(http.StatusBadRequest, map[string]any{
+			"message": "bad request",
+		})
+	}
+
+	if err := h.groupUsecase.AddMembers(ctx, p, id, params.Members); err != nil {
+		return resourceError(c, err, "AddMembers", "group not found")
+	}
+
+	return c.JSON(http.StatusOK, map[string]any{
+		"message": "success",
+	})
+}
+
+// RemoveMembers removes the users of the user_id query params, e.g.
+// DELETE /groups/1/members?user_id=2&user_id=3.
+func (h *groupHandler) RemoveMembers(c echo.Context) error {
+	ctx := c.Request().Context()
+
+	p, err := principal(c)
+	if err != nil {
+		return err
+	}
+
+	id, err := paramID(c)
+	if err != nil {
+		return err
+	}
+
+	var params struct {
+		UserIDs []int `query:"user_id"`
+	}
+
+	if err := c.Bind(&params); err != nil {
+		logging.Errorf(ctx, err, "failed to bind query params: %s", err.Error())
+		return echo.NewHTTPError(http.StatusBadRequest, map[string]any{
+			"message": http.StatusText(http.StatusBadRequest),
+		})
+	}
+
+	if err := h.groupUsecase.RemoveMembers(ctx, p, id, params.UserIDs); err != nil {
+		return resourceError(c, err, "RemoveMembers", "group not found")
+	}
+
+	return c.JSON(http.StatusOK, map[string]any{
+		"message": "success",
+	})
+}
+
+func (h *groupHandler) GetUserGroups(c echo.Context) error {
+	ctx := c.Request().Context()
+
+	id, err := paramID(c)
+	if err != nil {
+		return err
+	}
+
+	res, err := h.groupUsecase.GetUserGroups(ctx, id)
+	if err != nil {
+		return resourceError(c, err, "GetUserGroups", "user not found")
+	}
+
+	return c.JSON(http.StatusOK, res)
+}
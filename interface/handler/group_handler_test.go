@@ -0,0 +1,238 @@
+package handler_test
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"go02/interface/handler"
+	"go02/model"
+	"go02/packages/auth"
+	"go02/packages/db"
+	"go02/packages/metrics"
+	"go02/repository"
+	"go02/testutils"
+	"go02/usecase"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/labstack/echo/v4"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestGroupMembers(t *testing.T) {
+	// Arrange
+	ctx := testutils.DefaultTenant(context.Background())
+	container := testutils.PrepareContainer(ctx, t)
+	defer container.TearDown()
+
+	conn, err := testutils.OpenDBForTest(t, container.DSN)
+	require.NoError(t, err)
+	require.NoError(t, testutils.MigrateUp(t, container.DSN))
+
+	acme, err := model.NewOrganization("acme", "Acme")
+	require.NoError(t, err)
+	require.NoError(t, repository.NewOrganizationRepository(conn).Create(ctx, acme))
+
+	insertUser := func(ctx context.Context, handle string) *model.User {
+		user, err := model.NewUser(handle, handle, "", 24)
+		require.NoError(t, err)
+		_, err = conn.NewInsert().Model(user).Exec(ctx)
+		require.NoError(t, err)
+		return user
+	}
+	taro := insertUser(ctx, "taro")
+	hanako := insertUser(ctx, "hanako")
+	saburo := insertUser(ctx, "saburo")
+	outsider := insertUser(db.WithTenant(ctx, acme.ID), "jiro")
+
+	groupUsecase := usecase.NewGroupUsecase(
+		repository.NewTransactionRepository(conn),
+		repository.NewGroupRepository(conn),
+		repository.NewGroupMembershipRepository(conn),
+		repository.NewUserRepository(conn),
+		metrics.NewUsecase(nil),
+	)
+	groupHandler := handler.NewGroupHandler(groupUsecase)
+
+	as := func(user *model.User) *auth.Principal {
+		return &auth.Principal{UserID: user.ID, OrgID: model.DefaultOrgID}
+	}
+	admin := &auth.Principal{Admin: true}
+
+	do := func(method, path, body string, p *auth.Principal, h echo.HandlerFunc, params ...string) *httptest.ResponseRecorder {
+		reqCtx := ctx
+		if p != nil {
+			reqCtx = auth.NewContext(ctx, *p)
+		}
+		req := httptest.NewRequest(method, path, strings.NewReader(body)).WithContext(reqCtx)
+		req.Header.Set(echo.HeaderContentType, echo.MIMEApplicationJSON)
+		rec := httptest.NewRecorder()
+		c := echo.New().NewContext(req, rec)
+		if len(params) > 0 {
+			c.SetParamNames("id")
+			c.SetParamValues(params...)
+		}
+		if err := h(c); err != nil {
+			he := err.(*echo.HTTPError)
+			rec.Code = he.Code
+		}
+		return rec
+	}
+	roles := func(t *testing.T, groupID int) map[int]string {
+		memberships, err := repository.NewGroupMembershipRepository(conn).GetByGroupID(ctx, groupID)
+		require.NoError(t, err)
+		roles := map[int]string{}
+		for _, m := range memberships {
+			roles[m.UserID] = m.Role
+		}
+		return roles
+	}
+
+	t.Run("異常系: 未認証ではグループを作れない", func(t *testing.T) {
+		rec := do(http.MethodPost, "/groups", `{"name":"frontend"}`, nil, groupHandler.CreateGroup)
+		assert.Equal(t, http.StatusUnauthorized, rec.Code)
+	})
+
+	t.Run("異常系: 管理者トークンではグループを作れない", func(t *testing.T) {
+		rec := do(http.MethodPost, "/groups", `{"name":"frontend"}`, admin, groupHandler.CreateGroup)
+		assert.Equal(t, http.StatusForbidden, rec.Code)
+	})
+
+	rec := do(http.MethodPost, "/groups", `{"name":"backend","description":"api team"}`, as(taro), groupHandler.CreateGroup)
+	require.Equal(t, http.StatusCreated, rec.Code)
+	var group usecase.ResGroup
+	require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &group))
+	groupID := fmt.Sprint(group.ID)
+	membersPath := "/groups/" + groupID + "/members"
+
+	t.Run("正常系: 作成者がオーナーになる", func(t *testing.T) {
+		assert.Equal(t, map[int]string{taro.ID: model.RoleOwner}, roles(t, group.ID))
+	})
+
+	t.Run("正常系: メンバーを一括で追加し、同じユーザーは後勝ちになる", func(t *testing.T) {
+		body := fmt.Sprintf(`{"members":[{"user_id":%d},{"user_id":%d,"role":"owner"},{"user_id":%d,"role":"admin"}]}`, taro.ID, hanako.ID, taro.ID)
+		rec := do(http.MethodPost, membersPath, body, as(taro), groupHandler.AddMembers, groupID)
+		assert.Equal(t, http.StatusOK, rec.Code)
+
+		rec = do(http.MethodGet, "/groups/"+groupID, "", nil, groupHandler.GetGroup, groupID)
+		require.Equal(t, http.StatusOK, rec.Code)
+		var res usecase.ResGetGroup
+		require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &res))
+		require.Len(t, res.Members, 2)
+		assert.Equal(t, map[int]string{taro.ID: model.RoleAdmin, hanako.ID: model.RoleOwner}, roles(t, group.ID))
+
+		rec = do(http.MethodGet, "/users/"+fmt.Sprint(taro.ID)+"/groups", "", nil, groupHandler.GetUserGroups, fmt.Sprint(taro.ID))
+		require.Equal(t, http.StatusOK, rec.Code)
+		var groups usecase.ResGetUserGroups
+		require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &groups))
+		require.Len(t, groups.Groups, 1)
+		assert.Equal(t, "backend", groups.Groups[0].Name)
+		assert.Equal(t, model.RoleAdmin, groups.Groups[0].Role)
+	})
+
+	t.Run("正常系: 管理者はメンバーを追加できるがオーナーにはできない", func(t *testing.T) {
+		body := fmt.Sprintf(`{"members":[{"user_id":%d,"role":"owner"}]}`, saburo.ID)
+		rec := do(http.MethodPost, membersPath, body, as(taro), groupHandler.AddMembers, groupID)
+		assert.Equal(t, http.StatusForbidden, rec.Code)
+
+		body = fmt.Sprintf(`{"members":[{"user_id":%d,"role":"member"}]}`, hanako.ID)
+		rec = do(http.MethodPost, membersPath, body, as(taro), groupHandler.AddMembers, groupID)
+		assert.Equal(t, http.StatusForbidden, rec.Code)
+
+		rec = do(http.MethodDelete, fmt.Sprintf("%s?user_id=%d", membersPath, hanako.ID), "", as(taro), groupHandler.RemoveMembers, groupID)
+		assert.Equal(t, http.StatusForbidden, rec.Code)
+
+		body = fmt.Sprintf(`{"members":[{"user_id":%d}]}`, saburo.ID)
+		rec = do(http.MethodPost, membersPath, body, as(taro), groupHandler.AddMembers, groupID)
+		assert.Equal(t, http.StatusOK, rec.Code)
+
+		assert.Equal(t, map[int]string{taro.ID: model.RoleAdmin, hanako.ID: model.RoleOwner, saburo.ID: model.RoleMember}, roles(t, group.ID))
+	})
+
+	t.Run("異常系: ただのメンバーは変更できない", func(t *testing.T) {
+		rec := do(http.MethodPut, "/groups/"+groupID, `{"name":"renamed"}`, as(saburo), groupHandler.UpdateGroup, groupID)
+		assert.Equal(t, http.StatusForbidden, rec.Code)
+
+		body := fmt.Sprintf(`{"members":[{"user_id":%d,"role":"admin"}]}`, saburo.ID)
+		rec = do(http.MethodPost, membersPath, body, as(saburo), groupHandler.AddMembers, groupID)
+		assert.Equal(t, http.StatusForbidden, rec.Code)
+
+		rec = do(http.MethodDelete, "/groups/"+groupID, "", as(saburo), groupHandler.DeleteGroup, groupID)
+		assert.Equal(t, http.StatusForbidden, rec.Code)
+
+		rec = do(http.MethodDelete, "/groups/"+groupID, "", nil, groupHandler.DeleteGroup, groupID)
+		assert.Equal(t, http.StatusUnauthorized, rec.Code)
+	})
+
+	t.Run("正常系: 管理者と管理者トークンは変更できる", func(t *testing.T) {
+		rec := do(http.MethodPut, "/groups/"+groupID, `{"name":"backend","description":"apis"}`, as(taro), groupHandler.UpdateGroup, groupID)
+		assert.Equal(t, http.StatusOK, rec.Code)
+
+		rec = do(http.MethodPut, "/groups/"+groupID, `{"name":"backend","description":"api team"}`, admin, groupHandler.UpdateGroup, groupID)
+		assert.Equal(t, http.StatusOK, rec.Code)
+	})
+
+	t.Run("異常系: オーナーがいなくなる変更はできない", func(t *testing.T) {
+		body := fmt.Sprintf(`{"members":[{"user_id":%d,"role":"admin"}]}`, hanako.ID)
+		rec := do(http.MethodPost, membersPath, body, as(hanako), groupHandler.AddMembers, groupID)
+		assert.Equal(t, http.StatusBadRequest, rec.Code)
+
+		rec = do(http.MethodDelete, fmt.Sprintf("%s?user_id=%d", membersPath, hanako.ID), "", as(hanako), groupHandler.RemoveMembers, groupID)
+		assert.Equal(t, http.StatusBadRequest, rec.Code)
+
+		assert.Equal(t, model.RoleOwner, roles(t, group.ID)[hanako.ID])
+	})
+
+	t.Run("異常系: 他の組織のユーザーが含まれると何も追加されない", func(t *testing.T) {
+		body := fmt.Sprintf(`{"members":[{"user_id":%d},{"user_id":%d}]}`, saburo.ID, outsider.ID)
+		rec := do(http.MethodPost, membersPath, body, as(hanako), groupHandler.AddMembers, groupID)
+		assert.Equal(t, http.StatusBadRequest, rec.Code)
+
+		assert.Len(t, roles(t, group.ID), 3)
+	})
+
+	t.Run("異常系: 不正なロール", func(t *testing.T) {
+		body := fmt.Sprintf(`{"members":[{"user_id":%d,"role":"guest"}]}`, saburo.ID)
+		rec := do(http.MethodPost, membersPath, body, as(hanako), groupHandler.AddMembers, groupID)
+		assert.Equal(t, http.StatusBadRequest, rec.Code)
+	})
+
+	t.Run("正常系: オーナーはメンバーを一括で削除できる", func(t *testing.T) {
+		path := fmt.Sprintf("%s?user_id=%d&user_id=%d", membersPath, taro.ID, saburo.ID)
+		rec := do(http.MethodDelete, path, "", as(hanako), groupHandler.RemoveMembers, groupID)
+		assert.Equal(t, http.StatusOK, rec.Code)
+
+		assert.Equal(t, map[int]string{hanako.ID: model.RoleOwner}, roles(t, group.ID))
+	})
+
+	t.Run("異常系: メンバーでなければ変更できない", func(t *testing.T) {
+		rec := do(http.MethodDelete, "/groups/"+groupID, "", as(taro), groupHandler.DeleteGroup, groupID)
+		assert.Equal(t, http.StatusForbidden, rec.Code)
+	})
+
+	t.Run("異常系: 一覧の件数が範囲外", func(t *testing.T) {
+		for _, query := range []string{"limit=-1", "limit=101", "offset=-1"} {
+			rec := do(http.MethodGet, "/groups?"+query, "", nil, groupHandler.GetGroupList)
+			assert.Equal(t, http.StatusBadRequest, rec.Code, query)
+		}
+
+		rec := do(http.MethodGet, "/groups?limit=1", "", nil, groupHandler.GetGroupList)
+		assert.Equal(t, http.StatusOK, rec.Code)
+	})
+
+	t.Run("異常系: 存在しないグループ", func(t *testing.T) {
+		rec := do(http.MethodGet, "/groups/0", "", nil, groupHandler.GetGroup, "0")
+		assert.Equal(t, http.StatusNotFound, rec.Code)
+
+		rec = do(http.MethodDelete, "/groups/0", "", as(hanako), groupHandler.DeleteGroup, "0")
+		assert.Equal(t, http.StatusNotFound, rec.Code)
+	})
+
+	t.Run("正常系: オーナーは削除できる", func(t *testing.T) {
+		rec := do(http.MethodDelete, "/groups/"+groupID, "", as(hanako), groupHandler.DeleteGroup, groupID)
+		assert.Equal(t, http.StatusOK, rec.Code)
+	})
+}
@@ -0,0 +1,54 @@
+package handler
+
+import (
+	"net/http"
+
+	"go02/packages/health"
+
+	"github.com/labstack/echo/v4"
+)
+
+type HealthHandler interface {
+	Healthz(c echo.Context) error
+	Readyz(c echo.Context) error
+	Startupz(c echo.Context) error
+}
+
+type healthHandler struct {
+	registry *health.Registry
+}
+
+func NewHealthHandler(registry *health.Registry) HealthHandler {
+	return &healthHandler{
+		registry: registry,
+	}
+}
+
+func (h *healthHandler) Healthz(c echo.Context) error {
+	return h.probe(c, health.Liveness)
+}
+
+func (h *healthHandler) Readyz(c echo.Context) error {
+	return h.probe(c, health.Readiness)
+}
+
+func (h *healthHandler) Startupz(c echo.Context) error {
+	return h.probe(c, health.Startup)
+}
+
+// probe responds 503 when any check fails. The per-check detail is only
+// included when the verbose query parameter is given.
+func (h *healthHandler) probe(c echo.Context, kind health.Kind) error {
+	report := h.registry.Run(c.Request().Context(), kind)
+
+	status := http.StatusOK
+	if report.Status != health.StatusOK {
+		status = http.StatusServiceUnavailable
+	}
+
+	if _, verbose := c.QueryParams()["verbose"]; !verbose {
+		report.Checks = nil
+	}
+
+	return c.JSON(status, report)
+}
@@ -0,0 +1,53 @@
+package handler
+
+import (
+	"net/http"
+
+	"go02/packages/logging"
+	"go02/usecase"
+
+	"github.com/labstack/echo/v4"
+)
+
+type OrganizationHandler interface {
+	CreateOrganization(c echo.Context) error
+}
+
+type organizationHandler struct {
+	organizationUsecase usecase.OrganizationUsecase
+}
+
+func NewOrganizationHandler(organizationUsecase usecase.OrganizationUsecase) OrganizationHandler {
+	return &organizationHandler{
+		organizationUsecase: organizationUsecase,
+	}
+}
+
+func (h *organizationHandler) CreateOrganization(c echo.Context) error {
+	ctx := c.Request().Context()
+
+	var params struct {
+		Slug string `json:"slug"`
+		Name string `json:"name"`
+	}
+
+	if err := c.Bind(&params); err != nil {
+		logging.Errorf(ctx, err, "failed to bind request body: %s", err.Error())
+		return echo.NewHTTPError(http.StatusBadRequest, map[string]any{
+			"message": "bad request",
+		})
+	}
+
+	res, err := h.organizationUsecase.CreateOrganization(ctx, params.Slug, params.Name)
+	if he := inputError(err); he != nil {
+		return he
+	}
+	if err != nil {
+		logging.Errorf(ctx, err, "failed to CreateOrganization: %s", err.Error())
+		return echo.NewHTTPError(http.StatusInternalServerError, map[string]any{
+			"message": http.StatusText(http.StatusInternalServerError),
+		}).SetInternal(err)
+	}
+
+	return c.JSON(http.StatusCreated, res)
+}
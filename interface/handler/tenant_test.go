@@ -0,0 +1,258 @@
+package handler_test
+
+import (
+	"context"
+	"encoding/json"
+	"go02/interface/handler"
+	"go02/middleware"
+	"go02/model"
+	"go02/packages/auth"
+	"go02/packages/db"
+	"go02/packages/metrics"
+	"go02/repository"
+	"go02/testutils"
+	"go02/usecase"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/labstack/echo/v4"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestTenantIsolation(t *testing.T) {
+	// Arrange
+	ctx := context.Background()
+	container := testutils.PrepareContainer(ctx, t)
+	defer container.TearDown()
+
+	conn, err := testutils.OpenDBForTest(t, container.DSN)
+	require.NoError(t, err)
+	require.NoError(t, testutils.MigrateUp(t, container.DSN))
+
+	acme, err := model.NewOrganization("acme", "Acme")
+	require.NoError(t, err)
+	require.NoError(t, repository.NewOrganizationRepository(conn).Create(ctx, acme))
+
+	const (
+		password   = "correct horse battery"
+		adminToken = "admin-token"
+	)
+	insertUser := func(orgID int, handle string) *model.User {
+		user, err := model.NewUser(handle, handle, "", 24)
+		require.NoError(t, err)
+		_, err = conn.NewInsert().Model(user).Exec(db.WithTenant(ctx, orgID))
+		require.NoError(t, err)
+		credential, err := model.NewCredential(user, password)
+		require.NoError(t, err)
+		_, err = conn.NewInsert().Model(credential).Exec(ctx)
+		require.NoError(t, err)
+		return user
+	}
+	// the same handle in both organizations
+	defaultUser := insertUser(model.DefaultOrgID, "taro")
+	acmeUser := insertUser(acme.ID, "taro")
+	insertUser(model.DefaultOrgID, "hanako")
+	assert.Equal(t, acme.ID, acmeUser.OrgID)
+
+	authUsecase := usecase.NewAuthUsecase(
+		repository.NewTransactionRepository(conn),
+		repository.NewUserRepository(conn),
+		repository.NewCredentialRepository(conn),
+		repository.NewSessionRepository(conn),
+		auth.NewSigner([]byte("0123456789abcdef0123456789abcdef"), time.Minute),
+		usecase.LoginPolicy{SessionTTL: time.Hour, MaxFailedAttempts: 3, LockoutDuration: time.Hour},
+		metrics.NewUsecase(nil),
+	)
+	userUsecase := usecase.NewUserUsecase(
+		repository.NewTransactionRepository(conn),
+		repository.NewUserRepository(conn),
+		repository.NewProfileRepository(conn),
+		repository.NewCredentialRepository(conn),
+		repository.NewSessionRepository(conn),
+		metrics.NewUsecase(nil),
+	)
+	authHandler := handler.NewAuthHandler(authUsecase)
+	userHandler := handler.NewUserHandler(userUsecase)
+
+	tenant := middleware.Tenant(middleware.TenantOptions{
+		Organizations: usecase.NewOrganizationUsecase(repository.NewOrganizationRepository(conn), metrics.NewUsecase(nil)),
+		BaseDomain:    "go02.example.com",
+		Default:       "default",
+	})
+	e := echo.New()
+	e.Group("", tenant).POST("/auth/login", authHandler.Login)
+	e.Group("", middleware.RequireAuth(authUsecase, adminToken), tenant).GET("/users/by-handle/:handle", userHandler.GetUserByHandle)
+
+	request := func(method, host, org, token, path, body string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(method, path, strings.NewReader(body))
+		req.Header.Set(echo.HeaderContentType, echo.MIMEApplicationJSON)
+		req.Host = host
+		if org != "" {
+			req.Header.Set(middleware.HeaderOrganization, org)
+		}
+		if token != "" {
+			req.Header.Set(echo.HeaderAuthorization, "Bearer "+token)
+		}
+		rec := httptest.NewRecorder()
+		e.ServeHTTP(rec, req)
+		return rec
+	}
+	login := func(host, org string) string {
+		rec := request(http.MethodPost, host, org, "", "/auth/login", `{"login":"taro","password":"`+password+`"}`)
+		require.Equal(t, http.StatusOK, rec.Code)
+		var res usecase.ResToken
+		require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &res))
+		return res.AccessToken
+	}
+	get := func(host, org, token, path string) (int, usecase.ResGetUser) {
+		rec := request(http.MethodGet, host, org, token, path, "")
+		var res usecase.ResGetUser
+		_ = json.Unmarshal(rec.Body.Bytes(), &res)
+		return rec.Code, res
+	}
+
+	acmeToken := login("api.example.com", "acme")
+	defaultToken := login("api.example.com", "")
+
+	t.Run("正常系: ログインではヘッダーとサブドメインで組織を選べる", func(t *testing.T) {
+		code, res := get("api.example.com", "", acmeToken, "/users/by-handle/taro")
+		assert.Equal(t, http.StatusOK, code)
+		assert.Equal(t, acmeUser.ID, res.ID)
+
+		code, res = get("api.example.com", "", login("acme.go02.example.com", ""), "/users/by-handle/taro")
+		assert.Equal(t, http.StatusOK, code)
+		assert.Equal(t, acmeUser.ID, res.ID)
+
+		code, res = get("api.example.com", "", defaultToken, "/users/by-handle/taro")
+		assert.Equal(t, http.StatusOK, code)
+		assert.Equal(t, defaultUser.ID, res.ID)
+	})
+
+	t.Run("異常系: ログイン後はアクセストークンの組織しか使えない", func(t *testing.T) {
+		code, _ := get("api.example.com", "default", acmeToken, "/users/by-handle/taro")
+		assert.Equal(t, http.StatusForbidden, code)
+
+		code, _ = get("default.go02.example.com", "", acmeToken, "/users/by-handle/taro")
+		assert.Equal(t, http.StatusForbidden, code)
+
+		code, _ = get("api.example.com", "acme", "", "/users/by-handle/taro")
+		assert.Equal(t, http.StatusUnauthorized, code)
+	})
+
+	t.Run("異常系: 他の組織のユーザーは見えない", func(t *testing.T) {
+		code, _ := get("api.example.com", "", acmeToken, "/users/by-handle/hanako")
+		assert.Equal(t, http.StatusNotFound, code)
+	})
+
+	t.Run("正常系: 管理者トークンはヘッダーで組織を選べる", func(t *testing.T) {
+		code, res := get("api.example.com", "acme", adminToken, "/users/by-handle/taro")
+		assert.Equal(t, http.StatusOK, code)
+		assert.Equal(t, acmeUser.ID, res.ID)
+
+		code, res = get("api.example.com", "", adminToken, "/users/by-handle/taro")
+		assert.Equal(t, http.StatusOK, code)
+		assert.Equal(t, defaultUser.ID, res.ID)
+	})
+
+	t.Run("異常系: 存在しない組織", func(t *testing.T) {
+		rec := request(http.MethodPost, "api.example.com", "unknown", "", "/auth/login", `{"login":"taro","password":"`+password+`"}`)
+		assert.Equal(t, http.StatusNotFound, rec.Code)
+	})
+
+	t.Run("異常系: テナントのないクエリは拒否される", func(t *testing.T) {
+		_, err := repository.NewUserRepository(conn).GetOne(ctx, defaultUser.ID)
+		assert.ErrorIs(t, err, db.ErrNoTenant)
+	})
+}
+
+func TestRowLevelSecurity(t *testing.T) {
+	// Arrange
+	ctx := context.Background()
+	container := testutils.PrepareContainer(ctx, t)
+	defer container.TearDown()
+
+	owner, err := testutils.OpenDBForTest(t, container.DSN)
+	require.NoError(t, err)
+	require.NoError(t, testutils.MigrateUp(t, container.DSN))
+	conn, err := testutils.OpenTenantRoleForTest(t, owner, container.DSN)
+	require.NoError(t, err)
+
+	acme, err := model.NewOrganization("acme", "Acme")
+	require.NoError(t, err)
+	require.NoError(t, repository.NewOrganizationRepository(owner).Create(ctx, acme))
+
+	insertUser := func(orgID int, handle string) *model.User {
+		user, err := model.NewUser(handle, handle, "", 24)
+		require.NoError(t, err)
+		_, err = owner.NewInsert().Model(user).Exec(db.WithTenant(ctx, orgID))
+		require.NoError(t, err)
+		return user
+	}
+	insertUser(model.DefaultOrgID, "taro")
+	acmeUser := insertUser(acme.ID, "jiro")
+
+	// raw queries skip the tenant scoping of the models, so only the policies
+	// keep the organizations apart
+	visible := func(ctx context.Context) []int {
+		var ids []int
+		require.NoError(t, db.GetTxOrDB(ctx, conn).NewRaw("SELECT id FROM users ORDER BY id").Scan(ctx, &ids))
+		return ids
+	}
+	acmeCtx := db.WithTenant(ctx, acme.ID)
+
+	t.Run("正常系: テナントを設定した接続では自分の組織の行だけが見える", func(t *testing.T) {
+		err := db.WithTenantConn(acmeCtx, conn, func(ctx context.Context) error {
+			assert.Equal(t, []int{acmeUser.ID}, visible(ctx))
+			return nil
+		})
+		require.NoError(t, err)
+	})
+
+	t.Run("異常系: テナントを設定していない接続では何も見えない", func(t *testing.T) {
+		assert.Empty(t, visible(ctx))
+	})
+
+	t.Run("異常系: 他の組織の行は書き込めない", func(t *testing.T) {
+		err := db.WithTenantConn(acmeCtx, conn, func(ctx context.Context) error {
+			_, err := db.GetTxOrDB(ctx, conn).ExecContext(ctx,
+				"INSERT INTO users (org_id, name, handle, age) VALUES (?, 'saburo', 'saburo', 24)", model.DefaultOrgID)
+			return err
+		})
+		assert.Error(t, err)
+	})
+
+	t.Run("正常系: RowLevelSecurity はリクエストをテナントの接続で処理する", func(t *testing.T) {
+		userHandler := handler.NewUserHandler(usecase.NewUserUsecase(
+			repository.NewTransactionRepository(conn),
+			repository.NewUserRepository(conn),
+			repository.NewProfileRepository(conn),
+			repository.NewCredentialRepository(conn),
+			repository.NewSessionRepository(conn),
+			metrics.NewUsecase(nil),
+		))
+		e := echo.New()
+		e.Use(
+			middleware.Tenant(middleware.TenantOptions{
+				Organizations: usecase.NewOrganizationUsecase(repository.NewOrganizationRepository(conn), metrics.NewUsecase(nil)),
+				Default:       "default",
+			}),
+			middleware.RowLevelSecurity(conn),
+		)
+		e.GET("/users/by-handle/:handle", userHandler.GetUserByHandle)
+
+		get := func(org, path string) int {
+			req := httptest.NewRequest(http.MethodGet, path, nil)
+			req.Header.Set(middleware.HeaderOrganization, org)
+			rec := httptest.NewRecorder()
+			e.ServeHTTP(rec, req)
+			return rec.Code
+		}
+		assert.Equal(t, http.StatusOK, get("acme", "/users/by-handle/jiro"))
+		assert.Equal(t, http.StatusNotFound, get("acme", "/users/by-handle/taro"))
+		assert.Equal(t, http.StatusOK, get("default", "/users/by-handle/taro"))
+	})
+}
@@ -0,0 +1,40 @@
+package handler
+
+import (
+	"fmt"
+	"net/http"
+	"time"
+
+	"go02/packages/logging"
+
+	"github.com/labstack/echo/v4"
+)
+
+// HeaderAcceptTimezone asks for timestamps rendered in an IANA time zone,
+// e.g. "Asia/Tokyo". The tz query parameter takes precedence over it.
+const HeaderAcceptTimezone = "Accept-Timezone"
+
+// requestLocation returns the time zone the response should render
+// timestamps in, UTC unless the request asks for another one. An unknown
+// zone is a 400; so is "Local", which would leak the server's zone.
+func requestLocation(c echo.Context) (*time.Location, error) {
+	name := c.QueryParam("tz")
+	if name == "" {
+		name = c.Request().Header.Get(HeaderAcceptTimezone)
+	}
+	if name == "" {
+		return time.UTC, nil
+	}
+
+	loc, err := time.LoadLocation(name)
+	if err == nil && name == "Local" {
+		err = fmt.Errorf("time zone %q is not an IANA name", name)
+	}
+	if err != nil {
+		logging.Debugf(c.Request().Context(), "invalid timezone: %s", err.Error())
+		return nil, echo.NewHTTPError(http.StatusBadRequest, map[string]any{
+			"message": "invalid timezone",
+		})
+	}
+	return loc, nil
+}
@@ -10,7 +10,7 @@ import (
 	"go02/usecase"
 
 	"github.com/labstack/echo/v4"
-	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/trace"
 )
 
 type UserHandler interface {
@@ -19,6 +19,7 @@ type UserHandler interface {
 	DeleteUser(c echo.Context) error
 	GetUserList(c echo.Context) error
 	GetUserOne(c echo.Context) error
+	GetUserByHandle(c echo.Context) error
 }
 
 type userHandler struct {
@@ -36,6 +37,9 @@ func (h *userHandler) CreateUser(c echo.Context) error {
 
 	var params struct {
 		Name      string `json:"name"`
+		Handle    string `json:"handle"`
+		Email     string `json:"email"`
+		Password  string `json:"password"`
 		Age       int    `json:"age"`
 		Bio       string `json:"bio"`
 		AvatarURL string `json:"avatar_url"`
@@ -48,9 +52,12 @@ func (h *userHandler) CreateUser(c echo.Context) error {
 		})
 	}
 
-	err := h.userUsecase.CreateUser(ctx, params.Name, params.Age, params.Bio, params.AvatarURL)
+	err := h.userUsecase.CreateUser(ctx, params.Name, params.Handle, params.Email, params.Password, params.Age, params.Bio, params.AvatarURL)
 	if err != nil {
 		logging.Errorf(ctx, err, "failed to CreateUser: %s", err.Error())
+		if he := inputError(err); he != nil {
+			return he
+		}
 		return echo.NewHTTPError(http.StatusBadRequest, map[string]any{
 			"message": "failed to create user",
 		})
@@ -71,9 +78,14 @@ func (h *userHandler) UpdateUser(c echo.Context) error {
 			"message": "invalid id",
 		})
 	}
+	if err := authorizeUser(c, id); err != nil {
+		return err
+	}
 
 	var params struct {
 		Name      string `json:"name"`
+		Handle    string `json:"handle"`
+		Email     string `json:"email"`
 		Age       int    `json:"age"`
 		Bio       string `json:"bio"`
 		AvatarURL string `json:"avatar_url"`
@@ -86,8 +98,11 @@ func (h *userHandler) UpdateUser(c echo.Context) error {
 		})
 	}
 
-	if err := h.userUsecase.UpdateUser(ctx, id, params.Name, params.Age, params.Bio, params.AvatarURL); err != nil {
+	if err := h.userUsecase.UpdateUser(ctx, id, params.Name, params.Handle, params.Email, params.Age, params.Bio, params.AvatarURL); err != nil {
 		logging.Errorf(ctx, err, "failed to UpdateUser: %s", err.Error())
+		if he := inputError(err); he != nil {
+			return he
+		}
 		return echo.NewHTTPError(http.StatusBadRequest, map[string]any{
 			"message": "failed to update user",
 		})
@@ -108,6 +123,9 @@ func (h *userHandler) DeleteUser(c echo.Context) error {
 			"message": "invalid id",
 		})
 	}
+	if err := authorizeUser(c, id); err != nil {
+		return err
+	}
 
 	if err := h.userUsecase.DeleteUser(ctx, id); err != nil {
 		logging.Errorf(ctx, err, "failed to DeleteUser: %s", err.Error())
@@ -123,7 +141,7 @@ func (h *userHandler) DeleteUser(c echo.Context) error {
 
 func (h *userHandler) GetUserList(c echo.Context) error {
 	ctx := c.Request().Context()
-	tracer := otel.Tracer("handler")
+	tracer := trace.SpanFromContext(ctx).TracerProvider().Tracer("handler")
 	ctx, span := tracer.Start(ctx, "GetUserList")
 	defer span.End()
 
@@ -139,15 +157,20 @@ func (h *userHandler) GetUserList(c echo.Context) error {
 		})
 	}
 
+	loc, err := requestLocation(c)
+	if err != nil {
+		return err
+	}
+
 	resUsers, err := h.userUsecase.GetUserList(ctx, params.Limit, params.Offset)
 	if err != nil && !errors.Is(err, apperrors.ErrNotFound) {
 		logging.Errorf(ctx, err, "failed to GetUserList: %s", err.Error())
 		return echo.NewHTTPError(http.StatusInternalServerError, map[string]any{
 			"message": http.StatusText(http.StatusInternalServerError),
-		})
+		}).SetInternal(err)
 	}
 
-	return c.JSON(http.StatusOK, resUsers)
+	return c.JSON(http.StatusOK, resUsers.In(loc))
 }
 
 func (h *userHandler) GetUserOne(c echo.Context) error {
@@ -161,6 +184,11 @@ func (h *userHandler) GetUserOne(c echo.Context) error {
 		})
 	}
 
+	loc, err := requestLocation(c)
+	if err != nil {
+		return err
+	}
+
 	resUser, err := h.userUsecase.GetUserOne(ctx, id)
 	if err != nil {
 		logging.Errorf(ctx, err, "failed to GetUserOne: %s", err.Error())
@@ -169,5 +197,29 @@ func (h *userHandler) GetUserOne(c echo.Context) error {
 		})
 	}
 
-	return c.JSON(http.StatusOK, resUser)
+	return c.JSON(http.StatusOK, resUser.In(loc))
+}
+
+func (h *userHandler) GetUserByHandle(c echo.Context) error {
+	ctx := c.Request().Context()
+
+	loc, err := requestLocation(c)
+	if err != nil {
+		return err
+	}
+
+	resUser, err := h.userUsecase.GetUserByHandle(ctx, c.Param("handle"))
+	if errors.Is(err, apperrors.ErrNotFound) {
+		return echo.NewHTTPError(http.StatusNotFound, map[string]any{
+			"message": "user not found",
+		})
+	}
+	if err != nil {
+		logging.Errorf(ctx, err, "failed to GetUserByHandle: %s", err.Error())
+		return echo.NewHTTPError(http.StatusInternalServerError, map[string]any{
+			"message": http.StatusText(http.StatusInternalServerError),
+		}).SetInternal(err)
+	}
+
+	return c.JSON(http.StatusOK, resUser.In(loc))
 }
@@ -4,6 +4,7 @@ import (
 	"context"
 	"go02/interface/handler"
 	"go02/model"
+	"go02/packages/metrics"
 	"go02/repository"
 	"go02/testutils"
 	"go02/usecase"
@@ -11,9 +12,8 @@ import (
 	"net/http/httptest"
 	"net/url"
 	"testing"
+	"time"
 
-	_ "github.com/golang-migrate/migrate/v4/database/postgres"
-	_ "github.com/golang-migrate/migrate/v4/source/file"
 	"github.com/labstack/echo/v4"
 	"github.com/stretchr/testify/assert"
 )
@@ -24,9 +24,13 @@ type Item struct {
 }
 
 func TestGetUserList(t *testing.T) {
+	createdAt := time.Date(2024, 4, 1, 0, 30, 0, 0, time.UTC)
+	updatedAt := time.Date(2024, 4, 2, 15, 0, 0, 0, time.UTC)
+
 	tests := []struct {
 		name             string
 		queryParams      map[string]string
+		header           map[string]string
 		wantError        bool
 		expectedStatus   int
 		expectedFilePath string
@@ -39,8 +43,8 @@ func TestGetUserList(t *testing.T) {
 			expectedStatus:   http.StatusOK,
 			expectedFilePath: "testdata/get_users/ok_res.golden.json",
 			testData: []model.User{
-				{ID: 1, Name: "taro", Age: 24},
-				{ID: 2, Name: "takeshi", Age: 20},
+				{ID: 1, Name: "taro", Handle: "taro", Age: 24, CreatedAt: createdAt, UpdatedAt: updatedAt},
+				{ID: 2, Name: "takeshi", Handle: "takeshi", Age: 20, CreatedAt: createdAt, UpdatedAt: updatedAt},
 			},
 		},
 		{
@@ -58,14 +62,50 @@ func TestGetUserList(t *testing.T) {
 			expectedStatus:   http.StatusOK,
 			expectedFilePath: "testdata/get_users/ok_res_query_param.golden.json",
 			testData: []model.User{
-				{ID: 1, Name: "taro", Age: 24},
-				{ID: 2, Name: "takeshi", Age: 20},
-				{ID: 3, Name: "hanako", Age: 21},
-				{ID: 4, Name: "kana", Age: 27},
-				{ID: 5, Name: "yuki", Age: 18},
-				{ID: 6, Name: "ichiro", Age: 30},
+				{ID: 1, Name: "taro", Handle: "taro", Age: 24, CreatedAt: createdAt, UpdatedAt: updatedAt},
+				{ID: 2, Name: "takeshi", Handle: "takeshi", Age: 20, CreatedAt: createdAt, UpdatedAt: updatedAt},
+				{ID: 3, Name: "hanako", Handle: "hanako", Age: 21, CreatedAt: createdAt, UpdatedAt: updatedAt},
+				{ID: 4, Name: "kana", Handle: "kana", Age: 27, CreatedAt: createdAt, UpdatedAt: updatedAt},
+				{ID: 5, Name: "yuki", Handle: "yuki", Age: 18, CreatedAt: createdAt, UpdatedAt: updatedAt},
+				{ID: 6, Name: "ichiro", Handle: "ichiro", Age: 30, CreatedAt: createdAt, UpdatedAt: updatedAt},
+			},
+		},
+		{
+			name:             "正常系: tz が指定されている場合",
+			queryParams:      map[string]string{"tz": "Asia/Tokyo"},
+			wantError:        false,
+			expectedStatus:   http.StatusOK,
+			expectedFilePath: "testdata/get_users/ok_res_tz.golden.json",
+			testData: []model.User{
+				{ID: 1, Name: "taro", Handle: "taro", Age: 24, CreatedAt: createdAt, UpdatedAt: updatedAt},
+			},
+		},
+		{
+			name:             "正常系: Accept-Timezone が指定されている場合",
+			header:           map[string]string{handler.HeaderAcceptTimezone: "Asia/Tokyo"},
+			wantError:        false,
+			expectedStatus:   http.StatusOK,
+			expectedFilePath: "testdata/get_users/ok_res_tz.golden.json",
+			testData: []model.User{
+				{ID: 1, Name: "taro", Handle: "taro", Age: 24, CreatedAt: createdAt, UpdatedAt: updatedAt},
 			},
 		},
+		{
+			name:             "異常系: tz が不正な場合",
+			queryParams:      map[string]string{"tz": "Mars/Olympus"},
+			wantError:        true,
+			expectedStatus:   http.StatusBadRequest,
+			expectedFilePath: "testdata/get_users/err_res_400_tz.golden.json",
+			testData:         []model.User{},
+		},
+		{
+			name:             "異常系: tz がLocalの場合",
+			queryParams:      map[string]string{"tz": "Local"},
+			wantError:        true,
+			expectedStatus:   http.StatusBadRequest,
+			expectedFilePath: "testdata/get_users/err_res_400_tz.golden.json",
+			testData:         []model.User{},
+		},
 		{
 			name:             "異常系: クエリパラメータの値が不正な場合",
 			queryParams:      map[string]string{"limit": "a", "offset": "b"},
@@ -104,13 +144,17 @@ func TestGetUserList(t *testing.T) {
 				q.Set(k, v)
 			}
 			req := httptest.NewRequest(http.MethodGet, "/users?"+q.Encode(), nil)
+			req = req.WithContext(testutils.DefaultTenant(req.Context()))
+			for k, v := range tt.header {
+				req.Header.Set(k, v)
+			}
 			rec := httptest.NewRecorder()
 			c := e.NewContext(req, rec)
 
 			transactionRepository := repository.NewTransactionRepository(db)
 			userRepository := repository.NewUserRepository(db)
 			profileRepository := repository.NewProfileRepository(db)
-			userUsecase := usecase.NewUserUsecase(transactionRepository, userRepository, profileRepository)
+			userUsecase := usecase.NewUserUsecase(transactionRepository, userRepository, profileRepository, repository.NewCredentialRepository(db), repository.NewSessionRepository(db), metrics.NewUsecase(nil))
 			userHandler := handler.NewUserHandler(userUsecase)
 
 			// Act
@@ -1,25 +1,84 @@
 package router
 
 import (
+	"expvar"
 	"go02/interface/handler"
-	"go02/repository"
-	"go02/usecase"
+	"net/http"
 
 	"github.com/labstack/echo/v4"
-	"github.com/uptrace/bun"
 )
 
-func Init(e *echo.Echo, db *bun.DB) {
+// Handlers are the dependencies of the routes. They are wired by the app
+// package.
+type Handlers struct {
+	User   handler.UserHandler
+	Debug  handler.DebugHandler
+	Health handler.HealthHandler
+	Admin  handler.AdminHandler
+	Email  handler.EmailHandler
+	Auth   handler.AuthHandler
+	Org    handler.OrganizationHandler
+	Group  handler.GroupHandler
+	Follow handler.FollowHandler
 
-	transactionRepository := repository.NewTransactionRepository(db)
-	userRepository := repository.NewUserRepository(db)
-	profileRepository := repository.NewProfileRepository(db)
-	userUsecase := usecase.NewUserUsecase(transactionRepository, userRepository, profileRepository)
-	userHandler := handler.NewUserHandler(userUsecase)
+	Metrics     http.Handler
+	AdminAuth   echo.MiddlewareFunc
+	RequireAuth echo.MiddlewareFunc
+	// resolve the organization of the request, see middleware.Tenant. They
+	// run after RequireAuth on the authenticated routes.
+	Tenant []echo.MiddlewareFunc
+}
+
+func Init(e *echo.Echo, h Handlers) {
+	e.GET("/healthz", h.Health.Healthz)
+	e.GET("/readyz", h.Health.Readyz)
+	e.GET("/startupz", h.Health.Startupz)
+	e.GET("/metrics", echo.WrapHandler(h.Metrics))
+
+	// everything below reads or writes the data of one organization. Signing
+	// up and the routes that hold a token of their own instead of an access
+	// token name it by the subdomain or the X-Organization header.
+	public := e.Group("", h.Tenant...)
+
+	public.POST("/users", h.User.CreateUser)
+	public.POST("/email/verify", h.Email.Verify)
+	public.POST("/auth/login", h.Auth.Login)
+	public.POST("/auth/refresh", h.Auth.Refresh)
+
+	// the rest belongs to the organization of the access token
+	authed := e.Group("", h.RequireAuth)
+	authed.Use(h.Tenant...)
+
+	authed.GET("/users", h.User.GetUserList)
+	authed.GET("/users/by-handle/:handle", h.User.GetUserByHandle)
+	authed.GET("/users/:id", h.User.GetUserOne)
+	authed.PUT("/users/:id", h.User.UpdateUser)
+	authed.DELETE("/users/:id", h.User.DeleteUser)
+	authed.POST("/users/:id/email/verify-request", h.Email.RequestVerification)
+	authed.GET("/users/:id/groups", h.Group.GetUserGroups)
+	authed.GET("/users/:id/followers", h.Follow.GetFollowers)
+	authed.GET("/users/:id/following", h.Follow.GetFollowing)
+	authed.GET("/users/:id/mutuals", h.Follow.GetMutuals)
+	authed.POST("/users/:id/follow", h.Follow.Follow)
+	authed.DELETE("/users/:id/follow", h.Follow.Unfollow)
+
+	authed.POST("/groups", h.Group.CreateGroup)
+	authed.GET("/groups", h.Group.GetGroupList)
+	authed.GET("/groups/:id", h.Group.GetGroup)
+	authed.PUT("/groups/:id", h.Group.UpdateGroup)
+	authed.DELETE("/groups/:id", h.Group.DeleteGroup)
+	authed.POST("/groups/:id/members", h.Group.AddMembers)
+	authed.DELETE("/groups/:id/members", h.Group.RemoveMembers)
+
+	authed.POST("/auth/logout", h.Auth.Logout)
+
+	admin := e.Group("/admin", h.AdminAuth)
+	admin.GET("/log-level", h.Admin.GetLogLevel)
+	admin.PUT("/log-level", h.Admin.UpdateLogLevel)
+	admin.POST("/organizations", h.Org.CreateOrganization)
 
-	e.POST("/users", userHandler.CreateUser)
-	e.GET("/users", userHandler.GetUserList)
-	e.GET("/users/:id", userHandler.GetUserOne)
-	e.PUT("/users/:id", userHandler.UpdateUser)
-	e.DELETE("/users/:id", userHandler.DeleteUser)
+	debug := e.Group("/debug", h.AdminAuth)
+	debug.GET("/db/stats", h.Debug.GetDBStats)
+	debug.GET("/config", h.Debug.GetConfig)
+	debug.GET("/vars", echo.WrapHandler(expvar.Handler()))
 }
@@ -2,65 +2,79 @@ package main
 
 import (
 	"context"
-	"go02/interface/router"
-	"go02/middleware"
+	"fmt"
+	"go02/app"
 	"go02/packages/config"
-	"go02/packages/db"
-	"go02/packages/logging"
-	"go02/packages/tracer"
 	"log"
-	"net/http"
+	"os"
+	"os/signal"
+	"syscall"
+	// time zones for the tz parameter, whatever the base image ships
+	_ "time/tzdata"
 
 	"github.com/cockroachdb/errors"
-	"github.com/labstack/echo/v4"
-	"go.opentelemetry.io/contrib/instrumentation/github.com/labstack/echo/otelecho"
 )
 
+const usage = `usage: go02 [command]
+
+commands:
+  serve                        run the api server (default)
+  migrate up                   apply all pending migrations
+  migrate down [-steps N]      roll back N migrations (default 1)
+  migrate goto VERSION         migrate up or down to VERSION
+  migrate force VERSION        set VERSION without migrating, to recover a dirty database
+  migrate status               print the current and latest migration version
+  migrate lint                 check the migrations for operations unsafe during a rolling update
+  seed                         insert sample data into a development database
+  schema check                 compare the database schema with the bun models
+`
+
 func main() {
-	if err := run(); err != nil {
+	if err := run(os.Args[1:]); err != nil {
 		log.Fatalf("failed to run: %v", err)
 	}
 }
 
-func run() error {
-	ctx := context.Background()
+func run(args []string) error {
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
 
-	err := config.Init()
-	if err != nil {
-		return errors.Wrap(err, "failed to initialize config")
+	cmd := "serve"
+	if len(args) > 0 {
+		cmd, args = args[0], args[1:]
 	}
 
-	logging.Init()
-
-	db, err := db.OpenDB()
-	if err != nil {
-		return errors.Wrap(err, "failed to initialize a new database")
+	switch cmd {
+	case "serve":
+		return serve(ctx)
+	case "migrate":
+		return migrateCommand(ctx, args)
+	case "seed":
+		return seedCommand(ctx)
+	case "schema":
+		return schemaCommand(ctx, args)
+	case "help", "-h", "-help", "--help":
+		fmt.Print(usage)
+		return nil
+	default:
+		return errors.Newf("unknown command %q\n\n%s", cmd, usage)
 	}
+}
 
-	tp := tracer.InitializeTracer()
-	defer func() {
-		if err := tp.Shutdown(ctx); err != nil {
-			log.Fatalf("failed to shutdown tracer: %v", err)
-		}
-	}()
-
-	e := echo.New()
-
-	e.Use(otelecho.Middleware("go02"))
-	e.Use(middleware.Logger())
-
-	router.Init(e, db)
-
-	port := "8080"
-	srv := &http.Server{
-		Addr:    ":" + port,
-		Handler: e,
+func serve(ctx context.Context) error {
+	src := config.SourceFromEnv()
+	cfg, err := config.Load(src)
+	if err != nil {
+		return errors.Wrap(err, "failed to initialize config")
 	}
 
-	logging.Infof(ctx, "listening on port %s", port)
-	if err := srv.ListenAndServe(); err != nil {
-		return errors.Wrap(err, "failed to listen and serve")
+	a, err := app.New(ctx, app.Options{
+		Config: cfg,
+		Source: &src,
+	})
+	if err != nil {
+		return err
 	}
 
-	return nil
+	return a.Run(ctx)
 }
@@ -0,0 +1,23 @@
+package middleware
+
+import (
+	"crypto/subtle"
+
+	"github.com/labstack/echo/v4"
+	echomiddleware "github.com/labstack/echo/v4/middleware"
+)
+
+// AdminAuth protects the admin and debug endpoints with the ADMIN_TOKEN bearer
+// token. Every request is rejected while no token is configured.
+func AdminAuth(token string) echo.MiddlewareFunc {
+	return echomiddleware.KeyAuthWithConfig(echomiddleware.KeyAuthConfig{
+		KeyLookup:  "header:" + echo.HeaderAuthorization,
+		AuthScheme: "Bearer",
+		Validator: func(key string, c echo.Context) (bool, error) {
+			if token == "" {
+				return false, nil
+			}
+			return subtle.ConstantTimeCompare([]byte(key), []byte(token)) == 1, nil
+		},
+	})
+}
@@ -0,0 +1,60 @@
+package middleware
+
+import (
+	"context"
+	"crypto/subtle"
+	"errors"
+	"net/http"
+	"strings"
+
+	"go02/packages/apperrors"
+	"go02/packages/auth"
+	"go02/packages/logging"
+
+	"github.com/labstack/echo/v4"
+)
+
+// Authenticator resolves a bearer access token to the user it was issued to.
+type Authenticator interface {
+	Authenticate(ctx context.Context, accessToken string) (auth.Principal, error)
+}
+
+// RequireAuth rejects requests without a valid access token and puts the
+// principal into the request context, see auth.FromContext. The adminToken,
+// when set, is accepted too and authenticates an admin principal.
+func RequireAuth(a Authenticator, adminToken string) echo.MiddlewareFunc {
+	return func(next echo.HandlerFunc) echo.HandlerFunc {
+		return func(c echo.Context) error {
+			ctx := c.Request().Context()
+
+			token, ok := strings.CutPrefix(c.Request().Header.Get(echo.HeaderAuthorization), "Bearer ")
+			if !ok || token == "" {
+				return unauthorized(c)
+			}
+
+			if adminToken != "" && subtle.ConstantTimeCompare([]byte(token), []byte(adminToken)) == 1 {
+				c.SetRequest(c.Request().WithContext(auth.NewContext(ctx, auth.Principal{Admin: true})))
+				return next(c)
+			}
+
+			p, err := a.Authenticate(ctx, token)
+			if errors.Is(err, apperrors.ErrUnauthorized) {
+				logging.Debug(ctx, "rejected access token: "+err.Error())
+				return unauthorized(c)
+			}
+			if err != nil {
+				return echo.NewHTTPError(http.StatusInternalServerError).SetInternal(err)
+			}
+
+			c.SetRequest(c.Request().WithContext(auth.NewContext(ctx, p)))
+			return next(c)
+		}
+	}
+}
+
+func unauthorized(c echo.Context) error {
+	c.Response().Header().Set(echo.HeaderWWWAuthenticate, `Bearer realm="go02"`)
+	return echo.NewHTTPError(http.StatusUnauthorized, map[string]any{
+		"message": "unauthorized",
+	})
+}
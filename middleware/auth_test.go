@@ -0,0 +1,98 @@
+package middleware_test
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"go02/middleware"
+	"go02/packages/apperrors"
+	"go02/packages/auth"
+
+	"github.com/labstack/echo/v4"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type authenticatorFunc func(ctx context.Context, accessToken string) (auth.Principal, error)
+
+func (f authenticatorFunc) Authenticate(ctx context.Context, accessToken string) (auth.Principal, error) {
+	return f(ctx, accessToken)
+}
+
+func TestRequireAuth(t *testing.T) {
+	authenticator := authenticatorFunc(func(_ context.Context, token string) (auth.Principal, error) {
+		switch token {
+		case "user-token":
+			return auth.Principal{UserID: 1, OrgID: 2, SessionID: 3}, nil
+		case "broken":
+			return auth.Principal{}, errors.New("connection refused")
+		}
+		return auth.Principal{}, apperrors.ErrUnauthorized
+	})
+
+	serve := func(adminToken, authorization string) (*httptest.ResponseRecorder, *auth.Principal, error) {
+		req := httptest.NewRequest(http.MethodPost, "/", nil)
+		if authorization != "" {
+			req.Header.Set(echo.HeaderAuthorization, authorization)
+		}
+		rec := httptest.NewRecorder()
+		c := echo.New().NewContext(req, rec)
+
+		var got *auth.Principal
+		err := middleware.RequireAuth(authenticator, adminToken)(func(c echo.Context) error {
+			p, ok := auth.FromContext(c.Request().Context())
+			require.True(t, ok)
+			got = &p
+			return nil
+		})(c)
+		return rec, got, err
+	}
+	code := func(err error) int {
+		var he *echo.HTTPError
+		require.ErrorAs(t, err, &he)
+		return he.Code
+	}
+
+	t.Run("正常系: アクセストークンのユーザー", func(t *testing.T) {
+		_, p, err := serve("", "Bearer user-token")
+		require.NoError(t, err)
+		assert.Equal(t, &auth.Principal{UserID: 1, OrgID: 2, SessionID: 3}, p)
+	})
+
+	t.Run("正常系: 管理者トークン", func(t *testing.T) {
+		_, p, err := serve("admin-token", "Bearer admin-token")
+		require.NoError(t, err)
+		assert.Equal(t, &auth.Principal{Admin: true}, p)
+		assert.True(t, p.CanActOn(42))
+	})
+
+	t.Run("異常系: トークンがない", func(t *testing.T) {
+		rec, p, err := serve("admin-token", "")
+		assert.Equal(t, http.StatusUnauthorized, code(err))
+		assert.Nil(t, p)
+		assert.Equal(t, `Bearer realm="go02"`, rec.Header().Get(echo.HeaderWWWAuthenticate))
+
+		_, _, err = serve("", "Basic dXNlcjpwYXNz")
+		assert.Equal(t, http.StatusUnauthorized, code(err))
+	})
+
+	t.Run("異常系: 不正なトークン", func(t *testing.T) {
+		_, p, err := serve("admin-token", "Bearer wrong")
+		assert.Equal(t, http.StatusUnauthorized, code(err))
+		assert.Nil(t, p)
+	})
+
+	t.Run("異常系: 管理者トークンが未設定なら空のトークンも通らない", func(t *testing.T) {
+		_, _, err := serve("", "Bearer ")
+		assert.Equal(t, http.StatusUnauthorized, code(err))
+	})
+
+	t.Run("異常系: 認証できない", func(t *testing.T) {
+		_, p, err := serve("", "Bearer broken")
+		assert.Equal(t, http.StatusInternalServerError, code(err))
+		assert.Nil(t, p)
+	})
+}
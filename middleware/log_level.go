@@ -0,0 +1,49 @@
+package middleware
+
+import (
+	"log/slog"
+
+	"go02/packages/config"
+	"go02/packages/logging"
+
+	"github.com/labstack/echo/v4"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// LogLevel elevates the log level for a single request when the level header
+// is sent, or to debug for sampled traces when LOG_DEBUG_SAMPLED is set. Both
+// settings are read per request so that they follow config reloads. The
+// header is off unless LOG_LEVEL_HEADER names one, since any client can send
+// it.
+func LogLevel(store *config.Store) echo.MiddlewareFunc {
+	return func(next echo.HandlerFunc) echo.HandlerFunc {
+		return func(c echo.Context) error {
+			cfg := store.Get().Logging
+			header := cfg.LevelHeader
+			debugSampled := cfg.DebugSampled
+
+			req := c.Request()
+			ctx := req.Context()
+
+			elevated := false
+			if header != "" {
+				if v := req.Header.Get(header); v != "" {
+					if l, err := logging.ParseLevel(v); err == nil {
+						ctx = logging.WithLevel(ctx, l)
+						elevated = true
+					}
+				}
+			}
+			if !elevated && debugSampled && trace.SpanContextFromContext(ctx).IsSampled() {
+				ctx = logging.WithLevel(ctx, slog.LevelDebug)
+				elevated = true
+			}
+
+			if elevated {
+				c.SetRequest(req.WithContext(ctx))
+			}
+
+			return next(c)
+		}
+	}
+}
@@ -5,27 +5,29 @@ import (
 	"log/slog"
 	"time"
 
+	"go02/packages/logging"
+
 	"github.com/labstack/echo/v4"
 	echomiddleware "github.com/labstack/echo/v4/middleware"
 )
 
 type HTTPRequest struct {
-	RequestMethod string   `json:"requestMethod"`
-	RequestURL    string   `json:"requestUrl"`
-	RequestSize   string   `json:"requestSize,omitempty"`
-	Status        int      `json:"status"`
-	ResponseSize  string   `json:"responseSize,omitempty"`
-	UserAgent     string   `json:"userAgent,omitempty"`
-	RemoteIP      string   `json:"remoteIp,omitempty"`
-	ServerIP      string   `json:"serverIp,omitempty"`
-	Referer       string   `json:"referer,omitempty"`
-	Latency       Duration `json:"latency"`
-	Protocol      string   `json:"protocol"`
+	RequestMethod string   `json:"requestMethod" log:"allow"`
+	RequestURL    string   `json:"requestUrl" log:"allow"`
+	RequestSize   string   `json:"requestSize,omitempty" log:"allow"`
+	Status        int      `json:"status" log:"allow"`
+	ResponseSize  string   `json:"responseSize,omitempty" log:"allow"`
+	UserAgent     string   `json:"userAgent,omitempty" log:"allow"`
+	RemoteIP      string   `json:"remoteIp,omitempty" log:"allow"`
+	ServerIP      string   `json:"serverIp,omitempty" log:"allow"`
+	Referer       string   `json:"referer,omitempty" log:"allow"`
+	Latency       Duration `json:"latency" log:"allow"`
+	Protocol      string   `json:"protocol" log:"allow"`
 }
 
 type Duration struct {
-	Nanos   int32 `json:"nanos"`
-	Seconds int64 `json:"seconds"`
+	Nanos   int32 `json:"nanos" log:"allow"`
+	Seconds int64 `json:"seconds" log:"allow"`
 }
 
 func MakeDuration(d time.Duration) Duration {
@@ -39,8 +41,8 @@ func MakeDuration(d time.Duration) Duration {
 }
 
 func Logger() echo.MiddlewareFunc {
-	logger := slog.Default()
 	return echomiddleware.RequestLoggerWithConfig(echomiddleware.RequestLoggerConfig{
+		Skipper:          SkipHealthCheck,
 		LogMethod:        true,
 		LogURI:           true,
 		LogContentLength: true,
@@ -55,6 +57,7 @@ func Logger() echo.MiddlewareFunc {
 		HandleError:      true,
 		LogValuesFunc: func(c echo.Context, v echomiddleware.RequestLoggerValues) error {
 			ctx := c.Request().Context()
+			logger := logging.FromContext(ctx)
 
 			if v.Error == nil {
 				httpRequest := HTTPRequest{
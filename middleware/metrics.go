@@ -0,0 +1,57 @@
+package middleware
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/labstack/echo/v4"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/metric"
+)
+
+// Metrics records RED metrics per route template.
+func Metrics(mp metric.MeterProvider) echo.MiddlewareFunc {
+	meter := mp.Meter("go02/http")
+
+	requests, _ := meter.Int64Counter("http.server.request.count",
+		metric.WithDescription("Number of HTTP requests."),
+	)
+	duration, _ := meter.Float64Histogram("http.server.request.duration",
+		metric.WithDescription("Duration of HTTP requests."),
+		metric.WithUnit("s"),
+	)
+
+	return func(next echo.HandlerFunc) echo.HandlerFunc {
+		return func(c echo.Context) error {
+			if SkipHealthCheck(c) || c.Path() == "/metrics" {
+				return next(c)
+			}
+
+			start := time.Now()
+			err := next(c)
+
+			status := c.Response().Status
+			if err != nil {
+				var he *echo.HTTPError
+				if errors.As(err, &he) {
+					status = he.Code
+				} else {
+					status = http.StatusInternalServerError
+				}
+			}
+
+			attrs := metric.WithAttributes(
+				attribute.String("http.request.method", c.Request().Method),
+				attribute.String("http.route", c.Path()),
+				attribute.Int("http.response.status_code", status),
+				attribute.String("http.response.status_class", fmt.Sprintf("%dxx", status/100)),
+			)
+			requests.Add(c.Request().Context(), 1, attrs)
+			duration.Record(c.Request().Context(), time.Since(start).Seconds(), attrs)
+
+			return err
+		}
+	}
+}
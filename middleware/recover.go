@@ -0,0 +1,90 @@
+package middleware
+
+import (
+	"fmt"
+	"net/http"
+	"runtime"
+	"strings"
+
+	"go02/packages/logging"
+
+	cerrors "github.com/cockroachdb/errors"
+	"github.com/labstack/echo/v4"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/metric"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// Recover converts a panic in a handler into a 500 response. The crash is
+// logged with its stack trace and the source location of the panic, the span
+// is marked as errored and the panic counter is incremented.
+func Recover(mp metric.MeterProvider) echo.MiddlewareFunc {
+	panics, _ := mp.Meter("go02/http").Int64Counter("http.server.panics",
+		metric.WithDescription("Number of panics recovered in HTTP handlers."),
+	)
+
+	return func(next echo.HandlerFunc) echo.HandlerFunc {
+		return func(c echo.Context) (err error) {
+			defer func() {
+				r := recover()
+				if r == nil {
+					return
+				}
+				if r == http.ErrAbortHandler {
+					panic(r)
+				}
+
+				perr, ok := r.(error)
+				if ok {
+					perr = cerrors.WithStack(cerrors.Wrap(perr, "panic"))
+				} else {
+					perr = cerrors.Newf("panic: %v", r)
+				}
+
+				ctx := c.Request().Context()
+
+				span := trace.SpanFromContext(ctx)
+				span.RecordError(perr, trace.WithStackTrace(true))
+				span.SetStatus(codes.Error, fmt.Sprintf("panic: %v", r))
+
+				panics.Add(ctx, 1, metric.WithAttributes(
+					attribute.String("http.request.method", c.Request().Method),
+					attribute.String("http.route", c.Path()),
+				))
+
+				logging.ErrorAt(ctx, panicPC(), perr, fmt.Sprintf("recovered from panic: %v", r))
+
+				err = echo.NewHTTPError(http.StatusInternalServerError, map[string]any{
+					"message": http.StatusText(http.StatusInternalServerError),
+				}).SetInternal(perr)
+			}()
+
+			return next(c)
+		}
+	}
+}
+
+// panicPC returns the program counter of the frame that raised the panic,
+// skipping the runtime frames between it and the deferred recover.
+func panicPC() uintptr {
+	pcs := make([]uintptr, 32)
+	n := runtime.Callers(3, pcs)
+	frames := runtime.CallersFrames(pcs[:n])
+
+	panicking := false
+	for {
+		frame, more := frames.Next()
+		if panicking && !strings.HasPrefix(frame.Function, "runtime.") {
+			return frame.PC
+		}
+		if frame.Function == "runtime.gopanic" {
+			panicking = true
+		}
+		if !more {
+			break
+		}
+	}
+
+	return pcs[0]
+}
@@ -0,0 +1,87 @@
+package middleware_test
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"go02/middleware"
+	"go02/packages/logging"
+
+	"github.com/labstack/echo/v4"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	sdkmetric "go.opentelemetry.io/otel/sdk/metric"
+	"go.opentelemetry.io/otel/sdk/metric/metricdata"
+)
+
+func TestRecover(t *testing.T) {
+	reader := sdkmetric.NewManualReader()
+	recoverMiddleware := middleware.Recover(sdkmetric.NewMeterProvider(sdkmetric.WithReader(reader)))
+
+	serve := func(h echo.HandlerFunc) (error, map[string]any) {
+		buf := &bytes.Buffer{}
+		ctx := logging.NewContext(context.Background(), slog.New(slog.NewJSONHandler(buf, &slog.HandlerOptions{AddSource: true})))
+		req := httptest.NewRequest(http.MethodGet, "/users", nil).WithContext(ctx)
+		c := echo.New().NewContext(req, httptest.NewRecorder())
+		c.SetPath("/users")
+
+		err := recoverMiddleware(h)(c)
+
+		var record map[string]any
+		if buf.Len() > 0 {
+			require.NoError(t, json.Unmarshal(buf.Bytes(), &record))
+		}
+		return err, record
+	}
+
+	t.Run("正常系: パニックしなければそのまま返す", func(t *testing.T) {
+		err, record := serve(func(c echo.Context) error { return nil })
+		assert.NoError(t, err)
+		assert.Nil(t, record)
+	})
+
+	t.Run("正常系: パニックを500にしてパニックした場所を記録する", func(t *testing.T) {
+		err, record := serve(func(c echo.Context) error { panic(errors.New("boom")) })
+
+		var herr *echo.HTTPError
+		require.ErrorAs(t, err, &herr)
+		assert.Equal(t, http.StatusInternalServerError, herr.Code)
+		assert.ErrorContains(t, herr.Internal, "panic: boom")
+
+		assert.Equal(t, "ERROR", record["level"])
+		assert.Equal(t, "recovered from panic: boom", record["msg"])
+		source := record["source"].(map[string]any)
+		assert.Contains(t, source["file"], "recover_test.go")
+		assert.Contains(t, source["function"], "TestRecover")
+	})
+
+	t.Run("正常系: error以外の値", func(t *testing.T) {
+		err, record := serve(func(c echo.Context) error { panic("nil map") })
+
+		var herr *echo.HTTPError
+		require.ErrorAs(t, err, &herr)
+		assert.ErrorContains(t, herr.Internal, "panic: nil map")
+		assert.Equal(t, "recovered from panic: nil map", record["msg"])
+	})
+
+	t.Run("正常系: パニックを数える", func(t *testing.T) {
+		var rm metricdata.ResourceMetrics
+		require.NoError(t, reader.Collect(context.Background(), &rm))
+		require.Len(t, rm.ScopeMetrics, 1)
+		sum := rm.ScopeMetrics[0].Metrics[0].Data.(metricdata.Sum[int64])
+		require.Len(t, sum.DataPoints, 1)
+		assert.Equal(t, int64(2), sum.DataPoints[0].Value)
+	})
+
+	t.Run("異常系: ErrAbortHandlerはパニックし直す", func(t *testing.T) {
+		assert.PanicsWithValue(t, http.ErrAbortHandler, func() {
+			serve(func(c echo.Context) error { panic(http.ErrAbortHandler) })
+		})
+	})
+}
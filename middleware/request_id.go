@@ -0,0 +1,32 @@
+package middleware
+
+import (
+	"go02/packages/requestid"
+
+	"github.com/labstack/echo/v4"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// RequestID accepts the caller's X-Request-ID or generates one, and makes it
+// available to logs, traces, response headers and error bodies.
+func RequestID() echo.MiddlewareFunc {
+	return func(next echo.HandlerFunc) echo.HandlerFunc {
+		return func(c echo.Context) error {
+			req := c.Request()
+
+			id := req.Header.Get(requestid.Header)
+			if !requestid.Valid(id) {
+				id = requestid.Generate()
+			}
+
+			ctx := requestid.NewContext(req.Context(), id)
+			c.SetRequest(req.WithContext(ctx))
+			c.Response().Header().Set(requestid.Header, id)
+
+			trace.SpanFromContext(ctx).SetAttributes(attribute.String("http.request_id", id))
+
+			return next(c)
+		}
+	}
+}
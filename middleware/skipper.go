@@ -0,0 +1,18 @@
+package middleware
+
+import (
+	"github.com/labstack/echo/v4"
+)
+
+var healthCheckPaths = map[string]struct{}{
+	"/healthz":  {},
+	"/readyz":   {},
+	"/startupz": {},
+}
+
+// SkipHealthCheck skips the probe endpoints polled by Kubernetes so that they
+// don't flood request logs and traces.
+func SkipHealthCheck(c echo.Context) bool {
+	_, ok := healthCheckPaths[c.Request().URL.Path]
+	return ok
+}
@@ -0,0 +1,126 @@
+package middleware
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"net"
+	"net/http"
+	"strings"
+
+	"go02/packages/apperrors"
+	"go02/packages/auth"
+	"go02/packages/db"
+	"go02/packages/logging"
+
+	"github.com/labstack/echo/v4"
+	"github.com/uptrace/bun"
+)
+
+// HeaderOrganization names the organization of a request by slug, for
+// clients that can't use a subdomain.
+const HeaderOrganization = "X-Organization"
+
+// OrganizationResolver looks up organizations by slug. Unknown slugs are
+// apperrors.ErrNotFound.
+type OrganizationResolver interface {
+	ResolveOrganization(ctx context.Context, slug string) (int, error)
+}
+
+type TenantOptions struct {
+	Organizations OrganizationResolver
+	// <slug>.<BaseDomain> names the organization; empty ignores the host
+	BaseDomain string
+	// the slug of the organization of requests that don't name one; empty
+	// rejects them
+	Default string
+}
+
+// Tenant resolves the organization of the request and scopes the queries of
+// the handlers to it, see db.WithTenant. An authenticated request belongs to
+// the organization of its access token only, so Tenant goes after
+// RequireAuth there and rejects a subdomain or X-Organization header naming
+// another one. The routes before login, and the admin token which belongs to
+// no organization, name it by the subdomain, the header or the default.
+func Tenant(opts TenantOptions) echo.MiddlewareFunc {
+	return func(next echo.HandlerFunc) echo.HandlerFunc {
+		return func(c echo.Context) error {
+			req := c.Request()
+			ctx := req.Context()
+
+			var orgID int
+			if p, ok := auth.FromContext(ctx); ok && !p.Admin {
+				orgID = p.OrgID
+			}
+
+			slug := subdomain(req.Host, opts.BaseDomain)
+			if slug == "" {
+				slug = req.Header.Get(HeaderOrganization)
+			}
+			if slug == "" && orgID == 0 {
+				slug = opts.Default
+			}
+
+			if slug != "" {
+				id, err := opts.Organizations.ResolveOrganization(ctx, strings.ToLower(slug))
+				if errors.Is(err, apperrors.ErrNotFound) {
+					return echo.NewHTTPError(http.StatusNotFound, map[string]any{
+						"message": "organization not found",
+					})
+				}
+				if err != nil {
+					return echo.NewHTTPError(http.StatusInternalServerError).SetInternal(err)
+				}
+				if orgID != 0 && id != orgID {
+					return echo.NewHTTPError(http.StatusForbidden, map[string]any{
+						"message": "the access token belongs to another organization",
+					})
+				}
+				orgID = id
+			}
+
+			if orgID == 0 {
+				return echo.NewHTTPError(http.StatusBadRequest, map[string]any{
+					"message": "organization is required",
+				})
+			}
+
+			ctx = db.WithTenant(ctx, orgID)
+			ctx = logging.NewContext(ctx, logging.FromContext(ctx).With(slog.Int("org_id", orgID)))
+			c.SetRequest(req.WithContext(ctx))
+			return next(c)
+		}
+	}
+}
+
+// subdomain returns the label in front of base, e.g. acme for
+// acme.go02.example.com:8080.
+func subdomain(host, base string) string {
+	if base == "" {
+		return ""
+	}
+	if h, _, err := net.SplitHostPort(host); err == nil {
+		host = h
+	}
+
+	label, ok := strings.CutSuffix(strings.ToLower(host), "."+strings.ToLower(base))
+	if !ok || strings.Contains(label, ".") {
+		return ""
+	}
+	return label
+}
+
+// RowLevelSecurity runs the rest of the request on a connection with the
+// tenant set for the row level security policies, see db.WithTenantConn. It
+// goes after Tenant.
+func RowLevelSecurity(conn *bun.DB) echo.MiddlewareFunc {
+	return func(next echo.HandlerFunc) echo.HandlerFunc {
+		return func(c echo.Context) error {
+			req := c.Request()
+			return db.WithTenantConn(req.Context(), conn, func(ctx context.Context) error {
+				c.SetRequest(req.WithContext(ctx))
+				return next(c)
+			})
+		}
+	}
+}
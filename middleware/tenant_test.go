@@ -0,0 +1,111 @@
+package middleware_test
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"go02/middleware"
+	"go02/packages/apperrors"
+	"go02/packages/auth"
+	"go02/packages/db"
+
+	"github.com/labstack/echo/v4"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type organizations map[string]int
+
+func (o organizations) ResolveOrganization(_ context.Context, slug string) (int, error) {
+	if id, ok := o[slug]; ok {
+		return id, nil
+	}
+	return 0, apperrors.ErrNotFound
+}
+
+func TestTenant(t *testing.T) {
+	tenant := middleware.Tenant(middleware.TenantOptions{
+		Organizations: organizations{"default": 1, "acme": 2},
+		BaseDomain:    "go02.example.com",
+		Default:       "default",
+	})
+
+	serve := func(p *auth.Principal, host, org string) (int, error) {
+		req := httptest.NewRequest(http.MethodGet, "/", nil)
+		req.Host = host
+		if org != "" {
+			req.Header.Set(middleware.HeaderOrganization, org)
+		}
+		if p != nil {
+			req = req.WithContext(auth.NewContext(req.Context(), *p))
+		}
+		c := echo.New().NewContext(req, httptest.NewRecorder())
+
+		var got int
+		err := tenant(func(c echo.Context) error {
+			orgID, ok := db.TenantFromContext(c.Request().Context())
+			require.True(t, ok)
+			got = orgID
+			return nil
+		})(c)
+		return got, err
+	}
+	code := func(err error) int {
+		var he *echo.HTTPError
+		require.ErrorAs(t, err, &he)
+		return he.Code
+	}
+	user := &auth.Principal{UserID: 1, OrgID: 2}
+
+	t.Run("正常系: ログイン前はサブドメイン、ヘッダー、デフォルトの順", func(t *testing.T) {
+		orgID, err := serve(nil, "acme.go02.example.com", "default")
+		require.NoError(t, err)
+		assert.Equal(t, 2, orgID)
+
+		orgID, err = serve(nil, "api.example.com", "ACME")
+		require.NoError(t, err)
+		assert.Equal(t, 2, orgID)
+
+		orgID, err = serve(nil, "api.example.com", "")
+		require.NoError(t, err)
+		assert.Equal(t, 1, orgID)
+	})
+
+	t.Run("正常系: ログイン後はアクセストークンの組織", func(t *testing.T) {
+		orgID, err := serve(user, "api.example.com", "")
+		require.NoError(t, err)
+		assert.Equal(t, 2, orgID)
+
+		orgID, err = serve(user, "acme.go02.example.com", "acme")
+		require.NoError(t, err)
+		assert.Equal(t, 2, orgID)
+	})
+
+	t.Run("異常系: アクセストークンと違う組織", func(t *testing.T) {
+		_, err := serve(user, "api.example.com", "default")
+		assert.Equal(t, http.StatusForbidden, code(err))
+
+		_, err = serve(user, "default.go02.example.com", "")
+		assert.Equal(t, http.StatusForbidden, code(err))
+	})
+
+	t.Run("正常系: 管理者トークンはヘッダーで組織を選ぶ", func(t *testing.T) {
+		orgID, err := serve(&auth.Principal{Admin: true}, "api.example.com", "acme")
+		require.NoError(t, err)
+		assert.Equal(t, 2, orgID)
+	})
+
+	t.Run("異常系: 存在しない組織", func(t *testing.T) {
+		_, err := serve(nil, "api.example.com", "unknown")
+		assert.Equal(t, http.StatusNotFound, code(err))
+	})
+
+	t.Run("異常系: 組織がない", func(t *testing.T) {
+		err := middleware.Tenant(middleware.TenantOptions{Organizations: organizations{}})(func(echo.Context) error {
+			return nil
+		})(echo.New().NewContext(httptest.NewRequest(http.MethodGet, "/", nil), httptest.NewRecorder()))
+		assert.Equal(t, http.StatusBadRequest, code(err))
+	})
+}
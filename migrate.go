@@ -0,0 +1,160 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"flag"
+	"fmt"
+	dbmigration "go02/db-migration"
+	"go02/packages/config"
+	"go02/packages/db"
+	"go02/packages/logging"
+	"os"
+	"strconv"
+
+	"github.com/cockroachdb/errors"
+)
+
+func migrateCommand(ctx context.Context, args []string) error {
+	if len(args) == 0 {
+		return errors.Newf("missing migrate subcommand\n\n%s", usage)
+	}
+	sub, args := args[0], args[1:]
+	switch sub {
+	case "lint":
+		return lintCommand()
+	case "up", "down", "goto", "force", "status":
+	default:
+		return errors.Newf("unknown migrate subcommand %q\n\n%s", sub, usage)
+	}
+
+	cfg, ctx, err := loadForCommand(ctx)
+	if err != nil {
+		return err
+	}
+
+	m, err := db.NewMigrator(ctx, db.URL(cfg.DB))
+	if err != nil {
+		return err
+	}
+	defer m.Close()
+
+	switch sub {
+	case "up":
+		err = m.Up()
+	case "down":
+		fs := flag.NewFlagSet("migrate down", flag.ContinueOnError)
+		steps := fs.Int("steps", 1, "number of migrations to roll back")
+		if err := fs.Parse(args); err != nil {
+			return err
+		}
+		if *steps < 1 {
+			return errors.New("-steps must be at least 1")
+		}
+		err = m.Down(*steps)
+	case "goto":
+		version, perr := versionArg(args)
+		if perr != nil {
+			return perr
+		}
+		if version < 0 {
+			return errors.New("VERSION must not be negative")
+		}
+		err = m.Goto(uint(version))
+	case "force":
+		version, perr := versionArg(args)
+		if perr != nil {
+			return perr
+		}
+		err = m.Force(version)
+	case "status":
+		status, serr := m.Status()
+		if serr != nil {
+			return serr
+		}
+		enc := json.NewEncoder(os.Stdout)
+		enc.SetIndent("", "  ")
+		return enc.Encode(status)
+	}
+	if err != nil {
+		return errors.Wrapf(err, "failed to migrate %s", sub)
+	}
+
+	status, err := m.Status()
+	if err != nil {
+		return err
+	}
+	logging.Infof(ctx, "database is at migration version %d of %d", status.Version, status.Latest)
+
+	return nil
+}
+
+// lintCommand prints the unsafe operations in the embedded migrations. It
+// doesn't need a database, so it runs in CI before anything is deployed.
+func lintCommand() error {
+	findings, err := dbmigration.LintMigrations()
+	if err != nil {
+		return errors.Wrap(err, "failed to lint migrations")
+	}
+	for _, f := range findings {
+		fmt.Println(f)
+	}
+	if len(findings) > 0 {
+		return errors.Newf("%d unsafe migration statement(s)", len(findings))
+	}
+	return nil
+}
+
+func seedCommand(ctx context.Context) error {
+	cfg, ctx, err := loadForCommand(ctx)
+	if err != nil {
+		return err
+	}
+	if cfg.Env == "production" {
+		return errors.New("refusing to seed a production database")
+	}
+
+	conn, err := db.Open(db.Options{Config: cfg.DB})
+	if err != nil {
+		return err
+	}
+	defer conn.Close()
+
+	if err := db.WaitReady(ctx, conn, cfg.DB); err != nil {
+		return err
+	}
+
+	return db.Seed(ctx, conn)
+}
+
+// loadForCommand loads the configuration and returns a context carrying the
+// configured logger.
+func loadForCommand(ctx context.Context) (*config.Config, context.Context, error) {
+	cfg, err := config.Load(config.SourceFromEnv())
+	if err != nil {
+		return nil, nil, errors.Wrap(err, "failed to initialize config")
+	}
+
+	logger, err := logging.New(logging.Options{
+		Level:     cfg.Logging.Level,
+		Redaction: cfg.Logging.Redaction,
+		Format:    cfg.Logging.Format,
+		Profile:   cfg.Logging.Profile,
+	})
+	if err != nil {
+		return nil, nil, errors.Wrap(err, "failed to initialize logging")
+	}
+
+	return cfg, logging.NewContext(ctx, logger.Logger), nil
+}
+
+func versionArg(args []string) (int, error) {
+	if len(args) != 1 {
+		return 0, errors.Newf("expected a single VERSION argument\n\n%s", usage)
+	}
+	version, err := strconv.Atoi(args[0])
+	if err != nil {
+		return 0, errors.Newf("invalid VERSION %q", args[0])
+	}
+	return version, nil
+}
@@ -0,0 +1,125 @@
+package model
+
+import (
+	"context"
+	"fmt"
+	"go02/packages/apperrors"
+	"go02/packages/auth"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"github.com/uptrace/bun"
+)
+
+const (
+	MinPasswordLength = 12
+	// argon2 hashes the whole password, so very long ones are a cheap way to
+	// burn CPU
+	MaxPasswordLength = 128
+)
+
+// commonPasswords are rejected even when long enough.
+var commonPasswords = map[string]struct{}{
+	"password1234": {}, "passwordpassword": {}, "123456789012": {},
+	"qwertyuiopas": {}, "iloveyou1234": {}, "administrator": {},
+	"letmein12345": {}, "welcome12345": {},
+}
+
+// Credential is the password of a user, and the failed logins counting
+// towards a lockout.
+type Credential struct {
+	bun.BaseModel `bun:"table:credentials"`
+
+	UserID         int       `bun:"user_id,pk" log:"allow"`
+	PasswordHash   string    `bun:"password_hash" log:"drop"`
+	FailedAttempts int       `bun:"failed_attempts" log:"allow"`
+	LockedUntil    time.Time `bun:"locked_until,nullzero" log:"allow"`
+
+	CreatedAt time.Time `bun:",nullzero" log:"allow"`
+	UpdatedAt time.Time `bun:",nullzero" log:"allow"`
+}
+
+// NewCredential checks password against the policy and hashes it for user.
+// It takes the ID of user, so a credential built before the user is created
+// needs UserID set afterwards.
+func NewCredential(user *User, password string) (*Credential, error) {
+	if err := validatePassword(user, password); err != nil {
+		return nil, err
+	}
+
+	hash, err := auth.HashPassword(password)
+	if err != nil {
+		return nil, err
+	}
+
+	return &Credential{
+		UserID:       user.ID,
+		PasswordHash: hash,
+	}, nil
+}
+
+// validatePassword is the password policy: long enough, not too long, not a
+// well known password and not containing the user's handle or email.
+func validatePassword(user *User, password string) error {
+	invalid := func(reason string) error {
+		return &apperrors.ValidationError{Field: "password", Reason: reason}
+	}
+
+	n := utf8.RuneCountInString(password)
+	switch {
+	case n < MinPasswordLength:
+		return invalid(fmt.Sprintf("must be at least %d characters", MinPasswordLength))
+	case len(password) > MaxPasswordLength:
+		return invalid(fmt.Sprintf("must be at most %d bytes", MaxPasswordLength))
+	case strings.Count(password, string([]rune(password)[:1])) == n:
+		return invalid("must not repeat a single character")
+	}
+
+	lower := strings.ToLower(password)
+	if _, ok := commonPasswords[lower]; ok {
+		return invalid("is too common")
+	}
+	for _, personal := range []string{user.Handle, strings.Split(user.Email, "@")[0]} {
+		if len(personal) >= 3 && strings.Contains(lower, strings.ToLower(personal)) {
+			return invalid("must not contain your handle or email")
+		}
+	}
+
+	return nil
+}
+
+// Locked reports whether logins are refused because of failed attempts.
+func (c *Credential) Locked(now time.Time) bool {
+	return now.Before(c.LockedUntil)
+}
+
+// RecordFailure counts a failed login and locks the credential for lockout
+// once maxAttempts have failed in a row.
+func (c *Credential) RecordFailure(now time.Time, maxAttempts int, lockout time.Duration) {
+	c.FailedAttempts++
+	if c.FailedAttempts >= maxAttempts {
+		c.LockedUntil = now.Add(lockout).UTC()
+		c.FailedAttempts = 0
+	}
+}
+
+func (c *Credential) RecordSuccess() {
+	c.FailedAttempts = 0
+	c.LockedUntil = time.Time{}
+}
+
+var (
+	_ bun.BeforeAppendModelHook = (*Credential)(nil)
+	_ bun.AfterScanRowHook      = (*Credential)(nil)
+)
+
+func (c *Credential) BeforeAppendModel(ctx context.Context, query bun.Query) error {
+	touchTimestamps(query, &c.CreatedAt, &c.UpdatedAt)
+	return nil
+}
+
+func (c *Credential) AfterScanRow(ctx context.Context) error {
+	toUTC(&c.LockedUntil, &c.CreatedAt, &c.UpdatedAt)
+	return nil
+}
@@ -0,0 +1,76 @@
+package model_test
+
+import (
+	"go02/model"
+	"go02/packages/apperrors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestNewCredentialPasswordPolicy(t *testing.T) {
+	user, err := model.NewUser("taro", "taro", "taro.yamada@example.com", 24)
+	require.NoError(t, err)
+
+	tests := []struct {
+		name     string
+		password string
+		wantErr  bool
+	}{
+		{name: "正常系: ポリシーを満たす", password: "correct horse battery"},
+		{name: "異常系: 短すぎる", password: "short pass", wantErr: true},
+		{name: "異常系: 長すぎる", password: string(make([]byte, 129)), wantErr: true},
+		{name: "異常系: 同じ文字の繰り返し", password: "aaaaaaaaaaaaaaaa", wantErr: true},
+		{name: "異常系: 同じマルチバイト文字の繰り返し", password: "ああああああああああああ", wantErr: true},
+		{name: "異常系: よく使われるパスワード", password: "Password1234", wantErr: true},
+		{name: "異常系: handle を含む", password: "my name is Taro!", wantErr: true},
+		{name: "異常系: email を含む", password: "taro.yamada-secret", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			credential, err := model.NewCredential(user, tt.password)
+			if tt.wantErr {
+				assert.ErrorIs(t, err, apperrors.ErrInvalid)
+				assert.Nil(t, credential)
+			} else {
+				assert.NoError(t, err)
+			}
+		})
+	}
+}
+
+func TestCredentialLockout(t *testing.T) {
+	now := time.Now()
+	c := &model.Credential{}
+
+	c.RecordFailure(now, 3, time.Minute)
+	c.RecordFailure(now, 3, time.Minute)
+	assert.False(t, c.Locked(now))
+
+	c.RecordFailure(now, 3, time.Minute)
+	assert.True(t, c.Locked(now))
+	assert.False(t, c.Locked(now.Add(time.Minute)))
+
+	c.RecordSuccess()
+	assert.False(t, c.Locked(now))
+	assert.Zero(t, c.FailedAttempts)
+}
+
+func TestSessionRotate(t *testing.T) {
+	s, first, err := model.NewSession(1, time.Hour)
+	require.NoError(t, err)
+	assert.Equal(t, model.HashToken(first), s.RefreshTokenHash)
+
+	second, err := s.Rotate()
+	require.NoError(t, err)
+	assert.NotEqual(t, first, second)
+	assert.Equal(t, model.HashToken(second), s.RefreshTokenHash)
+	assert.Equal(t, model.HashToken(first), s.PreviousRefreshTokenHash)
+
+	assert.True(t, s.Active(time.Now()))
+	s.Revoke(time.Now())
+	assert.False(t, s.Active(time.Now()))
+}
@@ -0,0 +1,53 @@
+package model
+
+import (
+	"context"
+	"time"
+
+	"github.com/uptrace/bun"
+)
+
+// EmailVerificationToken proves that the user received mail at Email. Only
+// the hash of the token is stored.
+type EmailVerificationToken struct {
+	bun.BaseModel `bun:"table:email_verification_tokens"`
+
+	ID        int       `bun:",pk,autoincrement" log:"allow"`
+	UserID    int       `bun:"user_id" log:"allow"`
+	Email     string    `bun:"email" log:"mask"`
+	TokenHash []byte    `bun:"token_hash" log:"drop"`
+	ExpiresAt time.Time `bun:"expires_at" log:"allow"`
+	UsedAt    time.Time `bun:"used_at,nullzero" log:"allow"`
+
+	CreatedAt time.Time `bun:",nullzero" log:"allow"`
+}
+
+// NewEmailVerificationToken returns the token to mail to the user along with
+// the row to store.
+func NewEmailVerificationToken(userID int, email string, ttl time.Duration) (*EmailVerificationToken, string, error) {
+	token, err := newToken()
+	if err != nil {
+		return nil, "", err
+	}
+
+	now := time.Now().UTC()
+	return &EmailVerificationToken{
+		UserID:    userID,
+		Email:     email,
+		TokenHash: HashToken(token),
+		ExpiresAt: now.Add(ttl),
+		CreatedAt: now,
+	}, token, nil
+}
+
+// Usable reports whether the token can still verify an email at now.
+func (t *EmailVerificationToken) Usable(now time.Time) bool {
+	return t.UsedAt.IsZero() && now.Before(t.ExpiresAt)
+}
+
+var _ bun.AfterScanRowHook = (*EmailVerificationToken)(nil)
+
+func (t *EmailVerificationToken) AfterScanRow(ctx context.Context) error {
+	toUTC(&t.ExpiresAt, &t.UsedAt, &t.CreatedAt)
+	return nil
+}
@@ -0,0 +1,72 @@
+package model
+
+import (
+	"context"
+	"go02/packages/apperrors"
+	"time"
+
+	"github.com/uptrace/bun"
+)
+
+// Roles of a group member, from most to least privileged.
+const (
+	RoleOwner  = "owner"
+	RoleAdmin  = "admin"
+	RoleMember = "member"
+)
+
+// GroupMembership makes a user a member of a group.
+type GroupMembership struct {
+	bun.BaseModel `bun:"table:group_memberships"`
+
+	GroupID int `bun:"group_id,pk" log:"allow"`
+	UserID  int `bun:"user_id,pk" log:"allow"`
+	TenantModel
+
+	Role string `bun:"role" log:"allow"`
+
+	CreatedAt time.Time `bun:",nullzero" log:"allow"`
+	UpdatedAt time.Time `bun:",nullzero" log:"allow"`
+
+	Group *Group `bun:"rel:belongs-to,join:group_id=id" log:"allow"`
+}
+
+// NewGroupMembership creates a membership. An empty role is RoleMember.
+func NewGroupMembership(groupID int, userID int, role string) (*GroupMembership, error) {
+	if role == "" {
+		role = RoleMember
+	}
+	if err := ValidateRole(role); err != nil {
+		return nil, err
+	}
+
+	return &GroupMembership{
+		GroupID: groupID,
+		UserID:  userID,
+		Role:    role,
+	}, nil
+}
+
+// ValidateRole checks that role is one of the roles of a group member.
+func ValidateRole(role string) error {
+	switch role {
+	case RoleOwner, RoleAdmin, RoleMember:
+		return nil
+	}
+	return &apperrors.ValidationError{Field: "role", Reason: "must be owner, admin or member"}
+}
+
+var (
+	_ bun.BeforeAppendModelHook = (*GroupMembership)(nil)
+	_ bun.AfterScanRowHook      = (*GroupMembership)(nil)
+)
+
+func (m *GroupMembership) BeforeAppendModel(ctx context.Context, query bun.Query) error {
+	touchTimestamps(query, &m.CreatedAt, &m.UpdatedAt)
+	return m.assignTenant(ctx, query)
+}
+
+func (m *GroupMembership) AfterScanRow(ctx context.Context) error {
+	toUTC(&m.CreatedAt, &m.UpdatedAt)
+	return nil
+}
@@ -0,0 +1,64 @@
+package model
+
+import (
+	"context"
+	"go02/packages/apperrors"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"github.com/uptrace/bun"
+)
+
+// Group is a team of users of one organization.
+type Group struct {
+	bun.BaseModel `bun:"table:groups"`
+
+	ID int `bun:",pk,autoincrement" log:"allow"`
+	TenantModel
+
+	Name        string `bun:"name" log:"allow"`
+	Description string `bun:"description" log:"allow"`
+
+	CreatedAt time.Time `bun:",nullzero" log:"allow"`
+	UpdatedAt time.Time `bun:",nullzero" log:"allow"`
+}
+
+func NewGroup(name string, description string) (*Group, error) {
+	group := &Group{}
+	if err := group.Set(name, description); err != nil {
+		return nil, err
+	}
+
+	return group, nil
+}
+
+// Set changes the name and description.
+func (g *Group) Set(name string, description string) error {
+	name = strings.TrimSpace(name)
+	if name == "" || utf8.RuneCountInString(name) > 100 {
+		return &apperrors.ValidationError{Field: "name", Reason: "must be 1 to 100 characters"}
+	}
+	if utf8.RuneCountInString(description) > 1000 {
+		return &apperrors.ValidationError{Field: "description", Reason: "must be at most 1000 characters"}
+	}
+
+	g.Name = name
+	g.Description = description
+	return nil
+}
+
+var (
+	_ bun.BeforeAppendModelHook = (*Group)(nil)
+	_ bun.AfterScanRowHook      = (*Group)(nil)
+)
+
+func (g *Group) BeforeAppendModel(ctx context.Context, query bun.Query) error {
+	touchTimestamps(query, &g.CreatedAt, &g.UpdatedAt)
+	return g.assignTenant(ctx, query)
+}
+
+func (g *Group) AfterScanRow(ctx context.Context) error {
+	toUTC(&g.CreatedAt, &g.UpdatedAt)
+	return nil
+}
@@ -0,0 +1,19 @@
+package model
+
+// All returns the models that are backed by a table, for the schema drift
+// check. Add new table models here.
+func All() []any {
+	return []any{
+		(*Organization)(nil),
+		(*User)(nil),
+		(*Profile)(nil),
+		(*EmailVerificationToken)(nil),
+		(*Credential)(nil),
+		(*Session)(nil),
+		(*Group)(nil),
+		(*GroupMembership)(nil),
+		(*UserFollow)(nil),
+		(*UserFollowCount)(nil),
+		(*UserFollowCountChange)(nil),
+	}
+}
@@ -0,0 +1,77 @@
+package model
+
+import (
+	"context"
+	"go02/packages/apperrors"
+	"regexp"
+	"time"
+
+	"github.com/uptrace/bun"
+)
+
+// Organization is a tenant. Users and their data belong to exactly one.
+type Organization struct {
+	bun.BaseModel `bun:"table:organizations"`
+
+	ID   int    `bun:",pk,autoincrement" log:"allow"`
+	Slug string `bun:"slug" log:"allow"`
+	Name string `bun:"name" log:"allow"`
+
+	CreatedAt time.Time `bun:",nullzero" log:"allow"`
+	UpdatedAt time.Time `bun:",nullzero" log:"allow"`
+}
+
+func NewOrganization(slug string, name string) (*Organization, error) {
+	if err := ValidateSlug(slug); err != nil {
+		return nil, err
+	}
+	if name == "" || len(name) > 255 {
+		return nil, &apperrors.ValidationError{Field: "name", Reason: "must be 1 to 255 characters"}
+	}
+
+	return &Organization{Slug: slug, Name: name}, nil
+}
+
+// slugPattern is a DNS label, slugs are used as subdomains.
+var slugPattern = regexp.MustCompile(`^[a-z0-9]([a-z0-9-]*[a-z0-9])?$`)
+
+// reservedSlugs are subdomains that don't name an organization.
+var reservedSlugs = map[string]struct{}{
+	"admin": {}, "api": {}, "app": {}, "mail": {}, "static": {}, "www": {},
+}
+
+// ValidateSlug checks the format of an organization slug.
+func ValidateSlug(slug string) error {
+	invalid := func(reason string) error {
+		return &apperrors.ValidationError{Field: "slug", Reason: reason}
+	}
+
+	switch {
+	case slug == "":
+		return invalid("is required")
+	case len(slug) > 63:
+		return invalid("must be at most 63 characters")
+	case !slugPattern.MatchString(slug):
+		return invalid("must contain only lower case letters, digits and hyphens, and start and end with a letter or digit")
+	}
+	if _, ok := reservedSlugs[slug]; ok {
+		return invalid("is reserved")
+	}
+
+	return nil
+}
+
+var (
+	_ bun.BeforeAppendModelHook = (*Organization)(nil)
+	_ bun.AfterScanRowHook      = (*Organization)(nil)
+)
+
+func (o *Organization) BeforeAppendModel(ctx context.Context, query bun.Query) error {
+	touchTimestamps(query, &o.CreatedAt, &o.UpdatedAt)
+	return nil
+}
+
+func (o *Organization) AfterScanRow(ctx context.Context) error {
+	toUTC(&o.CreatedAt, &o.UpdatedAt)
+	return nil
+}
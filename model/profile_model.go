@@ -1,6 +1,7 @@
 package model
 
 import (
+	"context"
 	"time"
 
 	"github.com/uptrace/bun"
@@ -9,13 +10,15 @@ import (
 type Profile struct {
 	bun.BaseModel `bun:"table:profiles"`
 
-	ID        int    `bun:",pk,autoincrement"`
-	UserID    int    `bun:"user_id"`
-	Bio       string `bun:"bio"`
-	AvatarURL string `bun:"avatar_url"`
+	ID int `bun:",pk,autoincrement" log:"allow"`
+	TenantModel
 
-	CreatedAt time.Time `bun:",nullzero"`
-	UpdatedAt time.Time `bun:",nullzero"`
+	UserID    int    `bun:"user_id" log:"allow"`
+	Bio       string `bun:"bio" log:"drop"`
+	AvatarURL string `bun:"avatar_url" log:"hash"`
+
+	CreatedAt time.Time `bun:",nullzero" log:"allow"`
+	UpdatedAt time.Time `bun:",nullzero" log:"allow"`
 }
 
 func NewProfile(userID int, bio string, avatarURL string) (*Profile, error) {
@@ -28,3 +31,18 @@ func NewProfile(userID int, bio string, avatarURL string) (*Profile, error) {
 
 	return profile, nil
 }
+
+var (
+	_ bun.BeforeAppendModelHook = (*Profile)(nil)
+	_ bun.AfterScanRowHook      = (*Profile)(nil)
+)
+
+func (p *Profile) BeforeAppendModel(ctx context.Context, query bun.Query) error {
+	touchTimestamps(query, &p.CreatedAt, &p.UpdatedAt)
+	return p.assignTenant(ctx, query)
+}
+
+func (p *Profile) AfterScanRow(ctx context.Context) error {
+	toUTC(&p.CreatedAt, &p.UpdatedAt)
+	return nil
+}
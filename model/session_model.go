@@ -0,0 +1,79 @@
+package model
+
+import (
+	"context"
+	"time"
+
+	"github.com/uptrace/bun"
+)
+
+// Session is a login. It lasts until ExpiresAt unless revoked, and every
+// refresh swaps its refresh token for a new one.
+type Session struct {
+	bun.BaseModel `bun:"table:sessions"`
+
+	ID                       int       `bun:",pk,autoincrement" log:"allow"`
+	UserID                   int       `bun:"user_id" log:"allow"`
+	RefreshTokenHash         []byte    `bun:"refresh_token_hash" log:"drop"`
+	PreviousRefreshTokenHash []byte    `bun:"previous_refresh_token_hash,nullzero" log:"drop"`
+	ExpiresAt                time.Time `bun:"expires_at" log:"allow"`
+	RevokedAt                time.Time `bun:"revoked_at,nullzero" log:"allow"`
+
+	CreatedAt time.Time `bun:",nullzero" log:"allow"`
+	UpdatedAt time.Time `bun:",nullzero" log:"allow"`
+}
+
+// NewSession returns the refresh token to hand to the client along with the
+// session to store.
+func NewSession(userID int, ttl time.Duration) (*Session, string, error) {
+	token, err := newToken()
+	if err != nil {
+		return nil, "", err
+	}
+
+	return &Session{
+		UserID:           userID,
+		RefreshTokenHash: HashToken(token),
+		ExpiresAt:        time.Now().UTC().Add(ttl),
+	}, token, nil
+}
+
+// Rotate replaces the refresh token and returns the new one. The old one is
+// remembered so that a replay of it can be detected.
+func (s *Session) Rotate() (string, error) {
+	token, err := newToken()
+	if err != nil {
+		return "", err
+	}
+
+	s.PreviousRefreshTokenHash = s.RefreshTokenHash
+	s.RefreshTokenHash = HashToken(token)
+
+	return token, nil
+}
+
+func (s *Session) Revoke(now time.Time) {
+	if s.RevokedAt.IsZero() {
+		s.RevokedAt = now.UTC()
+	}
+}
+
+// Active reports whether the session can still be used at now.
+func (s *Session) Active(now time.Time) bool {
+	return s.RevokedAt.IsZero() && now.Before(s.ExpiresAt)
+}
+
+var (
+	_ bun.BeforeAppendModelHook = (*Session)(nil)
+	_ bun.AfterScanRowHook      = (*Session)(nil)
+)
+
+func (s *Session) BeforeAppendModel(ctx context.Context, query bun.Query) error {
+	touchTimestamps(query, &s.CreatedAt, &s.UpdatedAt)
+	return nil
+}
+
+func (s *Session) AfterScanRow(ctx context.Context) error {
+	toUTC(&s.ExpiresAt, &s.RevokedAt, &s.CreatedAt, &s.UpdatedAt)
+	return nil
+}
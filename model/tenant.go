@@ -0,0 +1,48 @@
+package model
+
+import (
+	"context"
+	"go02/packages/db"
+
+	"github.com/uptrace/bun"
+)
+
+// DefaultOrgID is the organization that owned every row before there were
+// organizations.
+const DefaultOrgID = 1
+
+// TenantModel is embedded by the models of tables with an org_id column. Its
+// hooks scope every select, update and delete to the tenant of the context,
+// see db.WithTenant. Models embedding it call assignTenant from
+// BeforeAppendModel.
+type TenantModel struct {
+	OrgID int `bun:"org_id,notnull" log:"allow"`
+}
+
+var (
+	_ bun.BeforeSelectHook = (*TenantModel)(nil)
+	_ bun.BeforeUpdateHook = (*TenantModel)(nil)
+	_ bun.BeforeDeleteHook = (*TenantModel)(nil)
+)
+
+func (*TenantModel) BeforeSelect(ctx context.Context, query *bun.SelectQuery) error {
+	return db.ScopeToTenant(ctx, query.QueryBuilder())
+}
+
+func (*TenantModel) BeforeUpdate(ctx context.Context, query *bun.UpdateQuery) error {
+	return db.ScopeToTenant(ctx, query.QueryBuilder())
+}
+
+func (*TenantModel) BeforeDelete(ctx context.Context, query *bun.DeleteQuery) error {
+	return db.ScopeToTenant(ctx, query.QueryBuilder())
+}
+
+// assignTenant puts new rows into the tenant of the context and refuses to
+// write rows of another one.
+func (m *TenantModel) assignTenant(ctx context.Context, query bun.Query) error {
+	switch query.(type) {
+	case *bun.InsertQuery, *bun.UpdateQuery:
+		return db.AssignTenant(ctx, &m.OrgID)
+	}
+	return nil
+}
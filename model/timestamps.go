@@ -0,0 +1,37 @@
+package model
+
+import (
+	"time"
+
+	"github.com/uptrace/bun"
+)
+
+// touchTimestamps sets the timestamps bun is about to write, always in UTC,
+// instead of leaving them to the column defaults.
+func touchTimestamps(query bun.Query, createdAt, updatedAt *time.Time) {
+	now := time.Now().UTC()
+
+	switch query.(type) {
+	case *bun.InsertQuery:
+		if createdAt.IsZero() {
+			*createdAt = now
+		}
+		if updatedAt.IsZero() {
+			*updatedAt = *createdAt
+		}
+	case *bun.UpdateQuery:
+		*updatedAt = now
+	}
+
+	*createdAt = createdAt.UTC()
+	*updatedAt = updatedAt.UTC()
+}
+
+// toUTC converts scanned timestamps to UTC, whatever the session time zone.
+func toUTC(ts ...*time.Time) {
+	for _, t := range ts {
+		if !t.IsZero() {
+			*t = t.UTC()
+		}
+	}
+}
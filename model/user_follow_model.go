@@ -0,0 +1,101 @@
+package model
+
+import (
+	"context"
+	"go02/packages/apperrors"
+	"time"
+
+	"github.com/uptrace/bun"
+)
+
+// UserFollow makes the follower follow the followee.
+type UserFollow struct {
+	bun.BaseModel `bun:"table:user_follows"`
+
+	FollowerID int `bun:"follower_id,pk" log:"allow"`
+	FolloweeID int `bun:"followee_id,pk" log:"allow"`
+	TenantModel
+
+	CreatedAt time.Time `bun:",nullzero" log:"allow"`
+
+	Follower *User `bun:"rel:belongs-to,join:follower_id=id" log:"allow"`
+	Followee *User `bun:"rel:belongs-to,join:followee_id=id" log:"allow"`
+}
+
+// NewUserFollow creates a follow. Users can't follow themselves.
+func NewUserFollow(followerID int, followeeID int) (*UserFollow, error) {
+	if followerID == followeeID {
+		return nil, &apperrors.ValidationError{Field: "user_id", Reason: "can't follow oneself"}
+	}
+
+	return &UserFollow{
+		FollowerID: followerID,
+		FolloweeID: followeeID,
+	}, nil
+}
+
+// UserFollowCount is the number of followers and followed users of a user as
+// of the last reconciliation, see repository.FollowRepository.ReconcileCounts.
+type UserFollowCount struct {
+	bun.BaseModel `bun:"table:user_follow_counts"`
+
+	UserID int `bun:"user_id,pk" log:"allow"`
+	TenantModel
+
+	FollowersCount int `bun:"followers_count" log:"allow"`
+	FollowingCount int `bun:"following_count" log:"allow"`
+
+	UpdatedAt time.Time `bun:",nullzero" log:"allow"`
+}
+
+// UserFollowCountChange marks a user whose follows changed since the last
+// reconciliation, see repository.FollowRepository.MarkChanged. A user may be
+// marked several times.
+type UserFollowCountChange struct {
+	bun.BaseModel `bun:"table:user_follow_count_changes"`
+
+	ID     int `bun:",pk,autoincrement" log:"allow"`
+	UserID int `bun:"user_id" log:"allow"`
+	TenantModel
+
+	ChangedAt time.Time `bun:",nullzero" log:"allow"`
+}
+
+var (
+	_ bun.BeforeAppendModelHook = (*UserFollow)(nil)
+	_ bun.AfterScanRowHook      = (*UserFollow)(nil)
+	_ bun.AfterScanRowHook      = (*UserFollowCount)(nil)
+	_ bun.BeforeAppendModelHook = (*UserFollowCountChange)(nil)
+	_ bun.AfterScanRowHook      = (*UserFollowCountChange)(nil)
+)
+
+func (f *UserFollow) BeforeAppendModel(ctx context.Context, query bun.Query) error {
+	if _, ok := query.(*bun.InsertQuery); ok && f.CreatedAt.IsZero() {
+		f.CreatedAt = time.Now()
+	}
+	f.CreatedAt = f.CreatedAt.UTC()
+	return f.assignTenant(ctx, query)
+}
+
+func (f *UserFollow) AfterScanRow(ctx context.Context) error {
+	toUTC(&f.CreatedAt)
+	return nil
+}
+
+func (c *UserFollowCount) AfterScanRow(ctx context.Context) error {
+	toUTC(&c.UpdatedAt)
+	return nil
+}
+
+func (c *UserFollowCountChange) BeforeAppendModel(ctx context.Context, query bun.Query) error {
+	if _, ok := query.(*bun.InsertQuery); ok && c.ChangedAt.IsZero() {
+		c.ChangedAt = time.Now()
+	}
+	c.ChangedAt = c.ChangedAt.UTC()
+	return c.assignTenant(ctx, query)
+}
+
+func (c *UserFollowCountChange) AfterScanRow(ctx context.Context) error {
+	toUTC(&c.ChangedAt)
+	return nil
+}
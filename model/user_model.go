@@ -1,6 +1,11 @@
 package model
 
 import (
+	"context"
+	"go02/packages/apperrors"
+	"net/mail"
+	"regexp"
+	"strings"
 	"time"
 
 	"github.com/uptrace/bun"
@@ -9,22 +14,125 @@ import (
 type User struct {
 	bun.BaseModel `bun:"table:users"`
 
-	ID   int    `bun:",pk,autoincrement"`
-	Name string `bun:"name"`
-	Age  int    `bun:"age"`
+	ID int `bun:",pk,autoincrement" log:"allow"`
+	TenantModel
 
-	CreatedAt time.Time `bun:",nullzero"`
-	UpdatedAt time.Time `bun:",nullzero"`
-	DeletedAt time.Time `bun:",soft_delete,nullzero"`
+	Name   string `bun:"name" log:"mask"`
+	Handle string `bun:"handle,nullzero" log:"allow"`
+	Age    int    `bun:"age" log:"drop"`
+
+	Email           string    `bun:"email,nullzero" log:"mask"`
+	EmailVerifiedAt time.Time `bun:"email_verified_at,nullzero" log:"allow"`
+
+	CreatedAt time.Time `bun:",nullzero" log:"allow"`
+	UpdatedAt time.Time `bun:",nullzero" log:"allow"`
+	DeletedAt time.Time `bun:",soft_delete,nullzero" log:"allow"`
+
+	// loaded by the queries that need it, nil otherwise
+	FollowCount *UserFollowCount `bun:"rel:has-one,join:id=user_id" log:"allow"`
 }
 type Users []User
 
-func NewUser(name string, age int) (*User, error) {
+// NewUser creates a user. email is optional.
+func NewUser(name string, handle string, email string, age int) (*User, error) {
+	if err := ValidateHandle(handle); err != nil {
+		return nil, err
+	}
 
 	user := &User{
-		Name: name,
-		Age:  age,
+		Name:   name,
+		Handle: handle,
+		Age:    age,
+	}
+
+	if email != "" {
+		if err := user.SetEmail(email); err != nil {
+			return nil, err
+		}
 	}
 
 	return user, nil
 }
+
+// SetEmail changes the email. A different address has to be verified again.
+func (u *User) SetEmail(email string) error {
+	normalized, err := NormalizeEmail(email)
+	if err != nil {
+		return err
+	}
+
+	if normalized != u.Email {
+		u.Email = normalized
+		u.EmailVerifiedAt = time.Time{}
+	}
+
+	return nil
+}
+
+// EmailVerified reports whether the current email has been verified.
+func (u *User) EmailVerified() bool {
+	return u.Email != "" && !u.EmailVerifiedAt.IsZero()
+}
+
+// NormalizeEmail checks that email is a bare address and lower cases it, so
+// that addresses differing only in case are the same address.
+func NormalizeEmail(email string) (string, error) {
+	invalid := &apperrors.ValidationError{Field: "email", Reason: "must be an email address"}
+
+	email = strings.TrimSpace(email)
+	if email == "" || len(email) > 254 {
+		return "", invalid
+	}
+	addr, err := mail.ParseAddress(email)
+	if err != nil || addr.Name != "" || addr.Address != email {
+		return "", invalid
+	}
+
+	return strings.ToLower(email), nil
+}
+
+var handlePattern = regexp.MustCompile(`^[A-Za-z][A-Za-z0-9_]*$`)
+
+// reservedHandles would be confused with routes, staff or missing values.
+var reservedHandles = map[string]struct{}{
+	"admin": {}, "administrator": {}, "api": {}, "debug": {}, "health": {},
+	"me": {}, "metrics": {}, "null": {}, "root": {}, "support": {},
+	"system": {}, "undefined": {}, "user": {}, "users": {},
+}
+
+// ValidateHandle checks the format of a handle. Uniqueness is checked by the
+// database, case-insensitively.
+func ValidateHandle(handle string) error {
+	invalid := func(reason string) error {
+		return &apperrors.ValidationError{Field: "handle", Reason: reason}
+	}
+
+	switch {
+	case handle == "":
+		return invalid("is required")
+	case len(handle) < 3 || len(handle) > 30:
+		return invalid("must be 3 to 30 characters")
+	case !handlePattern.MatchString(handle):
+		return invalid("must start with a letter and contain only letters, digits and underscores")
+	}
+	if _, ok := reservedHandles[strings.ToLower(handle)]; ok {
+		return invalid("is reserved")
+	}
+
+	return nil
+}
+
+var (
+	_ bun.BeforeAppendModelHook = (*User)(nil)
+	_ bun.AfterScanRowHook      = (*User)(nil)
+)
+
+func (u *User) BeforeAppendModel(ctx context.Context, query bun.Query) error {
+	touchTimestamps(query, &u.CreatedAt, &u.UpdatedAt)
+	return u.assignTenant(ctx, query)
+}
+
+func (u *User) AfterScanRow(ctx context.Context) error {
+	toUTC(&u.CreatedAt, &u.UpdatedAt, &u.DeletedAt, &u.EmailVerifiedAt)
+	return nil
+}
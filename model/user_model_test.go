@@ -0,0 +1,76 @@
+package model_test
+
+import (
+	"go02/model"
+	"go02/packages/apperrors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestValidateHandle(t *testing.T) {
+	tests := []struct {
+		name    string
+		handle  string
+		wantErr bool
+	}{
+		{name: "正常系: 英数字とアンダースコア", handle: "taro_24"},
+		{name: "正常系: 大文字を含む", handle: "Taro"},
+		{name: "異常系: 空", handle: "", wantErr: true},
+		{name: "異常系: 短すぎる", handle: "ab", wantErr: true},
+		{name: "異常系: 長すぎる", handle: "abcdefghijklmnopqrstuvwxyz12345", wantErr: true},
+		{name: "異常系: 数字で始まる", handle: "1taro", wantErr: true},
+		{name: "異常系: 記号を含む", handle: "taro-24", wantErr: true},
+		{name: "異常系: 予約語 (大文字小文字を区別しない)", handle: "Admin", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := model.ValidateHandle(tt.handle)
+			if tt.wantErr {
+				assert.ErrorIs(t, err, apperrors.ErrInvalid)
+			} else {
+				assert.NoError(t, err)
+			}
+		})
+	}
+}
+
+func TestNormalizeEmail(t *testing.T) {
+	tests := []struct {
+		name    string
+		email   string
+		want    string
+		wantErr bool
+	}{
+		{name: "正常系: 小文字に正規化", email: " Taro@Example.COM ", want: "taro@example.com"},
+		{name: "異常系: 空", email: "", wantErr: true},
+		{name: "異常系: @ がない", email: "taro.example.com", wantErr: true},
+		{name: "異常系: 表示名付き", email: "Taro <taro@example.com>", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := model.NormalizeEmail(tt.email)
+			if tt.wantErr {
+				assert.ErrorIs(t, err, apperrors.ErrInvalid)
+				return
+			}
+			assert.NoError(t, err)
+			assert.Equal(t, tt.want, got)
+		})
+	}
+}
+
+func TestSetEmailResetsVerification(t *testing.T) {
+	user, err := model.NewUser("taro", "taro", "taro@example.com", 24)
+	assert.NoError(t, err)
+	user.EmailVerifiedAt = time.Now()
+
+	assert.NoError(t, user.SetEmail("TARO@example.com"))
+	assert.True(t, user.EmailVerified())
+
+	assert.NoError(t, user.SetEmail("taro@example.org"))
+	assert.False(t, user.EmailVerified())
+}
@@ -11,8 +11,52 @@ import (
 
 var (
 	ErrNotFound = errors.New("item not found")
+	ErrConflict = errors.New("conflict")
+	ErrInvalid  = errors.New("invalid input")
+
+	// ErrUnauthorized is a failed login or an unusable token. It never tells
+	// which part was wrong.
+	ErrUnauthorized = errors.New("unauthorized")
+	// ErrForbidden is an authenticated caller lacking the permission.
+	ErrForbidden = errors.New("forbidden")
+	// ErrRateLimited is a request repeated sooner than allowed.
+	ErrRateLimited = errors.New("rate limited")
 )
 
+// ConflictError is a unique violation. Field names the input that already
+// exists.
+type ConflictError struct {
+	Field      string
+	Constraint string
+	Err        error
+}
+
+func (e *ConflictError) Error() string {
+	return fmt.Sprintf("%s already exists", e.Field)
+}
+
+func (e *ConflictError) Is(target error) bool {
+	return target == ErrConflict
+}
+
+func (e *ConflictError) Unwrap() error {
+	return e.Err
+}
+
+// ValidationError is an input that breaks a rule of the model.
+type ValidationError struct {
+	Field  string
+	Reason string
+}
+
+func (e *ValidationError) Error() string {
+	return fmt.Sprintf("invalid %s: %s", e.Field, e.Reason)
+}
+
+func (e *ValidationError) Is(target error) bool {
+	return target == ErrInvalid
+}
+
 func WithStack(err error) error {
 	if err == nil {
 		return cerrors.WithStack(err)
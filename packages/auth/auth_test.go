@@ -0,0 +1,49 @@
+package auth
+
+import (
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestPassword(t *testing.T) {
+	hash, err := HashPassword("correct horse battery staple")
+	require.NoError(t, err)
+	assert.True(t, strings.HasPrefix(hash, "$argon2id$v=19$"))
+
+	ok, err := VerifyPassword(hash, "correct horse battery staple")
+	require.NoError(t, err)
+	assert.True(t, ok)
+
+	ok, err = VerifyPassword(hash, "Correct horse battery staple")
+	require.NoError(t, err)
+	assert.False(t, ok)
+
+	_, err = VerifyPassword("$2a$10$bcrypt", "password")
+	assert.Error(t, err)
+}
+
+func TestSigner(t *testing.T) {
+	s := NewSigner([]byte("0123456789abcdef0123456789abcdef"), time.Minute)
+	p := Principal{UserID: 1, OrgID: 3, SessionID: 2}
+
+	token, err := s.Sign(p, time.Now())
+	require.NoError(t, err)
+
+	got, err := s.Verify(token)
+	require.NoError(t, err)
+	assert.Equal(t, p, got)
+
+	expired, err := s.Sign(p, time.Now().Add(-2*time.Minute))
+	require.NoError(t, err)
+	_, err = s.Verify(expired)
+	assert.True(t, errors.Is(err, ErrInvalidToken))
+
+	other := NewSigner([]byte("another key of thirty two bytes!"), time.Minute)
+	_, err = other.Verify(token)
+	assert.True(t, errors.Is(err, ErrInvalidToken))
+}
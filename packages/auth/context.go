@@ -0,0 +1,40 @@
+package auth
+
+import (
+	"context"
+	"fmt"
+)
+
+// Principal is the user a request is authenticated as, and the organization
+// the user belongs to. An admin is authenticated with the ADMIN_TOKEN instead
+// and is no user; it may act on every user of the organization of the
+// request.
+type Principal struct {
+	UserID    int
+	OrgID     int
+	SessionID int
+	Admin     bool
+}
+
+func (p Principal) String() string {
+	if p.Admin {
+		return "admin"
+	}
+	return fmt.Sprintf("user:%d", p.UserID)
+}
+
+// CanActOn reports whether the principal may change the user with userID.
+func (p Principal) CanActOn(userID int) bool {
+	return p.Admin || p.UserID == userID
+}
+
+type principalKey struct{}
+
+func NewContext(ctx context.Context, p Principal) context.Context {
+	return context.WithValue(ctx, principalKey{}, p)
+}
+
+func FromContext(ctx context.Context) (Principal, bool) {
+	p, ok := ctx.Value(principalKey{}).(Principal)
+	return p, ok
+}
@@ -0,0 +1,73 @@
+package auth
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+)
+
+const issuer = "go02"
+
+var ErrInvalidToken = errors.New("invalid access token")
+
+type claims struct {
+	jwt.RegisteredClaims
+	SessionID int `json:"sid"`
+	OrgID     int `json:"org,omitempty"`
+}
+
+// Signer issues and verifies HS256 access tokens.
+type Signer struct {
+	key []byte
+	ttl time.Duration
+}
+
+func NewSigner(key []byte, ttl time.Duration) *Signer {
+	return &Signer{key: key, ttl: ttl}
+}
+
+func (s *Signer) TTL() time.Duration {
+	return s.ttl
+}
+
+// Sign returns an access token for the principal, valid for the signer's TTL
+// from now.
+func (s *Signer) Sign(p Principal, now time.Time) (string, error) {
+	token := jwt.NewWithClaims(jwt.SigningMethodHS256, claims{
+		RegisteredClaims: jwt.RegisteredClaims{
+			Issuer:    issuer,
+			Subject:   strconv.Itoa(p.UserID),
+			IssuedAt:  jwt.NewNumericDate(now),
+			ExpiresAt: jwt.NewNumericDate(now.Add(s.ttl)),
+		},
+		SessionID: p.SessionID,
+		OrgID:     p.OrgID,
+	})
+	return token.SignedString(s.key)
+}
+
+// Verify checks the signature and expiry of an access token. It doesn't know
+// whether the session has been revoked since.
+func (s *Signer) Verify(token string) (Principal, error) {
+	var c claims
+	_, err := jwt.ParseWithClaims(token, &c, func(*jwt.Token) (any, error) {
+		return s.key, nil
+	},
+		jwt.WithValidMethods([]string{jwt.SigningMethodHS256.Alg()}),
+		jwt.WithIssuer(issuer),
+		jwt.WithExpirationRequired(),
+	)
+	if err != nil {
+		return Principal{}, fmt.Errorf("%w: %w", ErrInvalidToken, err)
+	}
+
+	userID, err := strconv.Atoi(c.Subject)
+	if err != nil {
+		return Principal{}, fmt.Errorf("%w: subject %q", ErrInvalidToken, c.Subject)
+	}
+
+	return Principal{UserID: userID, OrgID: c.OrgID, SessionID: c.SessionID}, nil
+}
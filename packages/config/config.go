@@ -0,0 +1,176 @@
+package config
+
+import (
+	"sync/atomic"
+	"time"
+)
+
+// Config is the typed configuration tree. Values are layered from the
+// envDefault tags, the YAML file, environment variables and finally files in
+// the secrets directory, see Load. Fields tagged reload:"true" can be changed
+// without a restart, see Watcher.
+type Config struct {
+	Env        string `env:"ENV" envDefault:"development" yaml:"env"`
+	AppVersion string `env:"APP_VERSION" envDefault:"dev" yaml:"app_version"`
+
+	// how often the config file and secrets are checked for changes, 0
+	// disables polling and leaves SIGHUP as the only reload trigger
+	ReloadInterval time.Duration `env:"CONFIG_RELOAD_INTERVAL" envDefault:"30s" yaml:"reload_interval"`
+
+	Server      ServerConfig      `yaml:"server"`
+	DB          DBConfig          `yaml:"db"`
+	Health      HealthConfig      `yaml:"health"`
+	Logging     LoggingConfig     `yaml:"logging"`
+	Tracing     TracingConfig     `yaml:"tracing"`
+	Metrics     MetricsConfig     `yaml:"metrics"`
+	ErrorReport ErrorReportConfig `yaml:"error_report"`
+	Auth        AuthConfig        `yaml:"auth"`
+	Mail        MailConfig        `yaml:"mail"`
+	Tenant      TenantConfig      `yaml:"tenant"`
+	Follow      FollowConfig      `yaml:"follow"`
+}
+
+type ServerConfig struct {
+	Port            string        `env:"PORT" envDefault:"8080" yaml:"port"`
+	ShutdownTimeout time.Duration `env:"SHUTDOWN_TIMEOUT" envDefault:"10s" yaml:"shutdown_timeout"`
+}
+
+type DBConfig struct {
+	Host     string `env:"DB_HOST,notEmpty" yaml:"host"`
+	Port     string `env:"DB_PORT,notEmpty" yaml:"port"`
+	Name     string `env:"DB_NAME,notEmpty" yaml:"name"`
+	User     string `env:"DB_USER,notEmpty" yaml:"user"`
+	Password string `env:"DB_PASSWORD,notEmpty" yaml:"password" secret:"true"`
+
+	// connection pool
+	MaxOpenConns    int           `env:"DB_MAX_OPEN_CONNS" envDefault:"20" yaml:"max_open_conns" reload:"true"`
+	MaxIdleConns    int           `env:"DB_MAX_IDLE_CONNS" envDefault:"10" yaml:"max_idle_conns" reload:"true"`
+	ConnMaxLifetime time.Duration `env:"DB_CONN_MAX_LIFETIME" envDefault:"30m" yaml:"conn_max_lifetime" reload:"true"`
+	ConnMaxIdleTime time.Duration `env:"DB_CONN_MAX_IDLE_TIME" envDefault:"5m" yaml:"conn_max_idle_time" reload:"true"`
+
+	// startup retry and health probing
+	PingTimeout         time.Duration `env:"DB_PING_TIMEOUT" envDefault:"5s" yaml:"ping_timeout"`
+	ConnectRetries      int           `env:"DB_CONNECT_RETRIES" envDefault:"10" yaml:"connect_retries"`
+	ConnectBackoff      time.Duration `env:"DB_CONNECT_BACKOFF" envDefault:"500ms" yaml:"connect_backoff"`
+	ConnectMaxBackoff   time.Duration `env:"DB_CONNECT_MAX_BACKOFF" envDefault:"10s" yaml:"connect_max_backoff"`
+	HealthCheckInterval time.Duration `env:"DB_HEALTH_CHECK_INTERVAL" envDefault:"15s" yaml:"health_check_interval"`
+
+	// apply pending migrations before serving, see db.MigrateOnStart
+	MigrateOnStart bool `env:"DB_MIGRATE_ON_START" envDefault:"false" yaml:"migrate_on_start"`
+
+	// query instrumentation
+	TraceStatements    bool          `env:"DB_TRACE_STATEMENTS" envDefault:"true" yaml:"trace_statements"`
+	SlowQueryThreshold time.Duration `env:"DB_SLOW_QUERY_THRESHOLD" envDefault:"200ms" yaml:"slow_query_threshold"`
+	SlowQueryExplain   bool          `env:"DB_SLOW_QUERY_EXPLAIN" envDefault:"false" yaml:"slow_query_explain"`
+
+	// run each request on a connection with app.org_id set, for the row level
+	// security policies. They only apply when DB_USER doesn't own the tables.
+	RowLevelSecurity bool `env:"DB_ROW_LEVEL_SECURITY" envDefault:"false" yaml:"row_level_security"`
+}
+
+type HealthConfig struct {
+	CheckTimeout time.Duration `env:"HEALTH_CHECK_TIMEOUT" envDefault:"2s" yaml:"check_timeout"`
+}
+
+type LoggingConfig struct {
+	Level        string `env:"LOG_LEVEL" envDefault:"info" yaml:"level" reload:"true"`
+	LevelHeader  string `env:"LOG_LEVEL_HEADER" yaml:"level_header" reload:"true"`
+	DebugSampled bool   `env:"LOG_DEBUG_SAMPLED" envDefault:"false" yaml:"debug_sampled" reload:"true"`
+	Redaction    string `env:"LOG_REDACTION" envDefault:"denylist" yaml:"redaction"`
+	RedactKey    string `env:"LOG_REDACT_KEY" yaml:"redact_key" secret:"true"`
+	Format       string `env:"LOG_FORMAT" envDefault:"json" yaml:"format"`
+	Profile      string `env:"LOG_PROFILE" yaml:"profile"`
+	File         string `env:"LOG_FILE" yaml:"file"`
+	FileMaxSize  int    `env:"LOG_FILE_MAX_SIZE_MB" envDefault:"100" yaml:"file_max_size_mb"`
+	FileBackups  int    `env:"LOG_FILE_MAX_BACKUPS" envDefault:"3" yaml:"file_max_backups"`
+}
+
+type TracingConfig struct {
+	ProjectID    string  `env:"PROJECT_ID" yaml:"project_id"`
+	Exporter     string  `env:"TRACE_EXPORTER" envDefault:"none" yaml:"exporter"`
+	OTLPEndpoint string  `env:"TRACE_OTLP_ENDPOINT" yaml:"otlp_endpoint"`
+	OTLPInsecure bool    `env:"TRACE_OTLP_INSECURE" envDefault:"false" yaml:"otlp_insecure"`
+	Sampler      string  `env:"TRACE_SAMPLER" envDefault:"parentbased_ratio" yaml:"sampler"`
+	SamplerRatio float64 `env:"TRACE_SAMPLER_RATIO" envDefault:"1" yaml:"sampler_ratio"`
+}
+
+type MetricsConfig struct {
+	OTLPEndpoint   string        `env:"METRICS_OTLP_ENDPOINT" yaml:"otlp_endpoint"`
+	OTLPInsecure   bool          `env:"METRICS_OTLP_INSECURE" envDefault:"false" yaml:"otlp_insecure"`
+	ExportInterval time.Duration `env:"METRICS_EXPORT_INTERVAL" envDefault:"60s" yaml:"export_interval"`
+}
+
+type ErrorReportConfig struct {
+	Reporter   string        `env:"ERROR_REPORTER" envDefault:"none" yaml:"reporter"`
+	SentryDSN  string        `env:"SENTRY_DSN" yaml:"sentry_dsn" secret:"true"`
+	File       string        `env:"ERROR_REPORT_FILE" envDefault:"errors.jsonl" yaml:"file"`
+	SampleRate float64       `env:"ERROR_REPORT_SAMPLE_RATE" envDefault:"1" yaml:"sample_rate" reload:"true"`
+	Burst      int           `env:"ERROR_REPORT_BURST" envDefault:"5" yaml:"burst" reload:"true"`
+	Window     time.Duration `env:"ERROR_REPORT_WINDOW" envDefault:"1m" yaml:"window" reload:"true"`
+}
+
+type AuthConfig struct {
+	// admin endpoints are disabled unless a token is set
+	AdminToken string `env:"ADMIN_TOKEN" yaml:"admin_token" secret:"true"`
+
+	// signs access tokens. Outside production a random key is used when it is
+	// empty, so tokens don't survive a restart and aren't shared by replicas.
+	TokenSecret     string        `env:"AUTH_TOKEN_SECRET" yaml:"token_secret" secret:"true"`
+	AccessTokenTTL  time.Duration `env:"AUTH_ACCESS_TOKEN_TTL" envDefault:"15m" yaml:"access_token_ttl"`
+	SessionTTL      time.Duration `env:"AUTH_SESSION_TTL" envDefault:"720h" yaml:"session_ttl"`
+	MaxFailedLogins int           `env:"AUTH_MAX_FAILED_LOGINS" envDefault:"5" yaml:"max_failed_logins"`
+	LockoutDuration time.Duration `env:"AUTH_LOCKOUT_DURATION" envDefault:"15m" yaml:"lockout_duration"`
+}
+
+type MailConfig struct {
+	Mailer       string `env:"MAILER" envDefault:"file" yaml:"mailer"`
+	From         string `env:"MAIL_FROM" envDefault:"no-reply@go02.local" yaml:"from"`
+	File         string `env:"MAIL_FILE" envDefault:"mail.jsonl" yaml:"file"`
+	SMTPHost     string `env:"SMTP_HOST" yaml:"smtp_host"`
+	SMTPPort     string `env:"SMTP_PORT" envDefault:"587" yaml:"smtp_port"`
+	SMTPUser     string `env:"SMTP_USER" yaml:"smtp_user"`
+	SMTPPassword string `env:"SMTP_PASSWORD" yaml:"smtp_password" secret:"true"`
+	// send without TLS to a relay that doesn't offer STARTTLS, e.g. a local
+	// mail catcher
+	SMTPAllowPlaintext bool `env:"SMTP_ALLOW_PLAINTEXT" envDefault:"false" yaml:"smtp_allow_plaintext"`
+
+	// the page that posts the token from the link to POST /email/verify
+	VerifyURL      string        `env:"EMAIL_VERIFY_URL" envDefault:"http://localhost:8080/email/verify" yaml:"verify_url"`
+	VerifyTokenTTL time.Duration `env:"EMAIL_VERIFY_TOKEN_TTL" envDefault:"24h" yaml:"verify_token_ttl"`
+	// how long a user waits before asking for another verification email
+	VerifyRequestInterval time.Duration `env:"EMAIL_VERIFY_REQUEST_INTERVAL" envDefault:"1m" yaml:"verify_request_interval"`
+}
+
+type TenantConfig struct {
+	// <slug>.<base domain> names the organization, e.g. acme.go02.example.com.
+	// Subdomains aren't looked at when it is empty.
+	BaseDomain string `env:"TENANT_BASE_DOMAIN" yaml:"base_domain"`
+	// the organization of requests that don't name one; empty rejects them
+	Default string `env:"TENANT_DEFAULT" envDefault:"default" yaml:"default"`
+}
+
+type FollowConfig struct {
+	// how often the follower and following counts are recomputed
+	CountInterval time.Duration `env:"FOLLOW_COUNT_INTERVAL" envDefault:"1m" yaml:"count_interval"`
+}
+
+// Store holds the configuration in effect. A Watcher swaps in a new value on
+// reload; the values themselves are never modified, so callers that need
+// consistent values should call Get once and keep the result.
+type Store struct {
+	current atomic.Pointer[Config]
+}
+
+func NewStore(cfg *Config) *Store {
+	s := &Store{}
+	s.current.Store(cfg)
+	return s
+}
+
+func (s *Store) Get() *Config {
+	return s.current.Load()
+}
+
+func (s *Store) set(cfg *Config) {
+	s.current.Store(cfg)
+}
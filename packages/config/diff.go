@@ -0,0 +1,80 @@
+package config
+
+import (
+	"reflect"
+	"strings"
+)
+
+// Change is a single field that differs between two configurations. Secret
+// values are redacted.
+type Change struct {
+	Key        string
+	Old        any
+	New        any
+	Reloadable bool
+}
+
+// Diff lists the fields that differ between old and new, keyed by their
+// dotted YAML path such as "db.max_open_conns".
+func Diff(old, new *Config) []Change {
+	var changes []Change
+	diffStruct(reflect.ValueOf(*old), reflect.ValueOf(*new), "", &changes)
+	return changes
+}
+
+func diffStruct(a, b reflect.Value, path string, changes *[]Change) {
+	t := a.Type()
+
+	for i := 0; i < t.NumField(); i++ {
+		f := t.Field(i)
+		key := strings.Split(f.Tag.Get("yaml"), ",")[0]
+		if key == "" || key == "-" {
+			continue
+		}
+
+		av, bv := a.Field(i), b.Field(i)
+		if f.Type.Kind() == reflect.Struct && f.Type != durationType {
+			diffStruct(av, bv, path+key+".", changes)
+			continue
+		}
+		if reflect.DeepEqual(av.Interface(), bv.Interface()) {
+			continue
+		}
+
+		*changes = append(*changes, Change{
+			Key:        path + key,
+			Old:        display(f, av),
+			New:        display(f, bv),
+			Reloadable: f.Tag.Get("reload") == "true",
+		})
+	}
+}
+
+func display(f reflect.StructField, v reflect.Value) any {
+	switch {
+	case f.Tag.Get("secret") == "true":
+		if v.IsZero() {
+			return ""
+		}
+		return redacted
+	case f.Type == durationType:
+		return v.Interface().(interface{ String() string }).String()
+	default:
+		return v.Interface()
+	}
+}
+
+// applyReloadable copies the fields tagged reload:"true" from src to dst.
+func applyReloadable(dst, src reflect.Value) {
+	t := dst.Type()
+
+	for i := 0; i < t.NumField(); i++ {
+		f := t.Field(i)
+		switch {
+		case f.Type.Kind() == reflect.Struct && f.Type != durationType:
+			applyReloadable(dst.Field(i), src.Field(i))
+		case f.Tag.Get("reload") == "true":
+			dst.Field(i).Set(src.Field(i))
+		}
+	}
+}
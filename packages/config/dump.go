@@ -0,0 +1,45 @@
+package config
+
+import (
+	"reflect"
+	"strings"
+)
+
+const redacted = "******"
+
+// Redacted returns the configuration as a map keyed by the YAML names, with
+// the values of fields tagged secret:"true" replaced.
+func (c *Config) Redacted() map[string]any {
+	return redactStruct(reflect.ValueOf(*c))
+}
+
+func redactStruct(v reflect.Value) map[string]any {
+	t := v.Type()
+	out := make(map[string]any, t.NumField())
+
+	for i := 0; i < t.NumField(); i++ {
+		f := t.Field(i)
+		key := strings.Split(f.Tag.Get("yaml"), ",")[0]
+		if key == "" || key == "-" {
+			continue
+		}
+
+		fv := v.Field(i)
+		switch {
+		case f.Tag.Get("secret") == "true":
+			if fv.IsZero() {
+				out[key] = ""
+			} else {
+				out[key] = redacted
+			}
+		case f.Type.Kind() == reflect.Struct && f.Type != durationType:
+			out[key] = redactStruct(fv)
+		case f.Type == durationType:
+			out[key] = fv.Interface().(interface{ String() string }).String()
+		default:
+			out[key] = fv.Interface()
+		}
+	}
+
+	return out
+}
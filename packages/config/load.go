@@ -0,0 +1,227 @@
+package config
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"time"
+
+	"github.com/caarlos0/env/v11"
+	"gopkg.in/yaml.v3"
+)
+
+const defaultSecretsDir = "/var/run/secrets/go02"
+
+// Source describes where the layers of the configuration come from.
+type Source struct {
+	// File is an optional YAML file.
+	File string
+	// Environ holds the environment variables.
+	Environ map[string]string
+	// SecretsDir holds one file per environment variable name, as mounted
+	// from a Kubernetes secret. It is skipped when it doesn't exist.
+	SecretsDir string
+}
+
+// SourceFromEnv reads the YAML file from CONFIG_FILE and the secrets from
+// SECRETS_DIR, or /var/run/secrets/go02 by default.
+func SourceFromEnv() Source {
+	secretsDir := os.Getenv("SECRETS_DIR")
+	if secretsDir == "" {
+		secretsDir = defaultSecretsDir
+	}
+
+	return Source{
+		File:       os.Getenv("CONFIG_FILE"),
+		Environ:    env.ToMap(os.Environ()),
+		SecretsDir: secretsDir,
+	}
+}
+
+// Load builds the configuration from defaults, the YAML file, environment
+// variables and secret files, each layer overriding the previous one. All
+// parse and validation errors are reported together.
+func Load(src Source) (*Config, error) {
+	values := map[string]string{}
+
+	if src.File != "" {
+		fileValues, err := readYAML(src.File)
+		if err != nil {
+			return nil, err
+		}
+		merge(values, fileValues)
+	}
+
+	merge(values, src.Environ)
+
+	if src.SecretsDir != "" {
+		secretValues, err := readSecrets(src.SecretsDir)
+		if err != nil {
+			return nil, err
+		}
+		merge(values, secretValues)
+	}
+
+	cfg := &Config{}
+	var errs []error
+
+	if err := env.ParseWithOptions(cfg, env.Options{Environment: values}); err != nil {
+		var agg env.AggregateError
+		if errors.As(err, &agg) {
+			for _, e := range agg.Errors {
+				errs = append(errs, withEnvKey(e))
+			}
+		} else {
+			errs = append(errs, err)
+		}
+	}
+	errs = append(errs, cfg.validate()...)
+
+	if len(errs) > 0 {
+		return nil, fmt.Errorf("invalid configuration:\n%w", errors.Join(errs...))
+	}
+
+	return cfg, nil
+}
+
+// withEnvKey names the environment variable in parse errors, which otherwise
+// only mention the Go field name.
+func withEnvKey(err error) error {
+	var perr env.ParseError
+	if !errors.As(err, &perr) {
+		return err
+	}
+
+	keys := envKeys(reflect.TypeOf(Config{}), perr.Name)
+	if len(keys) != 1 {
+		return err
+	}
+	return fmt.Errorf("%s: %w", keys[0], err)
+}
+
+func envKeys(t reflect.Type, field string) []string {
+	var keys []string
+	for i := 0; i < t.NumField(); i++ {
+		f := t.Field(i)
+		if f.Type.Kind() == reflect.Struct && f.Type != durationType {
+			keys = append(keys, envKeys(f.Type, field)...)
+			continue
+		}
+		if f.Name == field {
+			keys = append(keys, strings.Split(f.Tag.Get("env"), ",")[0])
+		}
+	}
+	return keys
+}
+
+func merge(dst map[string]string, src map[string]string) {
+	for k, v := range src {
+		dst[k] = v
+	}
+}
+
+// readYAML maps the YAML document onto the environment variable names of the
+// fields it sets, so that it can be layered below the real environment.
+func readYAML(path string) (map[string]string, error) {
+	b, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("read config file: %w", err)
+	}
+
+	var doc map[string]any
+	if err := yaml.Unmarshal(b, &doc); err != nil {
+		return nil, fmt.Errorf("parse config file %s: %w", path, err)
+	}
+
+	values := map[string]string{}
+	if err := flatten(reflect.TypeOf(Config{}), doc, "", values); err != nil {
+		return nil, fmt.Errorf("parse config file %s: %w", path, err)
+	}
+
+	return values, nil
+}
+
+var durationType = reflect.TypeOf(time.Duration(0))
+
+func flatten(t reflect.Type, doc map[string]any, path string, out map[string]string) error {
+	known := map[string]struct{}{}
+
+	for i := 0; i < t.NumField(); i++ {
+		f := t.Field(i)
+		key := strings.Split(f.Tag.Get("yaml"), ",")[0]
+		if key == "" || key == "-" {
+			continue
+		}
+		known[key] = struct{}{}
+
+		node, ok := doc[key]
+		if !ok || node == nil {
+			continue
+		}
+
+		if f.Type.Kind() == reflect.Struct && f.Type != durationType {
+			child, ok := node.(map[string]any)
+			if !ok {
+				return fmt.Errorf("%s%s must be a mapping", path, key)
+			}
+			if err := flatten(f.Type, child, path+key+".", out); err != nil {
+				return err
+			}
+			continue
+		}
+
+		name := strings.Split(f.Tag.Get("env"), ",")[0]
+		if name == "" {
+			continue
+		}
+		out[name] = scalar(node)
+	}
+
+	for key := range doc {
+		if _, ok := known[key]; !ok {
+			return fmt.Errorf("unknown key %s%s", path, key)
+		}
+	}
+
+	return nil
+}
+
+func scalar(node any) string {
+	if list, ok := node.([]any); ok {
+		items := make([]string, len(list))
+		for i, item := range list {
+			items[i] = fmt.Sprint(item)
+		}
+		return strings.Join(items, ",")
+	}
+	return fmt.Sprint(node)
+}
+
+func readSecrets(dir string) (map[string]string, error) {
+	entries, err := os.ReadDir(dir)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("read secrets dir: %w", err)
+	}
+
+	values := map[string]string{}
+	for _, entry := range entries {
+		// Kubernetes mounts the keys as symlinks into a ..data directory
+		if entry.IsDir() || strings.HasPrefix(entry.Name(), ".") {
+			continue
+		}
+
+		b, err := os.ReadFile(filepath.Join(dir, entry.Name()))
+		if err != nil {
+			return nil, fmt.Errorf("read secret %s: %w", entry.Name(), err)
+		}
+		values[entry.Name()] = strings.TrimRight(string(b), "\r\n")
+	}
+
+	return values, nil
+}
@@ -0,0 +1,119 @@
+package config_test
+
+import (
+	"go02/packages/config"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func baseEnv() map[string]string {
+	return map[string]string{
+		"DB_HOST":     "localhost",
+		"DB_PORT":     "5432",
+		"DB_NAME":     "go02",
+		"DB_USER":     "go02",
+		"DB_PASSWORD": "password",
+	}
+}
+
+func TestLoadLayers(t *testing.T) {
+	dir := t.TempDir()
+
+	file := filepath.Join(dir, "config.yaml")
+	require.NoError(t, os.WriteFile(file, []byte(`
+server:
+  port: "9090"
+db:
+  host: from-file
+  max_open_conns: 50
+  slow_query_threshold: 1s
+logging:
+  level: debug
+`), 0o600))
+
+	secrets := filepath.Join(dir, "secrets")
+	require.NoError(t, os.Mkdir(secrets, 0o700))
+	require.NoError(t, os.WriteFile(filepath.Join(secrets, "DB_PASSWORD"), []byte("from-secret\n"), 0o600))
+
+	environ := baseEnv()
+	delete(environ, "DB_HOST")
+	environ["LOG_LEVEL"] = "warn"
+
+	cfg, err := config.Load(config.Source{File: file, Environ: environ, SecretsDir: secrets})
+	require.NoError(t, err)
+
+	assert.Equal(t, "9090", cfg.Server.Port)
+	assert.Equal(t, "from-file", cfg.DB.Host)
+	assert.Equal(t, 50, cfg.DB.MaxOpenConns)
+	assert.Equal(t, time.Second, cfg.DB.SlowQueryThreshold)
+	assert.Equal(t, "warn", cfg.Logging.Level)
+	assert.Equal(t, "from-secret", cfg.DB.Password)
+	assert.Equal(t, 10, cfg.DB.MaxIdleConns)
+	assert.Empty(t, cfg.Logging.LevelHeader)
+
+	dump := cfg.Redacted()
+	assert.Equal(t, "******", dump["db"].(map[string]any)["password"])
+	assert.Equal(t, "1s", dump["db"].(map[string]any)["slow_query_threshold"])
+}
+
+func TestLoadErrors(t *testing.T) {
+	environ := baseEnv()
+	delete(environ, "DB_HOST")
+	environ["PORT"] = "http"
+	environ["DB_MAX_OPEN_CONNS"] = "many"
+	environ["LOG_FORMAT"] = "xml"
+	// consumers switch on the lowercase values
+	environ["MAILER"] = "SMTP"
+	environ["TRACE_EXPORTER"] = "None"
+
+	_, err := config.Load(config.Source{Environ: environ})
+	require.Error(t, err)
+
+	for _, want := range []string{"DB_HOST", "PORT", "DB_MAX_OPEN_CONNS", "LOG_FORMAT", "MAILER", "TRACE_EXPORTER"} {
+		assert.Contains(t, err.Error(), want)
+	}
+}
+
+func TestLoadProductionMailer(t *testing.T) {
+	environ := baseEnv()
+	environ["ENV"] = "production"
+	environ["AUTH_TOKEN_SECRET"] = "0123456789abcdef0123456789abcdef"
+
+	// the file mailer is the default
+	_, err := config.Load(config.Source{Environ: environ})
+	assert.ErrorContains(t, err, "MAILER must be smtp in production")
+
+	environ["MAILER"] = "smtp"
+	environ["SMTP_HOST"] = "smtp.example.com"
+	environ["TRACE_EXPORTER"] = "gcp"
+	_, err = config.Load(config.Source{Environ: environ})
+	assert.NoError(t, err)
+}
+
+func TestLoadTraceExporter(t *testing.T) {
+	// local runs and CI need no GCP credentials
+	cfg, err := config.Load(config.Source{Environ: baseEnv()})
+	require.NoError(t, err)
+	assert.Equal(t, "none", cfg.Tracing.Exporter)
+
+	environ := baseEnv()
+	environ["ENV"] = "production"
+	environ["AUTH_TOKEN_SECRET"] = "0123456789abcdef0123456789abcdef"
+	environ["MAILER"] = "smtp"
+	environ["SMTP_HOST"] = "smtp.example.com"
+	_, err = config.Load(config.Source{Environ: environ})
+	assert.ErrorContains(t, err, "TRACE_EXPORTER must be gcp in production")
+}
+
+func TestLoadUnknownFileKey(t *testing.T) {
+	file := filepath.Join(t.TempDir(), "config.yaml")
+	require.NoError(t, os.WriteFile(file, []byte("db:\n  hots: localhost\n"), 0o600))
+
+	_, err := config.Load(config.Source{File: file, Environ: baseEnv()})
+	assert.ErrorContains(t, err, "unknown key db.hots")
+}
@@ -0,0 +1,85 @@
+package config
+
+import (
+	"fmt"
+	"log/slog"
+	"net/mail"
+	"net/url"
+	"slices"
+	"strconv"
+	"strings"
+)
+
+// validate checks the values the env parser can't, returning every problem
+// rather than the first one.
+func (c *Config) validate() []error {
+	var errs []error
+	check := func(ok bool, format string, args ...any) {
+		if !ok {
+			errs = append(errs, fmt.Errorf(format, args...))
+		}
+	}
+
+	check(c.ReloadInterval >= 0, "CONFIG_RELOAD_INTERVAL must not be negative")
+
+	port, err := strconv.Atoi(c.Server.Port)
+	// 0 picks a free port, which tests use to run several servers
+	check(err == nil && port >= 0 && port < 65536, "PORT must be a valid port number, got %q", c.Server.Port)
+
+	check(c.DB.MaxOpenConns >= 0, "DB_MAX_OPEN_CONNS must not be negative")
+	check(c.DB.MaxIdleConns >= 0, "DB_MAX_IDLE_CONNS must not be negative")
+	check(c.DB.MaxOpenConns == 0 || c.DB.MaxIdleConns <= c.DB.MaxOpenConns, "DB_MAX_IDLE_CONNS (%d) must not exceed DB_MAX_OPEN_CONNS (%d)", c.DB.MaxIdleConns, c.DB.MaxOpenConns)
+	check(c.DB.ConnectRetries >= 0, "DB_CONNECT_RETRIES must not be negative")
+	check(c.DB.PingTimeout > 0, "DB_PING_TIMEOUT must be positive")
+	check(c.DB.HealthCheckInterval > 0, "DB_HEALTH_CHECK_INTERVAL must be positive")
+
+	var level slog.Level
+	check(level.UnmarshalText([]byte(c.Logging.Level)) == nil, "LOG_LEVEL %q is not a valid level", c.Logging.Level)
+	check(oneOf(c.Logging.Redaction, "denylist", "allowlist"), "LOG_REDACTION must be denylist or allowlist, got %q", c.Logging.Redaction)
+	check(oneOf(c.Logging.Format, "json", "text", "pretty"), "LOG_FORMAT must be json, text or pretty, got %q", c.Logging.Format)
+	check(oneOf(c.Logging.Profile, "", "gcp", "plain"), "LOG_PROFILE must be gcp or plain, got %q", c.Logging.Profile)
+	check(c.Logging.File == "" || c.Logging.FileMaxSize > 0, "LOG_FILE_MAX_SIZE_MB must be positive")
+
+	check(oneOf(c.Tracing.Exporter, "gcp", "otlp-grpc", "otlp-http", "stdout", "none"), "TRACE_EXPORTER must be gcp, otlp-grpc, otlp-http, stdout or none, got %q", c.Tracing.Exporter)
+	check(c.Env != "production" || c.Tracing.Exporter == "gcp", "TRACE_EXPORTER must be gcp in production, got %q", c.Tracing.Exporter)
+	check(oneOf(c.Tracing.Sampler, "parentbased_ratio", "always", "never"), "TRACE_SAMPLER must be parentbased_ratio, always or never, got %q", c.Tracing.Sampler)
+	check(c.Tracing.SamplerRatio >= 0 && c.Tracing.SamplerRatio <= 1, "TRACE_SAMPLER_RATIO must be between 0 and 1, got %v", c.Tracing.SamplerRatio)
+
+	check(c.Metrics.ExportInterval > 0, "METRICS_EXPORT_INTERVAL must be positive")
+
+	check(oneOf(c.ErrorReport.Reporter, "none", "sentry", "stdout", "file"), "ERROR_REPORTER must be none, sentry, stdout or file, got %q", c.ErrorReport.Reporter)
+	check(c.ErrorReport.Reporter != "sentry" || c.ErrorReport.SentryDSN != "", "SENTRY_DSN is required for the sentry error reporter")
+	check(c.ErrorReport.SampleRate >= 0 && c.ErrorReport.SampleRate <= 1, "ERROR_REPORT_SAMPLE_RATE must be between 0 and 1, got %v", c.ErrorReport.SampleRate)
+	check(c.ErrorReport.Burst > 0, "ERROR_REPORT_BURST must be positive")
+
+	check(c.Env != "production" || c.Auth.TokenSecret != "", "AUTH_TOKEN_SECRET is required in production")
+	check(c.Auth.TokenSecret == "" || len(c.Auth.TokenSecret) >= 32, "AUTH_TOKEN_SECRET must be at least 32 bytes")
+	check(c.Auth.AccessTokenTTL > 0, "AUTH_ACCESS_TOKEN_TTL must be positive")
+	check(c.Auth.SessionTTL > 0, "AUTH_SESSION_TTL must be positive")
+	check(c.Auth.MaxFailedLogins > 0, "AUTH_MAX_FAILED_LOGINS must be positive")
+	check(c.Auth.LockoutDuration > 0, "AUTH_LOCKOUT_DURATION must be positive")
+
+	check(oneOf(c.Mail.Mailer, "smtp", "file", "memory"), "MAILER must be smtp, file or memory, got %q", c.Mail.Mailer)
+	check(c.Mail.Mailer != "smtp" || c.Mail.SMTPHost != "", "SMTP_HOST is required for the smtp mailer")
+	// the file and memory mailers would drop every email unnoticed
+	check(c.Env != "production" || c.Mail.Mailer == "smtp", "MAILER must be smtp in production, got %q", c.Mail.Mailer)
+	_, err = mail.ParseAddress(c.Mail.From)
+	check(err == nil, "MAIL_FROM must be an email address, got %q", c.Mail.From)
+	_, err = url.ParseRequestURI(c.Mail.VerifyURL)
+	check(err == nil, "EMAIL_VERIFY_URL must be an absolute URL, got %q", c.Mail.VerifyURL)
+	check(c.Mail.VerifyTokenTTL > 0, "EMAIL_VERIFY_TOKEN_TTL must be positive")
+	check(c.Mail.VerifyRequestInterval >= 0, "EMAIL_VERIFY_REQUEST_INTERVAL must not be negative")
+
+	base := c.Tenant.BaseDomain
+	check(!strings.HasPrefix(base, ".") && !strings.Contains(base, ":") && !strings.Contains(base, "/"), "TENANT_BASE_DOMAIN must be a bare domain name, got %q", base)
+
+	check(c.Follow.CountInterval > 0, "FOLLOW_COUNT_INTERVAL must be positive")
+
+	return errs
+}
+
+// oneOf compares exactly: consumers switch on the lowercase values, so e.g.
+// MAILER=SMTP would pass a case-insensitive check and then match nothing.
+func oneOf(v string, allowed ...string) bool {
+	return slices.Contains(allowed, v)
+}
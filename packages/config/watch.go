@@ -0,0 +1,161 @@
+package config
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"os"
+	"os/signal"
+	"path/filepath"
+	"reflect"
+	"sync"
+	"syscall"
+	"time"
+
+	"go02/packages/logging"
+)
+
+// Subscriber is notified after a reload has changed the configuration.
+type Subscriber func(old, new *Config)
+
+// Watcher reloads the configuration when the YAML file or the secrets
+// directory change, or when the process receives SIGHUP. Only fields tagged
+// reload:"true" are applied; other changes are logged and wait for a restart.
+type Watcher struct {
+	src      Source
+	store    *Store
+	interval time.Duration
+
+	mu          sync.Mutex
+	subscribers []Subscriber
+	fingerprint string
+}
+
+func NewWatcher(src Source, store *Store) *Watcher {
+	return &Watcher{
+		src:         src,
+		store:       store,
+		interval:    store.Get().ReloadInterval,
+		fingerprint: fingerprint(src),
+	}
+}
+
+// Subscribe registers fn to be called after every reload that changes a
+// reloadable field.
+func (w *Watcher) Subscribe(fn Subscriber) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	w.subscribers = append(w.subscribers, fn)
+}
+
+// Start watches for changes until ctx is cancelled.
+func (w *Watcher) Start(ctx context.Context) {
+	hup := make(chan os.Signal, 1)
+	signal.Notify(hup, syscall.SIGHUP)
+
+	go func() {
+		defer signal.Stop(hup)
+
+		var tick <-chan time.Time
+		if w.interval > 0 {
+			ticker := time.NewTicker(w.interval)
+			defer ticker.Stop()
+			tick = ticker.C
+		}
+
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-hup:
+				logging.Info(ctx, "reloading config on SIGHUP")
+				w.reload(ctx)
+			case <-tick:
+				if fp := fingerprint(w.src); fp != w.currentFingerprint() {
+					logging.Info(ctx, "config source changed, reloading")
+					w.reload(ctx)
+				}
+			}
+		}
+	}()
+}
+
+func (w *Watcher) currentFingerprint() string {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	return w.fingerprint
+}
+
+func (w *Watcher) reload(ctx context.Context) {
+	if err := w.Reload(ctx); err != nil {
+		logging.Errorf(ctx, err, "config reload failed, keeping the current config: %s", err.Error())
+	}
+}
+
+// Reload loads and validates the configuration and, if that succeeds,
+// applies the changed reloadable fields and notifies the subscribers. The
+// current configuration is kept when the new one is invalid.
+func (w *Watcher) Reload(ctx context.Context) error {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	// remember the source even if it is invalid so that a broken file is
+	// reported once rather than on every poll
+	w.fingerprint = fingerprint(w.src)
+
+	loaded, err := Load(w.src)
+	if err != nil {
+		return err
+	}
+
+	old := w.store.Get()
+	next := *old
+	applyReloadable(reflect.ValueOf(&next).Elem(), reflect.ValueOf(loaded).Elem())
+
+	applied := false
+	for _, c := range Diff(old, loaded) {
+		if !c.Reloadable {
+			logging.Warn(ctx, "config change requires a restart", "key", c.Key, "old", c.Old, "new", c.New)
+			continue
+		}
+		logging.Info(ctx, "config changed", "key", c.Key, "old", c.Old, "new", c.New)
+		applied = true
+	}
+	if !applied {
+		return nil
+	}
+
+	w.store.set(&next)
+	for _, fn := range w.subscribers {
+		fn(old, &next)
+	}
+
+	return nil
+}
+
+// fingerprint hashes the config file and the secret files so that changes,
+// including Kubernetes swapping a ConfigMap symlink, can be detected cheaply.
+func fingerprint(src Source) string {
+	h := sha256.New()
+
+	if src.File != "" {
+		b, err := os.ReadFile(src.File)
+		fmt.Fprintf(h, "%s\x00%v\x00%s\x00", src.File, err, b)
+	}
+
+	if src.SecretsDir != "" {
+		entries, _ := os.ReadDir(src.SecretsDir)
+		for _, entry := range entries {
+			if entry.IsDir() || entry.Name()[0] == '.' {
+				continue
+			}
+			b, err := os.ReadFile(filepath.Join(src.SecretsDir, entry.Name()))
+			fmt.Fprintf(h, "%s\x00%v\x00%s\x00", entry.Name(), err, b)
+		}
+	}
+
+	return hex.EncodeToString(h.Sum(nil))
+}
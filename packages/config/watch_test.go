@@ -0,0 +1,69 @@
+package config_test
+
+import (
+	"context"
+	"go02/packages/config"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestWatcherReload(t *testing.T) {
+	dir := t.TempDir()
+	file := filepath.Join(dir, "config.yaml")
+	write := func(body string) {
+		require.NoError(t, os.WriteFile(file, []byte(body), 0o600))
+	}
+
+	src := config.Source{File: file, Environ: baseEnv()}
+
+	write("logging:\n  level: info\ndb:\n  max_open_conns: 20\n")
+	initial, err := config.Load(src)
+	require.NoError(t, err)
+
+	store := config.NewStore(initial)
+	w := config.NewWatcher(src, store)
+	var notified []*config.Config
+	w.Subscribe(func(old, new *config.Config) {
+		notified = append(notified, new)
+	})
+
+	// reloadable fields are applied, the port waits for a restart
+	write("logging:\n  level: debug\ndb:\n  max_open_conns: 40\nserver:\n  port: \"9090\"\n")
+	require.NoError(t, w.Reload(context.Background()))
+
+	cfg := store.Get()
+	assert.Equal(t, "debug", cfg.Logging.Level)
+	assert.Equal(t, 40, cfg.DB.MaxOpenConns)
+	assert.Equal(t, "8080", cfg.Server.Port)
+	assert.Len(t, notified, 1)
+
+	// an invalid file keeps the current config
+	write("logging:\n  level: loud\n")
+	assert.ErrorContains(t, w.Reload(context.Background()), "LOG_LEVEL")
+	assert.Equal(t, "debug", store.Get().Logging.Level)
+
+	// nothing changed, nobody is notified
+	write("logging:\n  level: debug\ndb:\n  max_open_conns: 40\n")
+	require.NoError(t, w.Reload(context.Background()))
+	assert.Len(t, notified, 1)
+}
+
+func TestDiff(t *testing.T) {
+	old := &config.Config{}
+	old.DB.Password = "a"
+	old.DB.SlowQueryThreshold = time.Second
+
+	next := *old
+	next.DB.Password = "b"
+	next.DB.SlowQueryThreshold = 2 * time.Second
+
+	assert.Equal(t, []config.Change{
+		{Key: "db.password", Old: "******", New: "******"},
+		{Key: "db.slow_query_threshold", Old: "1s", New: "2s"},
+	}, config.Diff(old, &next))
+}
@@ -6,7 +6,10 @@ import (
 	"github.com/uptrace/bun"
 )
 
-type dbTx struct{}
+type (
+	dbTx   struct{}
+	dbConn struct{}
+)
 
 func SetTx(ctx context.Context, tx *bun.Tx) context.Context {
 	return context.WithValue(ctx, dbTx{}, tx)
@@ -19,9 +22,18 @@ func getTx(ctx context.Context) *bun.Tx {
 	return nil
 }
 
+func setConn(ctx context.Context, conn bun.Conn) context.Context {
+	return context.WithValue(ctx, dbConn{}, conn)
+}
+
+// GetTxOrDB returns the transaction of ctx, else the connection of
+// WithTenantConn, else db.
 func GetTxOrDB(ctx context.Context, db *bun.DB) bun.IDB {
 	if tx := getTx(ctx); tx != nil {
 		return tx
 	}
+	if conn, ok := ctx.Value(dbConn{}).(bun.Conn); ok {
+		return conn
+	}
 	return db
 }
@@ -5,23 +5,45 @@ import (
 	"database/sql"
 	"fmt"
 	"go02/packages/config"
+	"go02/packages/logging"
 	"time"
 
 	"github.com/uptrace/bun"
 	"github.com/uptrace/bun/dialect/pgdialect"
 	"github.com/uptrace/bun/extra/bundebug"
+	"go.opentelemetry.io/otel/metric"
+	metricnoop "go.opentelemetry.io/otel/metric/noop"
+	"go.opentelemetry.io/otel/trace"
+	tracenoop "go.opentelemetry.io/otel/trace/noop"
 
 	_ "github.com/lib/pq"
 )
 
-func OpenDB() (*bun.DB, error) {
+type Options struct {
+	Config config.DBConfig
+	// Explain adds the EXPLAIN (ANALYZE, BUFFERS) plan of slow selects to
+	// their log entry and span. It runs each of them again before returning,
+	// so it is meant for development.
+	Explain bool
 
-	dsn := fmt.Sprintf("user=%s password=%s database=%s host=%s port=%s sslmode=disable",
-		config.Config.DBUser,
-		config.Config.DBPassword,
-		config.Config.DBName,
-		config.Config.DBHost,
-		config.Config.DBPort,
+	// TracerProvider and MeterProvider instrument the queries. They default
+	// to no-op providers.
+	TracerProvider trace.TracerProvider
+	MeterProvider  metric.MeterProvider
+}
+
+// Open prepares the connection pool and query hooks without connecting; call
+// WaitReady before serving traffic.
+func Open(opts Options) (*bun.DB, error) {
+	cfg := opts.Config
+
+	// timezone makes the session render timestamptz in UTC, like the models
+	dsn := fmt.Sprintf("user=%s password=%s database=%s host=%s port=%s sslmode=disable timezone=UTC",
+		cfg.User,
+		cfg.Password,
+		cfg.Name,
+		cfg.Host,
+		cfg.Port,
 	)
 
 	sqlDB, err := sql.Open("postgres", dsn)
@@ -29,13 +51,33 @@ func OpenDB() (*bun.DB, error) {
 		return nil, fmt.Errorf("sql.Open: %w", err)
 	}
 
-	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
-	defer cancel()
-	if err := sqlDB.PingContext(ctx); err != nil {
-		return nil, err
-	}
+	setPool(sqlDB, cfg)
 
 	db := bun.NewDB(sqlDB, pgdialect.New())
+	AddQueryHooks(db, opts)
+
+	return db, nil
+}
+
+// AddQueryHooks installs the tracing, metrics and slow query hooks. Open does
+// this already; it is exported for connections opened elsewhere, e.g. with
+// pgdriver in tests.
+func AddQueryHooks(db *bun.DB, opts Options) {
+	tp := opts.TracerProvider
+	if tp == nil {
+		tp = tracenoop.NewTracerProvider()
+	}
+	mp := opts.MeterProvider
+	if mp == nil {
+		mp = metricnoop.NewMeterProvider()
+	}
+
+	db.AddQueryHook(newTracingQueryHook(tp, opts.Config.TraceStatements))
+	db.AddQueryHook(newMetricsQueryHook(mp))
+
+	if threshold := opts.Config.SlowQueryThreshold; threshold > 0 {
+		db.AddQueryHook(newSlowQueryHook(threshold, opts.Explain))
+	}
 
 	db.AddQueryHook(bundebug.NewQueryHook(
 		// disable the hook
@@ -45,6 +87,57 @@ func OpenDB() (*bun.DB, error) {
 		// BUNDEBUG=2 logs all queries
 		bundebug.FromEnv("BUNDEBUG"),
 	))
+}
 
-	return db, nil
+// WaitReady waits for the database to accept connections, backing off
+// exponentially between attempts. Postgres is often still starting up when
+// the api container boots under compose.
+func WaitReady(ctx context.Context, db *bun.DB, cfg config.DBConfig) error {
+	return pingWithRetry(ctx, db.DB, cfg)
+}
+
+// SetPool applies the connection pool limits, e.g. after a config reload.
+func SetPool(db *bun.DB, cfg config.DBConfig) {
+	setPool(db.DB, cfg)
+}
+
+func setPool(sqlDB *sql.DB, cfg config.DBConfig) {
+	sqlDB.SetMaxOpenConns(cfg.MaxOpenConns)
+	sqlDB.SetMaxIdleConns(cfg.MaxIdleConns)
+	sqlDB.SetConnMaxLifetime(cfg.ConnMaxLifetime)
+	sqlDB.SetConnMaxIdleTime(cfg.ConnMaxIdleTime)
+}
+
+func pingWithRetry(ctx context.Context, sqlDB *sql.DB, cfg config.DBConfig) error {
+	backoff := cfg.ConnectBackoff
+	retries := max(cfg.ConnectRetries, 0)
+
+	var err error
+	for attempt := 0; attempt <= retries; attempt++ {
+		if err = ping(ctx, sqlDB, cfg.PingTimeout); err == nil {
+			return nil
+		}
+		if attempt == retries {
+			break
+		}
+
+		logging.Infof(ctx, "database is not ready (attempt %d/%d), retrying in %s: %s", attempt+1, retries+1, backoff, err.Error())
+
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-time.After(backoff):
+		}
+
+		backoff = min(backoff*2, cfg.ConnectMaxBackoff)
+	}
+
+	return fmt.Errorf("ping database after %d attempts: %w", retries+1, err)
+}
+
+func ping(ctx context.Context, sqlDB *sql.DB, timeout time.Duration) error {
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+
+	return sqlDB.PingContext(ctx)
 }
@@ -0,0 +1,56 @@
+package db
+
+import (
+	"errors"
+	"strings"
+
+	"go02/packages/apperrors"
+
+	"github.com/lib/pq"
+	"github.com/uptrace/bun/driver/pgdriver"
+)
+
+const uniqueViolation = "23505"
+
+// TranslateError turns driver errors the caller can act on into apperrors:
+// a unique violation becomes a *apperrors.ConflictError. Other errors are
+// returned as they are. It understands both lib/pq and pgdriver.
+func TranslateError(err error) error {
+	var (
+		pqErr *pq.Error
+		pgErr pgdriver.Error
+	)
+	switch {
+	case errors.As(err, &pqErr) && pqErr.Code == uniqueViolation:
+		return conflict(err, pqErr.Table, pqErr.Constraint)
+	case errors.As(err, &pgErr) && pgErr.Field('C') == uniqueViolation:
+		return conflict(err, pgErr.Field('t'), pgErr.Field('n'))
+	}
+	return err
+}
+
+func conflict(cause error, table, constraint string) error {
+	return &apperrors.ConflictError{
+		Field:      uniqueField(table, constraint),
+		Constraint: constraint,
+		Err:        cause,
+	}
+}
+
+// uniqueField derives the column from a constraint named like PostgreSQL
+// names them, <table>_<columns>_key or <table>_pkey. Keys scoped to a tenant
+// start with org_id, which isn't an input, so it is left out.
+func uniqueField(table, constraint string) string {
+	field := strings.TrimPrefix(constraint, table+"_")
+	switch {
+	case field == "pkey":
+		return "id"
+	case strings.HasSuffix(field, "_key"):
+		field = strings.TrimSuffix(field, "_key")
+		if scoped, ok := strings.CutPrefix(field, TenantColumn+"_"); ok && scoped != "" {
+			return scoped
+		}
+		return field
+	}
+	return constraint
+}
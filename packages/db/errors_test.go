@@ -0,0 +1,32 @@
+package db
+
+import (
+	"errors"
+	"testing"
+
+	"go02/packages/apperrors"
+
+	"github.com/lib/pq"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestTranslateError(t *testing.T) {
+	err := TranslateError(&pq.Error{Code: "23505", Table: "users", Constraint: "users_handle_key"})
+
+	var conflict *apperrors.ConflictError
+	require.True(t, errors.As(err, &conflict))
+	assert.Equal(t, "handle", conflict.Field)
+	assert.ErrorIs(t, err, apperrors.ErrConflict)
+
+	other := &pq.Error{Code: "23503", Table: "profiles", Constraint: "profiles_user_id_fkey"}
+	assert.Same(t, other, TranslateError(other))
+}
+
+func TestUniqueField(t *testing.T) {
+	assert.Equal(t, "handle", uniqueField("users", "users_handle_key"))
+	assert.Equal(t, "handle", uniqueField("users", "users_org_id_handle_key"))
+	assert.Equal(t, "org_id", uniqueField("users", "users_org_id_key"))
+	assert.Equal(t, "id", uniqueField("users", "users_pkey"))
+	assert.Equal(t, "custom_unique", uniqueField("users", "custom_unique"))
+}
@@ -0,0 +1,44 @@
+package db
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/uptrace/bun"
+)
+
+// PingCheck reports whether the database currently accepts connections.
+func PingCheck(db *bun.DB) func(ctx context.Context) error {
+	return func(ctx context.Context) error {
+		return db.PingContext(ctx)
+	}
+}
+
+// MigrationCheck reports whether the schema has been migrated to at least the
+// expected version by golang-migrate and is not left dirty by a failed
+// migration. A newer schema is fine: during a rolling update the migrate Job
+// runs ahead of the old pods, and migrations stay backward compatible.
+func MigrationCheck(db *bun.DB, expected uint) func(ctx context.Context) error {
+	return func(ctx context.Context) error {
+		var (
+			version uint
+			dirty   bool
+		)
+		if err := db.QueryRowContext(ctx, "SELECT version, dirty FROM schema_migrations LIMIT 1").Scan(&version, &dirty); err != nil {
+			return fmt.Errorf("read schema_migrations: %w", err)
+		}
+
+		return checkMigrationVersion(version, dirty, expected)
+	}
+}
+
+func checkMigrationVersion(version uint, dirty bool, expected uint) error {
+	if dirty {
+		return fmt.Errorf("migration version %d is dirty", version)
+	}
+	if version < expected {
+		return fmt.Errorf("migration version is %d, expected at least %d", version, expected)
+	}
+
+	return nil
+}
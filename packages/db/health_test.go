@@ -0,0 +1,25 @@
+package db
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCheckMigrationVersion(t *testing.T) {
+	t.Run("正常系: 期待したバージョン", func(t *testing.T) {
+		assert.NoError(t, checkMigrationVersion(27, false, 27))
+	})
+
+	t.Run("正常系: ローリングアップデート中の新しいスキーマ", func(t *testing.T) {
+		assert.NoError(t, checkMigrationVersion(28, false, 27))
+	})
+
+	t.Run("異常系: 古いスキーマ", func(t *testing.T) {
+		assert.EqualError(t, checkMigrationVersion(26, false, 27), "migration version is 26, expected at least 27")
+	})
+
+	t.Run("異常系: dirty", func(t *testing.T) {
+		assert.EqualError(t, checkMigrationVersion(28, true, 27), "migration version 28 is dirty")
+	})
+}
@@ -0,0 +1,94 @@
+package db
+
+import (
+	"context"
+	"database/sql"
+	"strings"
+	"time"
+
+	"github.com/uptrace/bun"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/metric"
+)
+
+type metricsQueryHook struct {
+	duration metric.Float64Histogram
+}
+
+var _ bun.QueryHook = (*metricsQueryHook)(nil)
+
+func newMetricsQueryHook(mp metric.MeterProvider) *metricsQueryHook {
+	duration, _ := mp.Meter("go02/db").Float64Histogram("db.client.query.duration",
+		metric.WithDescription("Duration of database queries."),
+		metric.WithUnit("s"),
+	)
+
+	return &metricsQueryHook{
+		duration: duration,
+	}
+}
+
+func (h *metricsQueryHook) BeforeQuery(ctx context.Context, event *bun.QueryEvent) context.Context {
+	return ctx
+}
+
+func (h *metricsQueryHook) AfterQuery(ctx context.Context, event *bun.QueryEvent) {
+	h.duration.Record(ctx, time.Since(event.StartTime).Seconds(), metric.WithAttributes(
+		attribute.String("db.operation", queryOperation(event)),
+		attribute.String("db.sql.table", queryTable(event)),
+		attribute.Bool("error", isQueryError(event.Err)),
+	))
+}
+
+// RegisterStatsMetrics exposes the connection pool statistics as observable gauges.
+func RegisterStatsMetrics(db *bun.DB, mp metric.MeterProvider) error {
+	meter := mp.Meter("go02/db")
+
+	maxOpen, _ := meter.Int64ObservableGauge("db.client.connections.max",
+		metric.WithDescription("Maximum number of open connections allowed."))
+	open, _ := meter.Int64ObservableGauge("db.client.connections.open",
+		metric.WithDescription("Number of established connections, both in use and idle."))
+	inUse, _ := meter.Int64ObservableGauge("db.client.connections.in_use",
+		metric.WithDescription("Number of connections currently in use."))
+	idle, _ := meter.Int64ObservableGauge("db.client.connections.idle",
+		metric.WithDescription("Number of idle connections."))
+	waitCount, _ := meter.Int64ObservableCounter("db.client.connections.wait_count",
+		metric.WithDescription("Total number of connections waited for."))
+	waitDuration, _ := meter.Float64ObservableCounter("db.client.connections.wait_duration",
+		metric.WithDescription("Total time blocked waiting for a new connection."),
+		metric.WithUnit("s"))
+
+	_, err := meter.RegisterCallback(func(ctx context.Context, o metric.Observer) error {
+		s := db.DB.Stats()
+		o.ObserveInt64(maxOpen, int64(s.MaxOpenConnections))
+		o.ObserveInt64(open, int64(s.OpenConnections))
+		o.ObserveInt64(inUse, int64(s.InUse))
+		o.ObserveInt64(idle, int64(s.Idle))
+		o.ObserveInt64(waitCount, s.WaitCount)
+		o.ObserveFloat64(waitDuration, s.WaitDuration.Seconds())
+		return nil
+	}, maxOpen, open, inUse, idle, waitCount, waitDuration)
+
+	return err
+}
+
+func queryOperation(event *bun.QueryEvent) string {
+	return strings.ToLower(event.Operation())
+}
+
+func queryTable(event *bun.QueryEvent) string {
+	if m, ok := event.Model.(bun.TableModel); ok {
+		if table := m.Table(); table != nil {
+			return table.Name
+		}
+	}
+	return ""
+}
+
+func isQueryError(err error) bool {
+	switch err {
+	case nil, sql.ErrNoRows, sql.ErrTxDone:
+		return false
+	}
+	return true
+}
@@ -0,0 +1,186 @@
+package db
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io/fs"
+	"net/url"
+	"sort"
+	"strings"
+
+	dbmigration "go02/db-migration"
+	"go02/packages/config"
+	"go02/packages/logging"
+
+	"github.com/golang-migrate/migrate/v4"
+	_ "github.com/golang-migrate/migrate/v4/database/postgres"
+	"github.com/golang-migrate/migrate/v4/source/iofs"
+	"github.com/uptrace/bun"
+)
+
+// migrationLockKey is the pg_advisory_lock key held while migrating on start,
+// so that only one replica applies migrations at a time.
+const migrationLockKey = 7_100_402
+
+// URL returns the database as a postgres:// URL, as golang-migrate expects.
+func URL(cfg config.DBConfig) string {
+	u := url.URL{
+		Scheme:   "postgres",
+		User:     url.UserPassword(cfg.User, cfg.Password),
+		Host:     cfg.Host + ":" + cfg.Port,
+		Path:     cfg.Name,
+		RawQuery: "sslmode=disable",
+	}
+	return u.String()
+}
+
+// Migrator applies the migrations embedded in the binary.
+type Migrator struct {
+	m *migrate.Migrate
+}
+
+type MigrationStatus struct {
+	Version uint `json:"version"`
+	Dirty   bool `json:"dirty"`
+	Latest  uint `json:"latest"`
+}
+
+// NewMigrator opens its own connection to databaseURL; close it with Close.
+func NewMigrator(ctx context.Context, databaseURL string) (*Migrator, error) {
+	src, err := iofs.New(dbmigration.Migrations(), ".")
+	if err != nil {
+		return nil, fmt.Errorf("open embedded migrations: %w", err)
+	}
+
+	m, err := migrate.NewWithSourceInstance("iofs", src, databaseURL)
+	if err != nil {
+		return nil, fmt.Errorf("migrate.New: %w", err)
+	}
+	m.Log = migrateLogger{ctx: ctx}
+
+	return &Migrator{m: m}, nil
+}
+
+func (m *Migrator) Close() error {
+	srcErr, dbErr := m.m.Close()
+	return errors.Join(srcErr, dbErr)
+}
+
+// Up applies all pending migrations.
+func (m *Migrator) Up() error {
+	return ignoreNoChange(m.m.Up())
+}
+
+// Down rolls back steps migrations.
+func (m *Migrator) Down(steps int) error {
+	return ignoreNoChange(m.m.Steps(-steps))
+}
+
+// Goto migrates up or down to version.
+func (m *Migrator) Goto(version uint) error {
+	return ignoreNoChange(m.m.Migrate(version))
+}
+
+// Force sets the version without running any migration and clears the dirty
+// flag, to recover after fixing a failed migration by hand. -1 means no
+// version.
+func (m *Migrator) Force(version int) error {
+	return m.m.Force(version)
+}
+
+func (m *Migrator) Status() (MigrationStatus, error) {
+	latest, err := dbmigration.LatestVersion()
+	if err != nil {
+		return MigrationStatus{}, err
+	}
+
+	version, dirty, err := m.m.Version()
+	if err != nil && !errors.Is(err, migrate.ErrNilVersion) {
+		return MigrationStatus{}, err
+	}
+
+	return MigrationStatus{Version: version, Dirty: dirty, Latest: latest}, nil
+}
+
+func ignoreNoChange(err error) error {
+	if errors.Is(err, migrate.ErrNoChange) {
+		return nil
+	}
+	return err
+}
+
+// MigrateOnStart applies pending migrations while holding an advisory lock on
+// db. Replicas starting together wait for each other, and the ones that get
+// the lock later find nothing left to do.
+func MigrateOnStart(ctx context.Context, db *bun.DB, databaseURL string) error {
+	conn, err := db.Conn(ctx)
+	if err != nil {
+		return fmt.Errorf("get connection: %w", err)
+	}
+	defer conn.Close()
+
+	logging.Info(ctx, "waiting for the migration lock")
+	if _, err := conn.ExecContext(ctx, "SELECT pg_advisory_lock(?)", migrationLockKey); err != nil {
+		return fmt.Errorf("acquire migration lock: %w", err)
+	}
+	defer func() {
+		if _, err := conn.ExecContext(context.WithoutCancel(ctx), "SELECT pg_advisory_unlock(?)", migrationLockKey); err != nil {
+			logging.Errorf(ctx, err, "failed to release the migration lock: %s", err.Error())
+		}
+	}()
+
+	m, err := NewMigrator(ctx, databaseURL)
+	if err != nil {
+		return err
+	}
+	defer m.Close()
+
+	if err := m.Up(); err != nil {
+		return fmt.Errorf("migrate up: %w", err)
+	}
+
+	status, err := m.Status()
+	if err != nil {
+		return err
+	}
+	logging.Infof(ctx, "database is at migration version %d", status.Version)
+
+	return nil
+}
+
+// Seed runs the embedded seed files in one transaction.
+func Seed(ctx context.Context, db *bun.DB) error {
+	seeds := dbmigration.Seeds()
+	names, err := fs.Glob(seeds, "*.sql")
+	if err != nil {
+		return err
+	}
+	sort.Strings(names)
+
+	return db.RunInTx(ctx, nil, func(ctx context.Context, tx bun.Tx) error {
+		for _, name := range names {
+			b, err := fs.ReadFile(seeds, name)
+			if err != nil {
+				return err
+			}
+			if _, err := tx.ExecContext(ctx, string(b)); err != nil {
+				return fmt.Errorf("seed %s: %w", name, err)
+			}
+			logging.Infof(ctx, "applied seed %s", name)
+		}
+		return nil
+	})
+}
+
+type migrateLogger struct {
+	ctx context.Context
+}
+
+func (l migrateLogger) Printf(format string, v ...any) {
+	logging.Info(l.ctx, strings.TrimSpace(fmt.Sprintf(format, v...)))
+}
+
+func (l migrateLogger) Verbose() bool {
+	return false
+}
@@ -0,0 +1,105 @@
+package db
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"go02/packages/logging"
+
+	"github.com/uptrace/bun"
+	"go.opentelemetry.io/otel/metric"
+)
+
+// Monitor periodically pings the database and remembers the outcome of the
+// last probe so that it can be reported without hitting the database again.
+type Monitor struct {
+	db       *bun.DB
+	interval time.Duration
+	timeout  time.Duration
+
+	mu        sync.RWMutex
+	lastErr   error
+	checkedAt time.Time
+}
+
+func NewMonitor(db *bun.DB, interval time.Duration, timeout time.Duration) *Monitor {
+	return &Monitor{
+		db:       db,
+		interval: interval,
+		timeout:  timeout,
+	}
+}
+
+// Start probes the database every interval until ctx is cancelled.
+func (m *Monitor) Start(ctx context.Context) {
+	m.probe(ctx)
+
+	go func() {
+		ticker := time.NewTicker(m.interval)
+		defer ticker.Stop()
+
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+				m.probe(ctx)
+			}
+		}
+	}()
+}
+
+// Status returns the result of the last probe and when it was taken.
+func (m *Monitor) Status() (time.Time, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	return m.checkedAt, m.lastErr
+}
+
+// RegisterMetrics exposes the last probe as the db.client.up gauge, 1 when the
+// database answered and 0 when it didn't, for alerting. Nothing is observed
+// before the first probe.
+func (m *Monitor) RegisterMetrics(mp metric.MeterProvider) error {
+	meter := mp.Meter("go02/db")
+
+	up, _ := meter.Int64ObservableGauge("db.client.up",
+		metric.WithDescription("Whether the last periodic database probe succeeded."))
+	age, _ := meter.Float64ObservableGauge("db.client.probe.age",
+		metric.WithDescription("Time since the last periodic database probe."),
+		metric.WithUnit("s"))
+
+	_, err := meter.RegisterCallback(func(ctx context.Context, o metric.Observer) error {
+		checkedAt, err := m.Status()
+		if checkedAt.IsZero() {
+			return nil
+		}
+		if err != nil {
+			o.ObserveInt64(up, 0)
+		} else {
+			o.ObserveInt64(up, 1)
+		}
+		o.ObserveFloat64(age, time.Since(checkedAt).Seconds())
+		return nil
+	}, up, age)
+
+	return err
+}
+
+func (m *Monitor) probe(ctx context.Context) {
+	err := ping(ctx, m.db.DB, m.timeout)
+
+	m.mu.Lock()
+	recovered := m.lastErr != nil && err == nil
+	m.lastErr = err
+	m.checkedAt = time.Now()
+	m.mu.Unlock()
+
+	switch {
+	case err != nil:
+		logging.Errorf(ctx, err, "database health probe failed: %s", err.Error())
+	case recovered:
+		logging.Info(ctx, "database health probe recovered")
+	}
+}
@@ -0,0 +1,57 @@
+package db
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	sdkmetric "go.opentelemetry.io/otel/sdk/metric"
+	"go.opentelemetry.io/otel/sdk/metric/metricdata"
+)
+
+func TestMonitorMetrics(t *testing.T) {
+	reader := sdkmetric.NewManualReader()
+	m := NewMonitor(nil, time.Minute, time.Second)
+	require.NoError(t, m.RegisterMetrics(sdkmetric.NewMeterProvider(sdkmetric.WithReader(reader))))
+
+	up := func() (int64, bool) {
+		var rm metricdata.ResourceMetrics
+		require.NoError(t, reader.Collect(context.Background(), &rm))
+		for _, sm := range rm.ScopeMetrics {
+			for _, metric := range sm.Metrics {
+				if metric.Name == "db.client.up" {
+					points := metric.Data.(metricdata.Gauge[int64]).DataPoints
+					require.Len(t, points, 1)
+					return points[0].Value, true
+				}
+			}
+		}
+		return 0, false
+	}
+	set := func(err error) {
+		m.mu.Lock()
+		defer m.mu.Unlock()
+		m.lastErr = err
+		m.checkedAt = time.Now()
+	}
+
+	t.Run("正常系: 最初の確認までは何も出さない", func(t *testing.T) {
+		_, ok := up()
+		assert.False(t, ok)
+	})
+
+	t.Run("正常系: 最後の確認の結果を出す", func(t *testing.T) {
+		set(nil)
+		v, ok := up()
+		require.True(t, ok)
+		assert.Equal(t, int64(1), v)
+
+		set(errors.New("connection refused"))
+		v, ok = up()
+		require.True(t, ok)
+		assert.Equal(t, int64(0), v)
+	})
+}
@@ -0,0 +1,22 @@
+package db
+
+import (
+	"regexp"
+	"strings"
+)
+
+var (
+	stringLiteral  = regexp.MustCompile(`(?:[EeBbXx])?'(?:[^']|'')*'`)
+	numericLiteral = regexp.MustCompile(`\b\d+(?:\.\d+)?\b`)
+	whitespace     = regexp.MustCompile(`\s+`)
+)
+
+// SanitizeQuery replaces the literal values bun inlines into a query with
+// placeholders so that the statement can be recorded without leaking data.
+func SanitizeQuery(query string) string {
+	query = stringLiteral.ReplaceAllString(query, "?")
+	query = numericLiteral.ReplaceAllString(query, "?")
+	query = whitespace.ReplaceAllString(query, " ")
+
+	return strings.TrimSpace(query)
+}
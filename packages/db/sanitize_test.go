@@ -0,0 +1,42 @@
+package db_test
+
+import (
+	"go02/packages/db"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSanitizeQuery(t *testing.T) {
+	tests := []struct {
+		name     string
+		query    string
+		expected string
+	}{
+		{
+			name:     "正常系: 文字列と数値が置き換えられる場合",
+			query:    `INSERT INTO "users" ("id", "name", "age") VALUES (DEFAULT, 'taro', 24) RETURNING "id"`,
+			expected: `INSERT INTO "users" ("id", "name", "age") VALUES (DEFAULT, ?, ?) RETURNING "id"`,
+		},
+		{
+			name:     "正常系: エスケープされた引用符を含む場合",
+			query:    `UPDATE "profiles" AS "profile" SET "bio" = 'it''s me' WHERE ("profile"."id" = 3)`,
+			expected: `UPDATE "profiles" AS "profile" SET "bio" = ? WHERE ("profile"."id" = ?)`,
+		},
+		{
+			name:     "正常系: 識別子に含まれる数字は置き換えられない場合",
+			query:    "SELECT \"t1\".\"col2\"\n  FROM \"t1\" LIMIT 100 OFFSET 0",
+			expected: `SELECT "t1"."col2" FROM "t1" LIMIT ? OFFSET ?`,
+		},
+	}
+
+	for _, tt := range tests {
+		tt := tt
+
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			assert.Equal(t, tt.expected, db.SanitizeQuery(tt.query))
+		})
+	}
+}
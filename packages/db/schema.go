@@ -0,0 +1,266 @@
+package db
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"reflect"
+	"regexp"
+	"sort"
+	"strings"
+
+	"github.com/uptrace/bun"
+	"github.com/uptrace/bun/schema"
+)
+
+const (
+	IssueMissingTable   = "missing_table"
+	IssueMissingColumn  = "missing_column"
+	IssueUnmappedColumn = "unmapped_column"
+	IssueTypeMismatch   = "type_mismatch"
+	IssueNullability    = "nullability_mismatch"
+	IssueMissingIndex   = "missing_index"
+)
+
+// SchemaIssue is a difference between a bun model and its table.
+type SchemaIssue struct {
+	Table   string `json:"table"`
+	Column  string `json:"column,omitempty"`
+	Kind    string `json:"kind"`
+	Message string `json:"message"`
+}
+
+func (i SchemaIssue) String() string {
+	name := i.Table
+	if i.Column != "" {
+		name += "." + i.Column
+	}
+	return fmt.Sprintf("%s: %s: %s", name, i.Kind, i.Message)
+}
+
+type dbColumn struct {
+	Name       string
+	DataType   string
+	Nullable   bool
+	HasDefault bool
+}
+
+// CheckSchema compares the tables of models in the current schema with bun's
+// metadata for them: missing tables and columns, columns the model doesn't
+// map, type and nullability mismatches and foreign keys without an index.
+func CheckSchema(ctx context.Context, db *bun.DB, models ...any) ([]SchemaIssue, error) {
+	var issues []SchemaIssue
+
+	for _, m := range models {
+		table := db.Table(reflect.TypeOf(m))
+
+		columns, err := tableColumns(ctx, db, table.Name)
+		if err != nil {
+			return nil, err
+		}
+		if len(columns) == 0 {
+			issues = append(issues, SchemaIssue{
+				Table:   table.Name,
+				Kind:    IssueMissingTable,
+				Message: fmt.Sprintf("table for %s doesn't exist", table.TypeName),
+			})
+			continue
+		}
+		issues = append(issues, compareColumns(table, columns)...)
+
+		indexIssues, err := checkForeignKeyIndexes(ctx, db, table.Name)
+		if err != nil {
+			return nil, err
+		}
+		issues = append(issues, indexIssues...)
+	}
+
+	return issues, nil
+}
+
+func tableColumns(ctx context.Context, db *bun.DB, table string) (map[string]dbColumn, error) {
+	rows, err := db.QueryContext(ctx, `
+		SELECT column_name, data_type, is_nullable = 'YES', column_default IS NOT NULL
+		FROM information_schema.columns
+		WHERE table_schema = current_schema() AND table_name = ?`, table)
+	if err != nil {
+		return nil, fmt.Errorf("read columns of %s: %w", table, err)
+	}
+	defer rows.Close()
+
+	columns := map[string]dbColumn{}
+	for rows.Next() {
+		var c dbColumn
+		if err := rows.Scan(&c.Name, &c.DataType, &c.Nullable, &c.HasDefault); err != nil {
+			return nil, err
+		}
+		columns[c.Name] = c
+	}
+
+	return columns, rows.Err()
+}
+
+func compareColumns(table *schema.Table, columns map[string]dbColumn) []SchemaIssue {
+	var issues []SchemaIssue
+	issue := func(column, kind, format string, args ...any) {
+		issues = append(issues, SchemaIssue{
+			Table:   table.Name,
+			Column:  column,
+			Kind:    kind,
+			Message: fmt.Sprintf(format, args...),
+		})
+	}
+
+	mapped := map[string]struct{}{}
+	for _, f := range table.Fields {
+		mapped[f.Name] = struct{}{}
+
+		c, ok := columns[f.Name]
+		if !ok {
+			issue(f.Name, IssueMissingColumn, "%s.%s has no column", table.TypeName, f.GoName)
+			continue
+		}
+
+		want := normalizeType(f.UserSQLType)
+		got := normalizeType(c.DataType)
+		if !compatibleTypes(want, got) {
+			issue(f.Name, IssueTypeMismatch, "column is %s but %s maps to %s", got, f.IndirectType, want)
+		}
+
+		nullable := fieldNullable(f)
+		switch {
+		case c.Nullable && !nullable:
+			issue(f.Name, IssueNullability, "column is nullable but %s can't hold NULL", f.StructField.Type)
+		case !c.Nullable && nullable && !c.HasDefault && !f.IsPK:
+			issue(f.Name, IssueNullability, "%s may be written as NULL but the column is NOT NULL without a default", f.StructField.Type)
+		}
+	}
+
+	names := make([]string, 0, len(columns))
+	for name := range columns {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		if _, ok := mapped[name]; !ok {
+			issue(name, IssueUnmappedColumn, "%s has no field for the column", table.TypeName)
+		}
+	}
+
+	return issues
+}
+
+var sqlNullType = regexp.MustCompile(`^Null[A-Z]`)
+
+// fieldNullable reports whether the field can round-trip NULL.
+func fieldNullable(f *schema.Field) bool {
+	if f.IsPtr || f.NullZero {
+		return true
+	}
+	t := f.StructField.Type
+	return t.PkgPath() == "database/sql" && sqlNullType.MatchString(t.Name())
+}
+
+var typeLength = regexp.MustCompile(`\(.*\)`)
+
+// normalizeType maps information_schema names and bun's types onto the
+// same spelling, e.g. "timestamp with time zone" and "TIMESTAMPTZ".
+func normalizeType(t string) string {
+	t = strings.ToLower(strings.TrimSpace(typeLength.ReplaceAllString(t, "")))
+
+	switch t {
+	case "character varying":
+		return "varchar"
+	case "character":
+		return "char"
+	case "timestamp without time zone":
+		return "timestamp"
+	case "timestamp with time zone":
+		return "timestamptz"
+	case "time without time zone":
+		return "time"
+	case "time with time zone":
+		return "timetz"
+	case "double precision", "float8":
+		return "double precision"
+	case "float4":
+		return "real"
+	case "int8", "bigserial":
+		return "bigint"
+	case "int4", "int", "serial":
+		return "integer"
+	case "int2", "smallserial":
+		return "smallint"
+	case "bool":
+		return "boolean"
+	}
+	return t
+}
+
+func compatibleTypes(model, column string) bool {
+	if model == column {
+		return true
+	}
+	// strings scan from any character type
+	text := map[string]bool{"varchar": true, "text": true}
+	return text[model] && text[column]
+}
+
+func checkForeignKeyIndexes(ctx context.Context, db *bun.DB, table string) ([]SchemaIssue, error) {
+	// columns of every foreign key and every index of the table, in order
+	const keyColumns = `array_to_string(ARRAY(
+		SELECT att.attname
+		FROM unnest(%s) WITH ORDINALITY AS k(attnum, ord)
+		JOIN pg_attribute att ON att.attrelid = %s AND att.attnum = k.attnum
+		ORDER BY k.ord), ',')`
+
+	var foreignKeys []struct {
+		Name    string
+		Columns string
+	}
+	if err := db.NewRaw(`
+		SELECT con.conname AS name, `+fmt.Sprintf(keyColumns, "con.conkey", "con.conrelid")+` AS columns
+		FROM pg_constraint con
+		WHERE con.contype = 'f' AND con.conrelid = to_regclass(?)`, table).
+		Scan(ctx, &foreignKeys); err != nil && !errors.Is(err, sql.ErrNoRows) {
+		return nil, fmt.Errorf("read foreign keys of %s: %w", table, err)
+	}
+	if len(foreignKeys) == 0 {
+		return nil, nil
+	}
+
+	var indexes []string
+	if err := db.NewRaw(`
+		SELECT `+fmt.Sprintf(keyColumns, "i.indkey::int2[]", "i.indrelid")+`
+		FROM pg_index i
+		WHERE i.indrelid = to_regclass(?)`, table).
+		Scan(ctx, &indexes); err != nil && !errors.Is(err, sql.ErrNoRows) {
+		return nil, fmt.Errorf("read indexes of %s: %w", table, err)
+	}
+
+	var issues []SchemaIssue
+	for _, fk := range foreignKeys {
+		if !hasLeadingIndex(indexes, fk.Columns) {
+			issues = append(issues, SchemaIssue{
+				Table:   table,
+				Column:  fk.Columns,
+				Kind:    IssueMissingIndex,
+				Message: fmt.Sprintf("foreign key %s has no index starting with its columns", fk.Name),
+			})
+		}
+	}
+
+	return issues, nil
+}
+
+// hasLeadingIndex reports whether one of the indexes starts with columns, so
+// that it can serve lookups and cascades on the foreign key.
+func hasLeadingIndex(indexes []string, columns string) bool {
+	for _, idx := range indexes {
+		if idx == columns || strings.HasPrefix(idx, columns+",") {
+			return true
+		}
+	}
+	return false
+}
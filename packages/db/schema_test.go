@@ -0,0 +1,62 @@
+package db
+
+import (
+	"database/sql"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/uptrace/bun"
+	"github.com/uptrace/bun/dialect/pgdialect"
+)
+
+// profile maps profiles like model.Profile, which imports this package
+type profile struct {
+	bun.BaseModel `bun:"table:profiles"`
+
+	ID        int    `bun:",pk,autoincrement"`
+	UserID    int    `bun:"user_id"`
+	Bio       string `bun:"bio"`
+	AvatarURL string `bun:"avatar_url"`
+
+	CreatedAt time.Time `bun:",nullzero"`
+	UpdatedAt time.Time `bun:",nullzero"`
+}
+
+func TestCompareColumns(t *testing.T) {
+	db := bun.NewDB(&sql.DB{}, pgdialect.New())
+	table := db.Table(reflect.TypeOf((*profile)(nil)))
+
+	// profiles as created by 000002, before 000025 made bio NOT NULL, plus a
+	// column the model doesn't know
+	columns := map[string]dbColumn{
+		"id":         {Name: "id", DataType: "bigint", HasDefault: true},
+		"user_id":    {Name: "user_id", DataType: "bigint"},
+		"bio":        {Name: "bio", DataType: "text", Nullable: true},
+		"created_at": {Name: "created_at", DataType: "timestamp without time zone", HasDefault: true},
+		"updated_at": {Name: "updated_at", DataType: "timestamp with time zone", HasDefault: true},
+		"legacy":     {Name: "legacy", DataType: "integer", Nullable: true},
+	}
+
+	var got []string
+	for _, issue := range compareColumns(table, columns) {
+		got = append(got, issue.Column+" "+issue.Kind)
+	}
+
+	assert.Equal(t, []string{
+		"bio " + IssueNullability,
+		"avatar_url " + IssueMissingColumn,
+		"created_at " + IssueTypeMismatch,
+		"legacy " + IssueUnmappedColumn,
+	}, got)
+}
+
+func TestHasLeadingIndex(t *testing.T) {
+	indexes := []string{"id", "user_id,created_at"}
+
+	assert.True(t, hasLeadingIndex(indexes, "user_id"))
+	assert.True(t, hasLeadingIndex(indexes, "id"))
+	assert.False(t, hasLeadingIndex(indexes, "created_at"))
+	assert.False(t, hasLeadingIndex(indexes, "user"))
+}
@@ -0,0 +1,145 @@
+package db
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"regexp"
+	"strings"
+	"time"
+
+	"go02/packages/logging"
+
+	"github.com/uptrace/bun"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// explainTimeout bounds EXPLAIN ANALYZE, which runs the slow query once more
+// while the caller waits for it.
+const explainTimeout = 5 * time.Second
+
+type explainKey struct{}
+
+type slowQueryHook struct {
+	threshold time.Duration
+	explain   bool
+
+	// one plan at a time; slow queries arriving meanwhile are logged without
+	explaining chan struct{}
+	explainFn  func(ctx context.Context, db bun.IDB, query string) (string, error)
+}
+
+var _ bun.QueryHook = (*slowQueryHook)(nil)
+
+func newSlowQueryHook(threshold time.Duration, explain bool) *slowQueryHook {
+	return &slowQueryHook{
+		threshold:  threshold,
+		explain:    explain,
+		explaining: make(chan struct{}, 1),
+		explainFn:  explainQuery,
+	}
+}
+
+func (h *slowQueryHook) BeforeQuery(ctx context.Context, event *bun.QueryEvent) context.Context {
+	return ctx
+}
+
+func (h *slowQueryHook) AfterQuery(ctx context.Context, event *bun.QueryEvent) {
+	if ctx.Value(explainKey{}) != nil {
+		return
+	}
+
+	duration := time.Since(event.StartTime)
+	if duration < h.threshold {
+		return
+	}
+
+	query := SanitizeQuery(event.Query)
+	attrs := []any{
+		slog.String("query", query),
+		slog.String("db.operation", queryOperation(event)),
+		slog.String("db.sql.table", queryTable(event)),
+		slog.Duration("duration", duration),
+		slog.Duration("threshold", h.threshold),
+	}
+
+	// the tracing hook runs after this one and hasn't ended the span yet
+	span := trace.SpanFromContext(ctx)
+	span.SetAttributes(attribute.Bool("db.slow_query", true))
+
+	if h.explain && event.Err == nil && explainable(event) {
+		attrs = append(attrs, h.explainAttrs(ctx, span, GetTxOrDB(ctx, event.DB), event.Query)...)
+	}
+
+	logging.Warn(ctx, "slow query", attrs...)
+}
+
+// explainAttrs reads the plan of the query on the caller's transaction or
+// connection, so it sees the same rows, and adds it to the span. It returns
+// nothing while another plan is still being read.
+func (h *slowQueryHook) explainAttrs(ctx context.Context, span trace.Span, db bun.IDB, query string) []any {
+	select {
+	case h.explaining <- struct{}{}:
+	default:
+		return nil
+	}
+	defer func() { <-h.explaining }()
+
+	plan, err := h.explainFn(ctx, db, query)
+	if err != nil {
+		return []any{slog.String("explain_error", err.Error())}
+	}
+
+	span.SetAttributes(attribute.String("db.query.plan", plan))
+	return []any{slog.String("plan", plan)}
+}
+
+var lockingClause = regexp.MustCompile(`(?i)\bFOR\s+(NO\s+KEY\s+UPDATE|UPDATE|KEY\s+SHARE|SHARE)\b`)
+
+// explainable reports whether the query may be run again for a plan. Only
+// reads are, and not those locking rows, which would take the locks again.
+func explainable(event *bun.QueryEvent) bool {
+	return queryOperation(event) == "select" && !lockingClause.MatchString(event.Query)
+}
+
+// explainQuery reads the actual plan with EXPLAIN (ANALYZE, BUFFERS). ANALYZE
+// runs the statement again, so it is wrapped in a transaction, or a savepoint
+// of the caller's one, that is always rolled back.
+func explainQuery(ctx context.Context, db bun.IDB, query string) (plan string, err error) {
+	ctx = context.WithValue(ctx, explainKey{}, struct{}{})
+
+	tx, err := db.BeginTx(ctx, nil)
+	if err != nil {
+		return "", fmt.Errorf("begin: %w", err)
+	}
+	defer func() {
+		if rbErr := tx.Rollback(); rbErr != nil && err == nil {
+			err = fmt.Errorf("rollback: %w", rbErr)
+		}
+	}()
+
+	if _, err := tx.ExecContext(ctx, fmt.Sprintf("SET LOCAL statement_timeout = %d", explainTimeout.Milliseconds())); err != nil {
+		return "", fmt.Errorf("set statement_timeout: %w", err)
+	}
+
+	rows, err := tx.QueryContext(ctx, "EXPLAIN (ANALYZE, BUFFERS) "+query)
+	if err != nil {
+		return "", fmt.Errorf("explain: %w", err)
+	}
+	defer rows.Close()
+
+	var lines []string
+	for rows.Next() {
+		var line string
+		if err := rows.Scan(&line); err != nil {
+			return "", fmt.Errorf("scan plan: %w", err)
+		}
+		lines = append(lines, line)
+	}
+	if err := rows.Err(); err != nil {
+		return "", fmt.Errorf("read plan: %w", err)
+	}
+
+	return strings.Join(lines, "\n"), nil
+}
@@ -0,0 +1,107 @@
+package db
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"log/slog"
+	"testing"
+	"time"
+
+	"go02/packages/logging"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/uptrace/bun"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+)
+
+func TestExplainable(t *testing.T) {
+	tests := []struct {
+		query string
+		want  bool
+	}{
+		{`SELECT "u"."id" FROM "users" AS "u" WHERE (id = 1)`, true},
+		{`SELECT "c"."id" FROM "credentials" AS "c" WHERE (user_id = 1) FOR UPDATE`, false},
+		{`SELECT * FROM sessions WHERE id = 1 for no key update`, false},
+		{`SELECT * FROM groups FOR SHARE`, false},
+		{`UPDATE "users" SET name = 'x' WHERE id = 1`, false},
+		{`DELETE FROM "users" WHERE id = 1`, false},
+	}
+
+	for _, tt := range tests {
+		assert.Equal(t, tt.want, explainable(&bun.QueryEvent{Query: tt.query}), tt.query)
+	}
+}
+
+func TestSlowQueryHookExplains(t *testing.T) {
+	hook := newSlowQueryHook(time.Millisecond, true)
+	var explained []string
+	hook.explainFn = func(ctx context.Context, _ bun.IDB, query string) (string, error) {
+		explained = append(explained, query)
+		return "Seq Scan on users (actual time=0.01..250.00 rows=1 loops=1)", nil
+	}
+
+	recorder := tracetest.NewSpanRecorder()
+	tracer := sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(recorder)).Tracer("test")
+
+	slow := func(query string) (map[string]any, sdktrace.ReadOnlySpan) {
+		buf := &bytes.Buffer{}
+		ctx := logging.NewContext(context.Background(), slog.New(slog.NewJSONHandler(buf, nil)))
+		ctx, span := tracer.Start(ctx, "query")
+		hook.AfterQuery(ctx, &bun.QueryEvent{Query: query, StartTime: time.Now().Add(-time.Second)})
+		span.End()
+
+		var record map[string]any
+		require.NoError(t, json.Unmarshal(buf.Bytes(), &record))
+		spans := recorder.Ended()
+		return record, spans[len(spans)-1]
+	}
+	planAttr := func(span sdktrace.ReadOnlySpan) (string, bool) {
+		for _, attr := range span.Attributes() {
+			if attr.Key == "db.query.plan" {
+				return attr.Value.AsString(), true
+			}
+		}
+		return "", false
+	}
+
+	t.Run("正常系: プランをログとスパンに付ける", func(t *testing.T) {
+		record, span := slow(`SELECT * FROM users`)
+		assert.Equal(t, "slow query", record["msg"])
+		assert.Contains(t, record["plan"], "Seq Scan on users")
+		plan, ok := planAttr(span)
+		assert.True(t, ok)
+		assert.Contains(t, plan, "Seq Scan on users")
+	})
+
+	t.Run("正常系: 行をロックする読み込みは実行し直さない", func(t *testing.T) {
+		explained = nil
+		record, span := slow(`SELECT * FROM credentials FOR UPDATE`)
+		assert.Empty(t, explained)
+		assert.NotContains(t, record, "plan")
+		_, ok := planAttr(span)
+		assert.False(t, ok)
+	})
+
+	t.Run("正常系: 他のプランを読んでいる間はプランなしで記録する", func(t *testing.T) {
+		explained = nil
+		hook.explaining <- struct{}{}
+		record, _ := slow(`SELECT * FROM profiles`)
+		<-hook.explaining
+		assert.Empty(t, explained)
+		assert.Equal(t, "slow query", record["msg"])
+		assert.NotContains(t, record, "plan")
+	})
+}
+
+func TestSlowQueryHookSkipsFastQueries(t *testing.T) {
+	hook := newSlowQueryHook(time.Hour, true)
+	hook.explainFn = func(context.Context, bun.IDB, string) (string, error) {
+		t.Fatal("explained a fast query")
+		return "", nil
+	}
+
+	hook.AfterQuery(context.Background(), &bun.QueryEvent{Query: `SELECT 1`, StartTime: time.Now()})
+}
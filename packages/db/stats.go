@@ -0,0 +1,54 @@
+package db
+
+import (
+	"expvar"
+	"sync"
+
+	"github.com/uptrace/bun"
+)
+
+var publishMu sync.Mutex
+
+type Stats struct {
+	MaxOpenConnections int   `json:"max_open_connections"`
+	OpenConnections    int   `json:"open_connections"`
+	InUse              int   `json:"in_use"`
+	Idle               int   `json:"idle"`
+	WaitCount          int64 `json:"wait_count"`
+	WaitDurationMs     int64 `json:"wait_duration_ms"`
+	MaxIdleClosed      int64 `json:"max_idle_closed"`
+	MaxIdleTimeClosed  int64 `json:"max_idle_time_closed"`
+	MaxLifetimeClosed  int64 `json:"max_lifetime_closed"`
+}
+
+// GetStats returns a snapshot of the connection pool statistics.
+func GetStats(db *bun.DB) Stats {
+	s := db.DB.Stats()
+
+	return Stats{
+		MaxOpenConnections: s.MaxOpenConnections,
+		OpenConnections:    s.OpenConnections,
+		InUse:              s.InUse,
+		Idle:               s.Idle,
+		WaitCount:          s.WaitCount,
+		WaitDurationMs:     s.WaitDuration.Milliseconds(),
+		MaxIdleClosed:      s.MaxIdleClosed,
+		MaxIdleTimeClosed:  s.MaxIdleTimeClosed,
+		MaxLifetimeClosed:  s.MaxLifetimeClosed,
+	}
+}
+
+// PublishStats exposes the connection pool statistics as the "db" expvar.
+// expvar is process wide, so when several instances run in one process only
+// the first database is published.
+func PublishStats(db *bun.DB) {
+	publishMu.Lock()
+	defer publishMu.Unlock()
+
+	if expvar.Get("db") != nil {
+		return
+	}
+	expvar.Publish("db", expvar.Func(func() any {
+		return GetStats(db)
+	}))
+}
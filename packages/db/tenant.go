@@ -0,0 +1,120 @@
+package db
+
+import (
+	"context"
+	"database/sql/driver"
+	"errors"
+	"fmt"
+	"strconv"
+
+	"github.com/uptrace/bun"
+)
+
+// TenantColumn is the column of tenant tables that holds the organization.
+const TenantColumn = "org_id"
+
+var (
+	// ErrNoTenant is a query on a tenant table with a context that neither
+	// names a tenant nor opted out with WithoutTenant.
+	ErrNoTenant = errors.New("db: query on a tenant table without a tenant")
+	// ErrCrossTenant is a write of a row that belongs to another tenant.
+	ErrCrossTenant = errors.New("db: row belongs to another tenant")
+)
+
+type (
+	tenantKey   struct{}
+	noTenantKey struct{}
+)
+
+// WithTenant scopes the queries made with ctx on tenant tables to the
+// organization.
+func WithTenant(ctx context.Context, orgID int) context.Context {
+	return context.WithValue(ctx, tenantKey{}, orgID)
+}
+
+// TenantFromContext returns the organization set by WithTenant.
+func TenantFromContext(ctx context.Context) (int, bool) {
+	orgID, ok := ctx.Value(tenantKey{}).(int)
+	return orgID, ok
+}
+
+// WithoutTenant lets the queries made with ctx reach every organization, for
+// work that isn't done on behalf of one, e.g. background jobs.
+func WithoutTenant(ctx context.Context) context.Context {
+	return context.WithValue(ctx, noTenantKey{}, true)
+}
+
+// Tenant returns the organization to scope queries to. scoped is false for a
+// context from WithoutTenant; a context without either is ErrNoTenant.
+func Tenant(ctx context.Context) (orgID int, scoped bool, err error) {
+	if orgID, ok := TenantFromContext(ctx); ok {
+		return orgID, true, nil
+	}
+	if unscoped, _ := ctx.Value(noTenantKey{}).(bool); unscoped {
+		return 0, false, nil
+	}
+	return 0, false, ErrNoTenant
+}
+
+// ScopeToTenant adds the tenant filter to a select, update or delete. It is
+// called from the query hooks of tenant models, so repositories can't forget
+// it.
+func ScopeToTenant(ctx context.Context, q bun.QueryBuilder) error {
+	orgID, scoped, err := Tenant(ctx)
+	if err != nil || !scoped {
+		return err
+	}
+
+	q.Where("?TableAlias.? = ?", bun.Ident(TenantColumn), orgID)
+	return nil
+}
+
+// AssignTenant checks the organization of a row that is about to be written
+// and fills it in for new rows.
+func AssignTenant(ctx context.Context, orgID *int) error {
+	tenant, scoped, err := Tenant(ctx)
+	if err != nil || !scoped {
+		return err
+	}
+
+	switch *orgID {
+	case 0:
+		*orgID = tenant
+	case tenant:
+	default:
+		return fmt.Errorf("%w: %d, not %d", ErrCrossTenant, *orgID, tenant)
+	}
+	return nil
+}
+
+// tenantSetting is read by the row level security policies.
+const tenantSetting = "app.org_id"
+
+// WithTenantConn runs fn on a connection of its own with app.org_id set to the
+// tenant of ctx, so that the row level security policies let its queries
+// through. Repositories pick the connection up with GetTxOrDB.
+func WithTenantConn(ctx context.Context, db *bun.DB, fn func(ctx context.Context) error) (err error) {
+	orgID, ok := TenantFromContext(ctx)
+	if !ok {
+		return fn(ctx)
+	}
+
+	conn, err := db.Conn(ctx)
+	if err != nil {
+		return err
+	}
+	defer conn.Close()
+
+	if _, err := conn.ExecContext(ctx, "SELECT set_config(?, ?, false)", tenantSetting, strconv.Itoa(orgID)); err != nil {
+		return err
+	}
+	defer func() {
+		// the connection goes back to the pool and serves other tenants next
+		if _, resetErr := conn.ExecContext(context.WithoutCancel(ctx), "RESET "+tenantSetting); resetErr != nil {
+			_ = conn.Raw(func(any) error { return driver.ErrBadConn })
+			err = errors.Join(err, resetErr)
+		}
+	}()
+
+	return fn(setConn(ctx, conn))
+}
@@ -0,0 +1,50 @@
+package db
+
+import (
+	"context"
+	"database/sql"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/uptrace/bun"
+	"github.com/uptrace/bun/dialect/pgdialect"
+)
+
+type tenantRow struct {
+	bun.BaseModel `bun:"table:rows,alias:r"`
+
+	ID    int `bun:",pk"`
+	OrgID int `bun:"org_id"`
+}
+
+func TestScopeToTenant(t *testing.T) {
+	db := bun.NewDB(&sql.DB{}, pgdialect.New())
+	ctx := context.Background()
+
+	q := db.NewSelect().Model((*tenantRow)(nil)).Where("id = 1")
+	require.NoError(t, ScopeToTenant(WithTenant(ctx, 2), q.QueryBuilder()))
+	assert.Equal(t, `SELECT "r"."id", "r"."org_id" FROM "rows" AS "r" WHERE (id = 1) AND ("r"."org_id" = 2)`, q.String())
+
+	q = db.NewSelect().Model((*tenantRow)(nil))
+	require.NoError(t, ScopeToTenant(WithoutTenant(ctx), q.QueryBuilder()))
+	assert.NotContains(t, q.String(), "WHERE")
+
+	assert.ErrorIs(t, ScopeToTenant(ctx, db.NewDelete().Model((*tenantRow)(nil)).QueryBuilder()), ErrNoTenant)
+}
+
+func TestAssignTenant(t *testing.T) {
+	ctx := WithTenant(context.Background(), 2)
+
+	orgID := 0
+	require.NoError(t, AssignTenant(ctx, &orgID))
+	assert.Equal(t, 2, orgID)
+
+	require.NoError(t, AssignTenant(ctx, &orgID))
+
+	orgID = 3
+	assert.ErrorIs(t, AssignTenant(ctx, &orgID), ErrCrossTenant)
+	require.NoError(t, AssignTenant(WithoutTenant(context.Background()), &orgID))
+
+	assert.ErrorIs(t, AssignTenant(context.Background(), &orgID), ErrNoTenant)
+}
@@ -0,0 +1,67 @@
+package db
+
+import (
+	"context"
+	"strings"
+
+	"github.com/uptrace/bun"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	semconv "go.opentelemetry.io/otel/semconv/v1.9.0"
+	"go.opentelemetry.io/otel/trace"
+)
+
+type tracingQueryHook struct {
+	tracer           trace.Tracer
+	captureStatement bool
+}
+
+var _ bun.QueryHook = (*tracingQueryHook)(nil)
+
+func newTracingQueryHook(tp trace.TracerProvider, captureStatement bool) *tracingQueryHook {
+	return &tracingQueryHook{
+		tracer:           tp.Tracer("go02/db"),
+		captureStatement: captureStatement,
+	}
+}
+
+func (h *tracingQueryHook) BeforeQuery(ctx context.Context, event *bun.QueryEvent) context.Context {
+	name := queryOperation(event)
+	if table := queryTable(event); table != "" {
+		name += " " + table
+	}
+
+	ctx, _ = h.tracer.Start(ctx, name, trace.WithSpanKind(trace.SpanKindClient))
+	return ctx
+}
+
+func (h *tracingQueryHook) AfterQuery(ctx context.Context, event *bun.QueryEvent) {
+	span := trace.SpanFromContext(ctx)
+	defer span.End()
+
+	if !span.IsRecording() {
+		return
+	}
+
+	attrs := []attribute.KeyValue{
+		semconv.DBSystemPostgreSQL,
+		semconv.DBOperationKey.String(strings.ToUpper(queryOperation(event))),
+	}
+	if table := queryTable(event); table != "" {
+		attrs = append(attrs, semconv.DBSQLTableKey.String(table))
+	}
+	if h.captureStatement {
+		attrs = append(attrs, semconv.DBStatementKey.String(SanitizeQuery(event.Query)))
+	}
+	if event.Result != nil {
+		if n, err := event.Result.RowsAffected(); err == nil {
+			attrs = append(attrs, attribute.Int64("db.rows_affected", n))
+		}
+	}
+	span.SetAttributes(attrs...)
+
+	if isQueryError(event.Err) {
+		span.RecordError(event.Err)
+		span.SetStatus(codes.Error, event.Err.Error())
+	}
+}
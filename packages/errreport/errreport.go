@@ -0,0 +1,125 @@
+package errreport
+
+import (
+	"context"
+	"crypto/sha1"
+	"encoding/hex"
+	"fmt"
+	"net/http"
+	"os"
+	"time"
+
+	cerrors "github.com/cockroachdb/errors"
+)
+
+// Event is a server error captured on the centralized error path.
+type Event struct {
+	Err         error
+	Fingerprint string
+	Time        time.Time
+	Status      int
+
+	Request   *http.Request
+	RequestID string
+	Principal string
+	TraceID   string
+	SpanID    string
+
+	// Suppressed is the number of events with the same fingerprint dropped by
+	// the rate limiter since the last one that was reported.
+	Suppressed int
+}
+
+type Reporter interface {
+	Report(ctx context.Context, event Event)
+	Flush(timeout time.Duration) bool
+}
+
+// Fingerprint groups errors by the type of their root cause and the place the
+// innermost stack trace was captured, so that messages carrying ids or values
+// still end up in the same group.
+func Fingerprint(err error) string {
+	key := string(cerrors.GetTypeKey(cerrors.UnwrapAll(err)))
+	if file, line, fn, ok := cerrors.GetOneLineSource(err); ok {
+		key += fmt.Sprintf("|%s:%d|%s", file, line, fn)
+	} else {
+		key += "|" + cerrors.UnwrapAll(err).Error()
+	}
+
+	sum := sha1.Sum([]byte(key))
+	return hex.EncodeToString(sum[:])[:16]
+}
+
+// sensitiveHeaders are never attached to reports.
+var sensitiveHeaders = map[string]struct{}{
+	"Authorization": {},
+	"Cookie":        {},
+	"Set-Cookie":    {},
+	"X-Api-Key":     {},
+}
+
+func requestHeaders(r *http.Request) map[string]string {
+	headers := make(map[string]string, len(r.Header))
+	for k, v := range r.Header {
+		if _, ok := sensitiveHeaders[http.CanonicalHeaderKey(k)]; ok {
+			continue
+		}
+		if len(v) > 0 {
+			headers[k] = v[0]
+		}
+	}
+	return headers
+}
+
+type nopReporter struct{}
+
+func (nopReporter) Report(context.Context, Event) {}
+func (nopReporter) Flush(time.Duration) bool      { return true }
+
+// Nop discards every event.
+func Nop() Reporter {
+	return nopReporter{}
+}
+
+const (
+	ReporterNone   = "none"
+	ReporterSentry = "sentry"
+	ReporterStdout = "stdout"
+	ReporterFile   = "file"
+)
+
+type Options struct {
+	Reporter    string
+	DSN         string
+	File        string
+	Environment string
+	Release     string
+
+	SampleRate float64
+	Burst      int
+	Window     time.Duration
+}
+
+func New(opts Options) (Reporter, error) {
+	var (
+		r   Reporter
+		err error
+	)
+	switch opts.Reporter {
+	case "", ReporterNone:
+		return Nop(), nil
+	case ReporterSentry:
+		r, err = NewSentry(opts.DSN, opts.Environment, opts.Release)
+	case ReporterStdout:
+		r = NewWriter(os.Stdout)
+	case ReporterFile:
+		r, err = NewFile(opts.File)
+	default:
+		return nil, fmt.Errorf("unknown error reporter %q", opts.Reporter)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	return Limit(r, opts.SampleRate, opts.Burst, opts.Window), nil
+}
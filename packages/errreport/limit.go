@@ -0,0 +1,103 @@
+package errreport
+
+import (
+	"context"
+	"math/rand/v2"
+	"sync"
+	"time"
+)
+
+type limiter struct {
+	next       Reporter
+	sampleRate float64
+	burst      int
+	window     time.Duration
+
+	mu      sync.Mutex
+	buckets map[string]*bucket
+}
+
+type bucket struct {
+	start      time.Time
+	reported   int
+	suppressed int
+}
+
+// Limit samples events and reports at most burst events per fingerprint in
+// each window. The number of dropped duplicates is carried on the next event
+// that gets through.
+func Limit(next Reporter, sampleRate float64, burst int, window time.Duration) Reporter {
+	return &limiter{
+		next:       next,
+		sampleRate: sampleRate,
+		burst:      burst,
+		window:     window,
+		buckets:    map[string]*bucket{},
+	}
+}
+
+// SetLimits changes the sampling and rate limits of a reporter created by
+// Limit or New. Other reporters are left untouched.
+func SetLimits(r Reporter, sampleRate float64, burst int, window time.Duration) {
+	l, ok := r.(*limiter)
+	if !ok {
+		return
+	}
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	l.sampleRate = sampleRate
+	l.burst = burst
+	l.window = window
+}
+
+func (l *limiter) Report(ctx context.Context, e Event) {
+	l.mu.Lock()
+	sampleRate := l.sampleRate
+	l.mu.Unlock()
+
+	if sampleRate < 1 && rand.Float64() >= sampleRate {
+		return
+	}
+
+	if e.Fingerprint == "" {
+		e.Fingerprint = Fingerprint(e.Err)
+	}
+
+	l.mu.Lock()
+	b, ok := l.buckets[e.Fingerprint]
+	if !ok || e.Time.Sub(b.start) >= l.window {
+		suppressed := 0
+		if ok {
+			suppressed = b.suppressed
+		}
+		b = &bucket{start: e.Time, suppressed: suppressed}
+		l.buckets[e.Fingerprint] = b
+		l.evict(e.Time)
+	}
+	if b.reported >= l.burst {
+		b.suppressed++
+		l.mu.Unlock()
+		return
+	}
+	b.reported++
+	e.Suppressed = b.suppressed
+	b.suppressed = 0
+	l.mu.Unlock()
+
+	l.next.Report(ctx, e)
+}
+
+// evict forgets fingerprints that have been quiet for a full window.
+func (l *limiter) evict(now time.Time) {
+	for fp, b := range l.buckets {
+		if now.Sub(b.start) >= 2*l.window && b.suppressed == 0 {
+			delete(l.buckets, fp)
+		}
+	}
+}
+
+func (l *limiter) Flush(timeout time.Duration) bool {
+	return l.next.Flush(timeout)
+}
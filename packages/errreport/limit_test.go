@@ -0,0 +1,39 @@
+package errreport_test
+
+import (
+	"context"
+	"errors"
+	"go02/packages/errreport"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+type recorder struct {
+	events []errreport.Event
+}
+
+func (r *recorder) Report(_ context.Context, e errreport.Event) { r.events = append(r.events, e) }
+func (r *recorder) Flush(time.Duration) bool                    { return true }
+
+func TestLimit(t *testing.T) {
+	// Arrange
+	rec := &recorder{}
+	reporter := errreport.Limit(rec, 1, 2, time.Minute)
+	now := time.Now()
+	err := errors.New("boom")
+
+	// Act
+	for i := 0; i < 5; i++ {
+		reporter.Report(context.Background(), errreport.Event{Err: err, Time: now})
+	}
+	reporter.Report(context.Background(), errreport.Event{Err: errors.New("other"), Time: now})
+	reporter.Report(context.Background(), errreport.Event{Err: err, Time: now.Add(time.Minute)})
+
+	// Assert
+	assert.Len(t, rec.events, 4)
+	assert.Equal(t, rec.events[0].Fingerprint, rec.events[1].Fingerprint)
+	assert.NotEqual(t, rec.events[0].Fingerprint, rec.events[2].Fingerprint)
+	assert.Equal(t, 3, rec.events[3].Suppressed)
+}
@@ -0,0 +1,78 @@
+package errreport
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	cerrors "github.com/cockroachdb/errors"
+	"github.com/getsentry/sentry-go"
+)
+
+type sentryReporter struct {
+	client *sentry.Client
+}
+
+// NewSentry reports to a Sentry-compatible DSN. Sampling and rate limiting are
+// applied by the caller, see Limit.
+func NewSentry(dsn string, environment string, release string) (Reporter, error) {
+	client, err := sentry.NewClient(sentry.ClientOptions{
+		Dsn:         dsn,
+		Environment: environment,
+		Release:     release,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("create sentry client: %w", err)
+	}
+
+	return &sentryReporter{
+		client: client,
+	}, nil
+}
+
+func (r *sentryReporter) Report(ctx context.Context, e Event) {
+	event, extra := cerrors.BuildSentryReport(e.Err)
+	if event == nil {
+		return
+	}
+
+	event.Level = sentry.LevelError
+	event.Timestamp = e.Time
+	event.Fingerprint = []string{e.Fingerprint}
+	for k, v := range extra {
+		event.Extra[k] = v
+	}
+	if e.Suppressed > 0 {
+		event.Extra["suppressed"] = e.Suppressed
+	}
+
+	if e.Request != nil {
+		event.Request = sentry.NewRequest(e.Request)
+		event.Request.Headers = requestHeaders(e.Request)
+		event.Request.Cookies = ""
+	}
+	if e.Principal != "" {
+		event.User = sentry.User{ID: e.Principal}
+	}
+
+	event.Tags = map[string]string{
+		"status": fmt.Sprint(e.Status),
+	}
+	if e.RequestID != "" {
+		event.Tags["request_id"] = e.RequestID
+	}
+	if e.TraceID != "" {
+		event.Contexts = map[string]sentry.Context{
+			"trace": {
+				"trace_id": e.TraceID,
+				"span_id":  e.SpanID,
+			},
+		}
+	}
+
+	r.client.CaptureEvent(event, nil, nil)
+}
+
+func (r *sentryReporter) Flush(timeout time.Duration) bool {
+	return r.client.Flush(timeout)
+}
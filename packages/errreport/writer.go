@@ -0,0 +1,91 @@
+package errreport
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"sync"
+	"time"
+
+	cerrors "github.com/cockroachdb/errors"
+)
+
+type writerReporter struct {
+	mu sync.Mutex
+	w  io.Writer
+}
+
+// NewWriter writes one JSON document per event, for offline testing.
+func NewWriter(w io.Writer) Reporter {
+	return &writerReporter{w: w}
+}
+
+// NewFile appends events to the file at path.
+func NewFile(path string) (Reporter, error) {
+	f, err := os.OpenFile(path, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0o644)
+	if err != nil {
+		return nil, fmt.Errorf("open error report file: %w", err)
+	}
+	return NewWriter(f), nil
+}
+
+type writtenEvent struct {
+	Time        time.Time         `json:"time"`
+	Fingerprint string            `json:"fingerprint"`
+	Message     string            `json:"message"`
+	Status      int               `json:"status"`
+	Suppressed  int               `json:"suppressed,omitempty"`
+	Request     *writtenRequest   `json:"request,omitempty"`
+	RequestID   string            `json:"request_id,omitempty"`
+	Principal   string            `json:"principal,omitempty"`
+	TraceID     string            `json:"trace_id,omitempty"`
+	SpanID      string            `json:"span_id,omitempty"`
+	Stacktrace  any               `json:"stacktrace,omitempty"`
+	Extra       map[string]any    `json:"extra,omitempty"`
+	Tags        map[string]string `json:"tags,omitempty"`
+}
+
+type writtenRequest struct {
+	Method  string            `json:"method"`
+	URL     string            `json:"url"`
+	Headers map[string]string `json:"headers,omitempty"`
+}
+
+func (r *writerReporter) Report(ctx context.Context, e Event) {
+	out := writtenEvent{
+		Time:        e.Time,
+		Fingerprint: e.Fingerprint,
+		Message:     e.Err.Error(),
+		Status:      e.Status,
+		Suppressed:  e.Suppressed,
+		RequestID:   e.RequestID,
+		Principal:   e.Principal,
+		TraceID:     e.TraceID,
+		SpanID:      e.SpanID,
+	}
+	if st := cerrors.GetReportableStackTrace(e.Err); st != nil {
+		out.Stacktrace = st.Frames
+	}
+	if e.Request != nil {
+		out.Request = &writtenRequest{
+			Method:  e.Request.Method,
+			URL:     e.Request.URL.String(),
+			Headers: requestHeaders(e.Request),
+		}
+	}
+
+	b, err := json.Marshal(out)
+	if err != nil {
+		return
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	_, _ = r.w.Write(append(b, '\n'))
+}
+
+func (r *writerReporter) Flush(time.Duration) bool {
+	return true
+}
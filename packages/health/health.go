@@ -0,0 +1,145 @@
+package health
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+// Kind is the kind of probe a check participates in.
+type Kind string
+
+const (
+	Liveness  Kind = "liveness"
+	Readiness Kind = "readiness"
+	Startup   Kind = "startup"
+)
+
+type Status string
+
+const (
+	StatusOK   Status = "ok"
+	StatusFail Status = "fail"
+)
+
+type CheckFunc func(ctx context.Context) error
+
+type Report struct {
+	Status Status            `json:"status"`
+	Checks map[string]Result `json:"checks,omitempty"`
+}
+
+type Result struct {
+	Status   Status `json:"status"`
+	Error    string `json:"error,omitempty"`
+	Duration string `json:"duration"`
+}
+
+type check struct {
+	name    string
+	timeout time.Duration
+	kinds   []Kind
+	fn      CheckFunc
+}
+
+// Registry holds the checks that back the health endpoints.
+type Registry struct {
+	mu     sync.RWMutex
+	checks []check
+}
+
+func NewRegistry() *Registry {
+	return &Registry{}
+}
+
+// Register adds a check that runs with its own timeout for each of the given kinds.
+func (r *Registry) Register(name string, timeout time.Duration, fn CheckFunc, kinds ...Kind) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.checks = append(r.checks, check{
+		name:    name,
+		timeout: timeout,
+		kinds:   kinds,
+		fn:      fn,
+	})
+}
+
+// Run executes every check registered for kind concurrently and aggregates the results.
+// A kind without checks is always healthy.
+func (r *Registry) Run(ctx context.Context, kind Kind) Report {
+	r.mu.RLock()
+	checks := make([]check, 0, len(r.checks))
+	for _, c := range r.checks {
+		if c.has(kind) {
+			checks = append(checks, c)
+		}
+	}
+	r.mu.RUnlock()
+
+	report := Report{
+		Status: StatusOK,
+		Checks: make(map[string]Result, len(checks)),
+	}
+
+	var (
+		mu sync.Mutex
+		wg sync.WaitGroup
+	)
+	for _, c := range checks {
+		wg.Add(1)
+		go func(c check) {
+			defer wg.Done()
+
+			result := c.run(ctx)
+
+			mu.Lock()
+			defer mu.Unlock()
+			report.Checks[c.name] = result
+			if result.Status != StatusOK {
+				report.Status = StatusFail
+			}
+		}(c)
+	}
+	wg.Wait()
+
+	return report
+}
+
+func (c check) has(kind Kind) bool {
+	for _, k := range c.kinds {
+		if k == kind {
+			return true
+		}
+	}
+	return false
+}
+
+func (c check) run(ctx context.Context) Result {
+	ctx, cancel := context.WithTimeout(ctx, c.timeout)
+	defer cancel()
+
+	start := time.Now()
+	errCh := make(chan error, 1)
+	go func() {
+		errCh <- c.fn(ctx)
+	}()
+
+	var err error
+	select {
+	case err = <-errCh:
+	case <-ctx.Done():
+		err = ctx.Err()
+	}
+
+	result := Result{
+		Status:   StatusOK,
+		Duration: time.Since(start).String(),
+	}
+	if err != nil {
+		result.Status = StatusFail
+		result.Error = err.Error()
+	}
+
+	return result
+}
@@ -0,0 +1,94 @@
+package health_test
+
+import (
+	"context"
+	"errors"
+	"go02/packages/health"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRegistryRun(t *testing.T) {
+	ok := func(ctx context.Context) error { return nil }
+	fail := func(ctx context.Context) error { return errors.New("boom") }
+	slow := func(ctx context.Context) error {
+		<-ctx.Done()
+		return ctx.Err()
+	}
+
+	tests := []struct {
+		name     string
+		register func(r *health.Registry)
+		kind     health.Kind
+		expected health.Status
+		failed   []string
+	}{
+		{
+			name:     "正常系: チェックが登録されていない場合",
+			register: func(r *health.Registry) {},
+			kind:     health.Liveness,
+			expected: health.StatusOK,
+		},
+		{
+			name: "正常系: 全てのチェックが成功する場合",
+			register: func(r *health.Registry) {
+				r.Register("a", time.Second, ok, health.Readiness)
+				r.Register("b", time.Second, ok, health.Readiness, health.Startup)
+			},
+			kind:     health.Readiness,
+			expected: health.StatusOK,
+		},
+		{
+			name: "正常系: 別の種類のチェックは実行されない場合",
+			register: func(r *health.Registry) {
+				r.Register("a", time.Second, ok, health.Readiness)
+				r.Register("b", time.Second, fail, health.Startup)
+			},
+			kind:     health.Readiness,
+			expected: health.StatusOK,
+		},
+		{
+			name: "異常系: チェックが失敗する場合",
+			register: func(r *health.Registry) {
+				r.Register("a", time.Second, ok, health.Readiness)
+				r.Register("b", time.Second, fail, health.Readiness)
+			},
+			kind:     health.Readiness,
+			expected: health.StatusFail,
+			failed:   []string{"b"},
+		},
+		{
+			name: "異常系: チェックがタイムアウトする場合",
+			register: func(r *health.Registry) {
+				r.Register("slow", 10*time.Millisecond, slow, health.Readiness)
+			},
+			kind:     health.Readiness,
+			expected: health.StatusFail,
+			failed:   []string{"slow"},
+		},
+	}
+
+	for _, tt := range tests {
+		tt := tt
+
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			// Arrange
+			r := health.NewRegistry()
+			tt.register(r)
+
+			// Act
+			report := r.Run(context.Background(), tt.kind)
+
+			// Assert
+			assert.Equal(t, tt.expected, report.Status)
+			for _, name := range tt.failed {
+				assert.Equal(t, health.StatusFail, report.Checks[name].Status)
+				assert.NotEmpty(t, report.Checks[name].Error)
+			}
+		})
+	}
+}
@@ -0,0 +1,24 @@
+package logging
+
+import (
+	"context"
+	"log/slog"
+)
+
+type loggerKey struct{}
+
+// NewContext returns a copy of ctx carrying logger, which the helpers in this
+// package log to.
+func NewContext(ctx context.Context, logger *slog.Logger) context.Context {
+	return context.WithValue(ctx, loggerKey{}, logger)
+}
+
+// FromContext returns the logger carried by ctx, or slog.Default.
+func FromContext(ctx context.Context) *slog.Logger {
+	if ctx != nil {
+		if logger, ok := ctx.Value(loggerKey{}).(*slog.Logger); ok {
+			return logger
+		}
+	}
+	return slog.Default()
+}
@@ -0,0 +1,52 @@
+package logging
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+)
+
+// fanoutHandler writes every record to all of its sinks.
+type fanoutHandler []slog.Handler
+
+func fanout(handlers []slog.Handler) slog.Handler {
+	if len(handlers) == 1 {
+		return handlers[0]
+	}
+	return fanoutHandler(handlers)
+}
+
+func (h fanoutHandler) Enabled(ctx context.Context, l slog.Level) bool {
+	for _, handler := range h {
+		if handler.Enabled(ctx, l) {
+			return true
+		}
+	}
+	return false
+}
+
+func (h fanoutHandler) Handle(ctx context.Context, record slog.Record) error {
+	var errs []error
+	for _, handler := range h {
+		if handler.Enabled(ctx, record.Level) {
+			errs = append(errs, handler.Handle(ctx, record.Clone()))
+		}
+	}
+	return errors.Join(errs...)
+}
+
+func (h fanoutHandler) WithAttrs(attrs []slog.Attr) slog.Handler {
+	handlers := make(fanoutHandler, len(h))
+	for i, handler := range h {
+		handlers[i] = handler.WithAttrs(attrs)
+	}
+	return handlers
+}
+
+func (h fanoutHandler) WithGroup(name string) slog.Handler {
+	handlers := make(fanoutHandler, len(h))
+	for i, handler := range h {
+		handlers[i] = handler.WithGroup(name)
+	}
+	return handlers
+}
@@ -0,0 +1,44 @@
+package logging
+
+import (
+	"bytes"
+	"encoding/json"
+	"log/slog"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestFanout(t *testing.T) {
+	debug, warn := &bytes.Buffer{}, &bytes.Buffer{}
+	logger := slog.New(fanout([]slog.Handler{
+		slog.NewJSONHandler(debug, &slog.HandlerOptions{Level: slog.LevelDebug}),
+		slog.NewJSONHandler(warn, &slog.HandlerOptions{Level: slog.LevelWarn}),
+	})).With("app", "go02").WithGroup("req")
+
+	logger.Info("info", "id", 1)
+	logger.Warn("warn", "id", 2)
+
+	decode := func(b []byte) map[string]any {
+		var m map[string]any
+		require.NoError(t, json.Unmarshal(b, &m))
+		return m
+	}
+
+	lines := bytes.Split(bytes.TrimSpace(debug.Bytes()), []byte("\n"))
+	require.Len(t, lines, 2)
+	assert.Equal(t, "info", decode(lines[0])["msg"])
+	assert.Equal(t, "go02", decode(lines[0])["app"])
+	assert.Equal(t, map[string]any{"id": float64(1)}, decode(lines[0])["req"])
+
+	got := decode(warn.Bytes())
+	assert.Equal(t, "warn", got["msg"])
+	assert.Equal(t, "go02", got["app"])
+	assert.Equal(t, map[string]any{"id": float64(2)}, got["req"])
+}
+
+func TestFanoutSingle(t *testing.T) {
+	h := slog.NewJSONHandler(&bytes.Buffer{}, nil)
+	assert.Same(t, h, fanout([]slog.Handler{h}))
+}
@@ -0,0 +1,30 @@
+package logging
+
+import (
+	"context"
+	"log/slog"
+	"strings"
+)
+
+type levelKey struct{}
+
+// ParseLevel parses a level name such as "debug" or "WARN".
+func ParseLevel(s string) (slog.Level, error) {
+	var l slog.Level
+	err := l.UnmarshalText([]byte(strings.TrimSpace(s)))
+	return l, err
+}
+
+// WithLevel lowers the minimum level for records logged with the returned
+// context, e.g. to turn on debug logs for a single request.
+func WithLevel(ctx context.Context, l slog.Level) context.Context {
+	return context.WithValue(ctx, levelKey{}, l)
+}
+
+func levelFromContext(ctx context.Context) (slog.Level, bool) {
+	if ctx == nil {
+		return 0, false
+	}
+	l, ok := ctx.Value(levelKey{}).(slog.Level)
+	return l, ok
+}
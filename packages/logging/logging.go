@@ -4,6 +4,7 @@ import (
 	"context"
 	"fmt"
 	"go02/packages/apperrors"
+	"go02/packages/requestid"
 	"log/slog"
 	"os"
 	"runtime"
@@ -12,71 +13,190 @@ import (
 	"go.opentelemetry.io/otel/trace"
 )
 
-func Init() {
-	jsonHandler := slog.NewJSONHandler(os.Stdout, &slog.HandlerOptions{
-		AddSource:   true,
-		ReplaceAttr: replacer,
-	})
-	instrumentedHandler := handlerWithSpanContext(jsonHandler)
-	slog.SetDefault(slog.New(instrumentedHandler))
+type Options struct {
+	Level         string
+	Redaction     string
+	RedactHashKey string
+
+	// Format of the stdout sink: json, text or pretty.
+	Format string
+	// Profile selects the field mapping: gcp or plain.
+	Profile string
+
+	// File enables an additional JSON sink rotated by size.
+	File           string
+	FileMaxSizeMB  int
+	FileMaxBackups int
+}
+
+// Logger is the logger of one application instance together with its
+// runtime level.
+type Logger struct {
+	*slog.Logger
+	level *slog.LevelVar
+	file  *rotatingFile
+}
+
+// New builds a logger from opts. It doesn't touch slog.Default; pass the
+// logger on with NewContext so that the helpers in this package find it.
+func New(opts Options) (*Logger, error) {
+	l, err := ParseLevel(opts.Level)
+	if err != nil {
+		return nil, fmt.Errorf("parse log level %q: %w", opts.Level, err)
+	}
+	level := new(slog.LevelVar)
+	level.Set(l)
+
+	redactor, err := NewRedactor(opts.Redaction, opts.RedactHashKey)
+	if err != nil {
+		return nil, err
+	}
+
+	profileName := opts.Profile
+	if profileName == "" && opts.Format != "" && opts.Format != FormatJSON {
+		profileName = ProfilePlain
+	}
+	p, err := profileByName(profileName)
+	if err != nil {
+		return nil, err
+	}
+
+	handlerOpts := &slog.HandlerOptions{
+		AddSource: true,
+		Level:     slog.LevelDebug,
+		ReplaceAttr: func(groups []string, a slog.Attr) slog.Attr {
+			return p.replace(groups, redactor.ReplaceAttr(groups, a))
+		},
+	}
+
+	stdout, err := newHandler(opts.Format, os.Stdout, handlerOpts)
+	if err != nil {
+		return nil, err
+	}
+	sinks := []slog.Handler{stdout}
+
+	var file *rotatingFile
+	if opts.File != "" {
+		file, err = newRotatingFile(opts.File, opts.FileMaxSizeMB, opts.FileMaxBackups)
+		if err != nil {
+			return nil, fmt.Errorf("open log file: %w", err)
+		}
+		sinks = append(sinks, slog.NewJSONHandler(file, handlerOpts))
+	}
+
+	instrumentedHandler := handlerWithSpanContext(fanout(sinks), p, level)
+
+	return &Logger{
+		Logger: slog.New(instrumentedHandler),
+		level:  level,
+		file:   file,
+	}, nil
+}
+
+// Close closes the log file, if any. Records logged afterwards only reach
+// stdout.
+func (l *Logger) Close() error {
+	if l.file == nil {
+		return nil
+	}
+	return l.file.Close()
+}
+
+// SetLevel changes the minimum level at runtime.
+func (l *Logger) SetLevel(level slog.Level) {
+	l.level.Set(level)
+}
+
+func (l *Logger) Level() slog.Level {
+	return l.level.Level()
+}
+
+func newHandler(format string, w *os.File, opts *slog.HandlerOptions) (slog.Handler, error) {
+	switch format {
+	case "", FormatJSON:
+		return slog.NewJSONHandler(w, opts), nil
+	case FormatText:
+		return slog.NewTextHandler(w, opts), nil
+	case FormatPretty:
+		return newPrettyHandler(w, opts, useColor(w)), nil
+	default:
+		return nil, fmt.Errorf("unknown log format %q", format)
+	}
 }
 
-func handlerWithSpanContext(handler slog.Handler) *spanContextLogHandler {
-	return &spanContextLogHandler{Handler: handler}
+func handlerWithSpanContext(handler slog.Handler, p profile, level *slog.LevelVar) *spanContextLogHandler {
+	return &spanContextLogHandler{Handler: handler, profile: p, level: level}
 }
 
 type spanContextLogHandler struct {
 	slog.Handler
+	profile profile
+	level   *slog.LevelVar
+}
+
+// Enabled honours a level elevated for the request before the logger's one.
+func (t *spanContextLogHandler) Enabled(ctx context.Context, l slog.Level) bool {
+	if floor, ok := levelFromContext(ctx); ok && l >= floor {
+		return true
+	}
+	return l >= t.level.Level()
+}
+
+func (t *spanContextLogHandler) WithAttrs(attrs []slog.Attr) slog.Handler {
+	return handlerWithSpanContext(t.Handler.WithAttrs(attrs), t.profile, t.level)
+}
+
+func (t *spanContextLogHandler) WithGroup(name string) slog.Handler {
+	return handlerWithSpanContext(t.Handler.WithGroup(name), t.profile, t.level)
 }
 
 func (t *spanContextLogHandler) Handle(ctx context.Context, record slog.Record) error {
 	if s := trace.SpanContextFromContext(ctx); s.IsValid() {
 		record.AddAttrs(
-			slog.Any("logging.googleapis.com/trace", s.TraceID()),
+			slog.Any(t.profile.traceKey, s.TraceID()),
 		)
 		record.AddAttrs(
-			slog.Any("logging.googleapis.com/spanId", s.SpanID()),
+			slog.Any(t.profile.spanKey, s.SpanID()),
 		)
 		record.AddAttrs(
-			slog.Bool("logging.googleapis.com/trace_sampled", s.TraceFlags().IsSampled()),
+			slog.Bool(t.profile.sampledKey, s.TraceFlags().IsSampled()),
+		)
+	}
+	if id := requestid.FromContext(ctx); id != "" {
+		record.AddAttrs(
+			slog.String("request_id", id),
 		)
 	}
 	return t.Handler.Handle(ctx, record)
 }
 
-func replacer(groups []string, a slog.Attr) slog.Attr {
-	switch a.Key {
-	case slog.LevelKey:
-		a.Key = "severity"
-		if level := a.Value.Any().(slog.Level); level == slog.LevelWarn {
-			a.Value = slog.StringValue("WARNING")
-		}
-	case slog.TimeKey:
-		a.Key = "timestamp"
-	case slog.MessageKey:
-		a.Key = "message"
-	case slog.SourceKey:
-		a.Key = "logging.googleapis.com/sourceLocation"
-	}
+func log(ctx context.Context, level slog.Level, msg string, args ...any) {
+	var pcs [1]uintptr
+	runtime.Callers(3, pcs[:])
 
-	return a
+	logAt(ctx, level, pcs[0], msg, args...)
 }
 
-func log(ctx context.Context, level slog.Level, msg string, args ...any) {
-	logger := slog.Default()
+func logAt(ctx context.Context, level slog.Level, pc uintptr, msg string, args ...any) {
+	logger := FromContext(ctx)
 	if !logger.Enabled(ctx, level) {
 		return
 	}
 
-	var pcs [1]uintptr
-	runtime.Callers(3, pcs[:])
-
-	r := slog.NewRecord(time.Now(), level, msg, pcs[0])
+	r := slog.NewRecord(time.Now(), level, msg, pc)
 	r.Add(args...)
 
 	_ = logger.Handler().Handle(ctx, r)
 }
 
+func Debug(ctx context.Context, msg string, args ...any) {
+	log(ctx, slog.LevelDebug, msg, args...)
+}
+
+func Debugf(ctx context.Context, format string, args ...any) {
+	log(ctx, slog.LevelDebug, fmt.Sprintf(format, args...))
+}
+
 func Info(ctx context.Context, msg string, args ...any) {
 	log(ctx, slog.LevelInfo, msg, args...)
 }
@@ -85,6 +205,14 @@ func Infof(ctx context.Context, format string, args ...any) {
 	log(ctx, slog.LevelInfo, fmt.Sprintf(format, args...))
 }
 
+func Warn(ctx context.Context, msg string, args ...any) {
+	log(ctx, slog.LevelWarn, msg, args...)
+}
+
+func Warnf(ctx context.Context, format string, args ...any) {
+	log(ctx, slog.LevelWarn, fmt.Sprintf(format, args...))
+}
+
 func Error(ctx context.Context, err error, msg string, args ...any) {
 	args = append(args, apperrors.LogStackTrace(err))
 	log(ctx, slog.LevelError, msg, args...)
@@ -93,3 +221,10 @@ func Error(ctx context.Context, err error, msg string, args ...any) {
 func Errorf(ctx context.Context, err error, format string, args ...any) {
 	log(ctx, slog.LevelError, fmt.Sprintf(format, args...), apperrors.LogStackTrace(err))
 }
+
+// ErrorAt is like Error but reports pc as the source location, e.g. the
+// place a recovered panic was raised.
+func ErrorAt(ctx context.Context, pc uintptr, err error, msg string, args ...any) {
+	args = append(args, apperrors.LogStackTrace(err))
+	logAt(ctx, slog.LevelError, pc, msg, args...)
+}
@@ -0,0 +1,212 @@
+package logging
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	FormatJSON   = "json"
+	FormatText   = "text"
+	FormatPretty = "pretty"
+)
+
+const (
+	ansiReset   = "\033[0m"
+	ansiBold    = "\033[1m"
+	ansiDim     = "\033[2m"
+	ansiRed     = "\033[31m"
+	ansiGreen   = "\033[32m"
+	ansiYellow  = "\033[33m"
+	ansiMagenta = "\033[35m"
+	ansiCyan    = "\033[36m"
+)
+
+// prettyHandler writes one human-readable line per record for local
+// development:
+//
+//	12:04:05.123 INFO  success to get user list users=[...] (usecase/user_usecase.go:171)
+type prettyHandler struct {
+	w      io.Writer
+	mu     *sync.Mutex
+	opts   slog.HandlerOptions
+	color  bool
+	groups []string
+	attrs  []byte
+}
+
+func newPrettyHandler(w io.Writer, opts *slog.HandlerOptions, color bool) *prettyHandler {
+	h := &prettyHandler{
+		w:     w,
+		mu:    &sync.Mutex{},
+		color: color,
+	}
+	if opts != nil {
+		h.opts = *opts
+	}
+	return h
+}
+
+// useColor follows the NO_COLOR convention and only colors terminals.
+func useColor(f *os.File) bool {
+	if os.Getenv("NO_COLOR") != "" {
+		return false
+	}
+	info, err := f.Stat()
+	if err != nil {
+		return false
+	}
+	return info.Mode()&os.ModeCharDevice != 0
+}
+
+func (h *prettyHandler) Enabled(_ context.Context, l slog.Level) bool {
+	minLevel := slog.LevelInfo
+	if h.opts.Level != nil {
+		minLevel = h.opts.Level.Level()
+	}
+	return l >= minLevel
+}
+
+func (h *prettyHandler) WithAttrs(attrs []slog.Attr) slog.Handler {
+	h2 := *h
+	buf := bytes.NewBuffer(append([]byte{}, h.attrs...))
+	for _, a := range attrs {
+		h2.appendAttr(buf, h.groups, a)
+	}
+	h2.attrs = buf.Bytes()
+	return &h2
+}
+
+func (h *prettyHandler) WithGroup(name string) slog.Handler {
+	if name == "" {
+		return h
+	}
+	h2 := *h
+	h2.groups = append(append([]string{}, h.groups...), name)
+	return &h2
+}
+
+func (h *prettyHandler) Handle(_ context.Context, r slog.Record) error {
+	buf := &bytes.Buffer{}
+
+	h.paint(buf, ansiDim, r.Time.Format("15:04:05.000"))
+	buf.WriteByte(' ')
+	color, label := levelStyle(r.Level)
+	h.paint(buf, color, fmt.Sprintf("%-5s", label))
+	buf.WriteByte(' ')
+	h.paint(buf, ansiBold, r.Message)
+
+	buf.Write(h.attrs)
+	r.Attrs(func(a slog.Attr) bool {
+		h.appendAttr(buf, h.groups, a)
+		return true
+	})
+
+	if h.opts.AddSource && r.PC != 0 {
+		frame, _ := runtime.CallersFrames([]uintptr{r.PC}).Next()
+		if frame.File != "" {
+			buf.WriteByte(' ')
+			h.paint(buf, ansiDim, fmt.Sprintf("(%s/%s:%d)", filepath.Base(filepath.Dir(frame.File)), filepath.Base(frame.File), frame.Line))
+		}
+	}
+	buf.WriteByte('\n')
+
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	_, err := h.w.Write(buf.Bytes())
+	return err
+}
+
+func (h *prettyHandler) appendAttr(buf *bytes.Buffer, groups []string, a slog.Attr) {
+	a.Value = a.Value.Resolve()
+	if h.opts.ReplaceAttr != nil && a.Value.Kind() != slog.KindGroup {
+		a = h.opts.ReplaceAttr(groups, a)
+		a.Value = a.Value.Resolve()
+	}
+	if a.Equal(slog.Attr{}) {
+		return
+	}
+
+	if a.Value.Kind() == slog.KindGroup {
+		if a.Key != "" {
+			groups = append(append([]string{}, groups...), a.Key)
+		}
+		for _, ga := range a.Value.Group() {
+			h.appendAttr(buf, groups, ga)
+		}
+		return
+	}
+
+	key := a.Key
+	if len(groups) > 0 {
+		key = strings.Join(groups, ".") + "." + key
+	}
+
+	buf.WriteByte(' ')
+	h.paint(buf, ansiCyan, key)
+	buf.WriteByte('=')
+	buf.WriteString(formatValue(a.Value))
+}
+
+func (h *prettyHandler) paint(buf *bytes.Buffer, color string, s string) {
+	if !h.color {
+		buf.WriteString(s)
+		return
+	}
+	buf.WriteString(color)
+	buf.WriteString(s)
+	buf.WriteString(ansiReset)
+}
+
+func levelStyle(l slog.Level) (string, string) {
+	switch {
+	case l >= slog.LevelError:
+		return ansiRed, l.String()
+	case l >= slog.LevelWarn:
+		return ansiYellow, l.String()
+	case l >= slog.LevelInfo:
+		return ansiGreen, l.String()
+	default:
+		return ansiMagenta, l.String()
+	}
+}
+
+func formatValue(v slog.Value) string {
+	switch v.Kind() {
+	case slog.KindString:
+		return quoteIfNeeded(v.String())
+	case slog.KindTime:
+		return v.Time().Format(time.RFC3339Nano)
+	case slog.KindAny:
+		switch a := v.Any().(type) {
+		case error:
+			return quoteIfNeeded(a.Error())
+		case fmt.Stringer:
+			return quoteIfNeeded(a.String())
+		}
+		if b, err := json.Marshal(v.Any()); err == nil {
+			return string(b)
+		}
+		return quoteIfNeeded(fmt.Sprint(v.Any()))
+	default:
+		return v.String()
+	}
+}
+
+func quoteIfNeeded(s string) string {
+	if s == "" || strings.ContainsAny(s, " =\"\t\n") {
+		return strconv.Quote(s)
+	}
+	return s
+}
@@ -0,0 +1,62 @@
+package logging
+
+import (
+	"bytes"
+	"errors"
+	"log/slog"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestPrettyHandler(t *testing.T) {
+	// drops the time, which is the first 13 bytes of a line
+	line := func(buf *bytes.Buffer) string {
+		return strings.TrimSuffix(buf.String()[13:], "\n")
+	}
+
+	t.Run("正常系: 属性とグループを1行で書く", func(t *testing.T) {
+		buf := &bytes.Buffer{}
+		logger := slog.New(newPrettyHandler(buf, nil, false))
+
+		logger.With("user_id", 1).WithGroup("req").Info("hello world", "path", "/a b", "err", errors.New("boom"))
+
+		assert.Equal(t, `INFO  hello world user_id=1 req.path="/a b" req.err=boom`, line(buf))
+	})
+
+	t.Run("正常系: ReplaceAttrを通す", func(t *testing.T) {
+		buf := &bytes.Buffer{}
+		logger := slog.New(newPrettyHandler(buf, &slog.HandlerOptions{
+			ReplaceAttr: func(_ []string, a slog.Attr) slog.Attr {
+				if a.Key == "password" {
+					return slog.Attr{}
+				}
+				return a
+			},
+		}, false))
+
+		logger.Warn("login", "password", "secret", "ok", false)
+
+		assert.Equal(t, `WARN  login ok=false`, line(buf))
+	})
+
+	t.Run("正常系: レベル未満は書かない", func(t *testing.T) {
+		buf := &bytes.Buffer{}
+		logger := slog.New(newPrettyHandler(buf, nil, false))
+
+		logger.Debug("hidden")
+
+		assert.Empty(t, buf.String())
+	})
+
+	t.Run("正常系: 色付き", func(t *testing.T) {
+		buf := &bytes.Buffer{}
+		logger := slog.New(newPrettyHandler(buf, nil, true))
+
+		logger.Error("failed")
+
+		assert.Contains(t, buf.String(), ansiRed+"ERROR"+ansiReset)
+		assert.Contains(t, buf.String(), ansiBold+"failed"+ansiReset)
+	})
+}
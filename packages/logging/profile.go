@@ -0,0 +1,60 @@
+package logging
+
+import (
+	"fmt"
+	"log/slog"
+)
+
+const (
+	ProfileGCP   = "gcp"
+	ProfilePlain = "plain"
+)
+
+// profile maps records onto the field names a log backend expects.
+type profile struct {
+	replace    func(groups []string, a slog.Attr) slog.Attr
+	traceKey   string
+	spanKey    string
+	sampledKey string
+}
+
+func profileByName(name string) (profile, error) {
+	switch name {
+	case "", ProfileGCP:
+		return profile{
+			replace:    gcpReplacer,
+			traceKey:   "logging.googleapis.com/trace",
+			spanKey:    "logging.googleapis.com/spanId",
+			sampledKey: "logging.googleapis.com/trace_sampled",
+		}, nil
+	case ProfilePlain:
+		return profile{
+			replace:    func(groups []string, a slog.Attr) slog.Attr { return a },
+			traceKey:   "trace_id",
+			spanKey:    "span_id",
+			sampledKey: "trace_sampled",
+		}, nil
+	default:
+		return profile{}, fmt.Errorf("unknown log profile %q", name)
+	}
+}
+
+// gcpReplacer maps the slog built-in keys onto the Cloud Logging structured
+// logging fields.
+func gcpReplacer(groups []string, a slog.Attr) slog.Attr {
+	switch a.Key {
+	case slog.LevelKey:
+		a.Key = "severity"
+		if level := a.Value.Any().(slog.Level); level == slog.LevelWarn {
+			a.Value = slog.StringValue("WARNING")
+		}
+	case slog.TimeKey:
+		a.Key = "timestamp"
+	case slog.MessageKey:
+		a.Key = "message"
+	case slog.SourceKey:
+		a.Key = "logging.googleapis.com/sourceLocation"
+	}
+
+	return a
+}
@@ -0,0 +1,44 @@
+package logging
+
+import (
+	"log/slog"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestProfile(t *testing.T) {
+	t.Run("正常系: gcpはCloud Loggingのフィールドに置き換える", func(t *testing.T) {
+		p, err := profileByName(ProfileGCP)
+		require.NoError(t, err)
+
+		assert.Equal(t, slog.String("severity", "WARNING"), p.replace(nil, slog.Any(slog.LevelKey, slog.LevelWarn)))
+		assert.Equal(t, slog.Any("severity", slog.LevelError), p.replace(nil, slog.Any(slog.LevelKey, slog.LevelError)))
+		assert.Equal(t, "message", p.replace(nil, slog.String(slog.MessageKey, "hi")).Key)
+		assert.Equal(t, "timestamp", p.replace(nil, slog.String(slog.TimeKey, "")).Key)
+		assert.Equal(t, "logging.googleapis.com/sourceLocation", p.replace(nil, slog.String(slog.SourceKey, "")).Key)
+		assert.Equal(t, slog.Int("user_id", 1), p.replace(nil, slog.Int("user_id", 1)))
+		assert.Equal(t, "logging.googleapis.com/trace", p.traceKey)
+	})
+
+	t.Run("正常系: 空はgcp", func(t *testing.T) {
+		p, err := profileByName("")
+		require.NoError(t, err)
+		assert.Equal(t, "logging.googleapis.com/spanId", p.spanKey)
+	})
+
+	t.Run("正常系: plainはそのまま", func(t *testing.T) {
+		p, err := profileByName(ProfilePlain)
+		require.NoError(t, err)
+
+		level := slog.Any(slog.LevelKey, slog.LevelWarn)
+		assert.Equal(t, level, p.replace(nil, level))
+		assert.Equal(t, "trace_id", p.traceKey)
+	})
+
+	t.Run("異常系: 不明なプロファイル", func(t *testing.T) {
+		_, err := profileByName("datadog")
+		assert.Error(t, err)
+	})
+}
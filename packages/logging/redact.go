@@ -0,0 +1,239 @@
+package logging
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"log/slog"
+	"reflect"
+	"strings"
+	"sync"
+	"unicode/utf8"
+)
+
+// Fields of the application's own types are redacted according to their log
+// struct tag:
+//
+//	Name string `log:"mask"`  // "t***"
+//	Bio  string `log:"drop"`  // omitted
+//	Mail string `log:"hash"`  // keyed hash, still usable for correlation
+//	ID   int    `log:"allow"` // kept as is, also in allow-list mode
+//
+// Untagged fields are kept in deny-list mode and dropped in allow-list mode.
+// Types that need more control can implement slog.LogValuer instead, which is
+// resolved before redaction.
+const (
+	RedactionDenylist  = "denylist"
+	RedactionAllowlist = "allowlist"
+)
+
+const (
+	tagMask  = "mask"
+	tagHash  = "hash"
+	tagDrop  = "drop"
+	tagAllow = "allow"
+)
+
+// modulePrefix limits redaction to types declared in this module, third-party
+// values such as stack frames are logged untouched.
+const modulePrefix = "go02/"
+
+type Redactor struct {
+	allowlist bool
+	hashKey   []byte
+	plans     sync.Map // reflect.Type -> []fieldPlan
+}
+
+type fieldPlan struct {
+	index     int
+	name      string
+	action    string
+	omitempty bool
+	inline    bool
+}
+
+func NewRedactor(mode string, hashKey string) (*Redactor, error) {
+	switch mode {
+	case "", RedactionDenylist, RedactionAllowlist:
+	default:
+		return nil, fmt.Errorf("unknown redaction mode %q", mode)
+	}
+
+	return &Redactor{
+		allowlist: mode == RedactionAllowlist,
+		hashKey:   []byte(hashKey),
+	}, nil
+}
+
+// ReplaceAttr redacts values of attributes logged with slog.Any.
+func (r *Redactor) ReplaceAttr(groups []string, a slog.Attr) slog.Attr {
+	if a.Value.Kind() != slog.KindAny {
+		return a
+	}
+
+	v := a.Value.Any()
+	if !r.applies(reflect.TypeOf(v)) {
+		return a
+	}
+
+	return slog.Any(a.Key, r.Redact(v))
+}
+
+// Redact returns v with sensitive fields masked, hashed or dropped. Structs
+// are converted to maps keyed by their JSON field names.
+func (r *Redactor) Redact(v any) any {
+	return r.redact(reflect.ValueOf(v))
+}
+
+func (r *Redactor) redact(v reflect.Value) any {
+	if !v.IsValid() {
+		return nil
+	}
+	if !r.applies(v.Type()) {
+		return v.Interface()
+	}
+
+	switch v.Kind() {
+	case reflect.Pointer, reflect.Interface:
+		if v.IsNil() {
+			return nil
+		}
+		return r.redact(v.Elem())
+	case reflect.Slice, reflect.Array:
+		if v.Kind() == reflect.Slice && v.IsNil() {
+			return nil
+		}
+		out := make([]any, v.Len())
+		for i := range out {
+			out[i] = r.redact(v.Index(i))
+		}
+		return out
+	case reflect.Map:
+		if v.IsNil() {
+			return nil
+		}
+		out := make(map[string]any, v.Len())
+		iter := v.MapRange()
+		for iter.Next() {
+			out[fmt.Sprint(iter.Key().Interface())] = r.redact(iter.Value())
+		}
+		return out
+	case reflect.Struct:
+		out := map[string]any{}
+		r.redactStruct(v, out)
+		return out
+	default:
+		return v.Interface()
+	}
+}
+
+func (r *Redactor) redactStruct(v reflect.Value, out map[string]any) {
+	for _, p := range r.plan(v.Type()) {
+		f := v.Field(p.index)
+
+		if p.inline {
+			if f.Kind() == reflect.Pointer {
+				if f.IsNil() {
+					continue
+				}
+				f = f.Elem()
+			}
+			r.redactStruct(f, out)
+			continue
+		}
+
+		if p.omitempty && f.IsZero() {
+			continue
+		}
+
+		switch p.action {
+		case tagDrop:
+		case tagMask:
+			out[p.name] = mask(f)
+		case tagHash:
+			out[p.name] = r.hash(f)
+		case tagAllow:
+			out[p.name] = r.redact(f)
+		default:
+			if !r.allowlist {
+				out[p.name] = r.redact(f)
+			}
+		}
+	}
+}
+
+func (r *Redactor) plan(t reflect.Type) []fieldPlan {
+	if cached, ok := r.plans.Load(t); ok {
+		return cached.([]fieldPlan)
+	}
+
+	plans := make([]fieldPlan, 0, t.NumField())
+	for i := 0; i < t.NumField(); i++ {
+		sf := t.Field(i)
+
+		name, opts, _ := strings.Cut(sf.Tag.Get("json"), ",")
+		if name == "-" {
+			continue
+		}
+
+		if sf.Anonymous && name == "" {
+			ft := sf.Type
+			if ft.Kind() == reflect.Pointer {
+				ft = ft.Elem()
+			}
+			// embedded third-party types such as bun.BaseModel carry no data
+			if ft.Kind() == reflect.Struct && r.applies(ft) {
+				plans = append(plans, fieldPlan{index: i, inline: true})
+			}
+			continue
+		}
+		if !sf.IsExported() {
+			continue
+		}
+
+		if name == "" {
+			name = sf.Name
+		}
+		plans = append(plans, fieldPlan{
+			index:     i,
+			name:      name,
+			action:    sf.Tag.Get("log"),
+			omitempty: strings.Contains(opts, "omitempty"),
+		})
+	}
+
+	r.plans.Store(t, plans)
+	return plans
+}
+
+func (r *Redactor) applies(t reflect.Type) bool {
+	for t != nil {
+		switch t.Kind() {
+		case reflect.Pointer, reflect.Slice, reflect.Array, reflect.Map:
+			if t.Name() != "" {
+				return strings.HasPrefix(t.PkgPath(), modulePrefix)
+			}
+			t = t.Elem()
+		case reflect.Struct:
+			return strings.HasPrefix(t.PkgPath(), modulePrefix)
+		default:
+			return false
+		}
+	}
+	return false
+}
+
+func mask(v reflect.Value) string {
+	if v.Kind() == reflect.String && v.Len() > 0 {
+		r, _ := utf8.DecodeRuneInString(v.String())
+		return string(r) + "***"
+	}
+	return "***"
+}
+
+func (r *Redactor) hash(v reflect.Value) string {
+	mac := hmac.New(sha256.New, r.hashKey)
+	fmt.Fprint(mac, v.Interface())
+	return "sha256:" + hex.EncodeToString(mac.Sum(nil))[:16]
+}
@@ -0,0 +1,105 @@
+package logging_test
+
+import (
+	"go02/packages/logging"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+type redactUser struct {
+	ID    int    `json:"id" log:"allow"`
+	Name  string `json:"name" log:"mask"`
+	Email string `json:"email" log:"hash"`
+	Bio   string `json:"bio" log:"drop"`
+	Note  string `json:"note,omitempty"`
+}
+
+type redactList struct {
+	Users []redactUser `json:"users" log:"allow"`
+}
+
+func TestRedactor(t *testing.T) {
+	user := redactUser{ID: 1, Name: "taro", Email: "taro@example.com", Bio: "hello", Note: "memo"}
+
+	tests := []struct {
+		name     string
+		mode     string
+		value    any
+		expected any
+	}{
+		{
+			name:  "正常系: deny-listモードでタグ付きのフィールドが秘匿される場合",
+			mode:  logging.RedactionDenylist,
+			value: user,
+			expected: map[string]any{
+				"id":    1,
+				"name":  "t***",
+				"email": "sha256:",
+				"note":  "memo",
+			},
+		},
+		{
+			name:  "正常系: allow-listモードでタグのないフィールドが除外される場合",
+			mode:  logging.RedactionAllowlist,
+			value: &redactList{Users: []redactUser{user}},
+			expected: map[string]any{
+				"users": []any{
+					map[string]any{
+						"id":    1,
+						"name":  "t***",
+						"email": "sha256:",
+					},
+				},
+			},
+		},
+		{
+			name:     "正常系: モジュール外の型はそのまま出力される場合",
+			mode:     logging.RedactionAllowlist,
+			value:    map[string]any{"name": "taro"},
+			expected: map[string]any{"name": "taro"},
+		},
+	}
+
+	for _, tt := range tests {
+		tt := tt
+
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			// Arrange
+			redactor, err := logging.NewRedactor(tt.mode, "key")
+			assert.NoError(t, err)
+
+			// Act
+			actual := redactor.Redact(tt.value)
+
+			// Assert
+			assert.Equal(t, tt.expected, normalizeHashes(actual))
+		})
+	}
+}
+
+// normalizeHashes strips the digest of hashed values so that expectations do
+// not depend on the hash key.
+func normalizeHashes(v any) any {
+	switch v := v.(type) {
+	case map[string]any:
+		for k, e := range v {
+			v[k] = normalizeHashes(e)
+		}
+		return v
+	case []any:
+		for i, e := range v {
+			v[i] = normalizeHashes(e)
+		}
+		return v
+	case string:
+		if len(v) > len("sha256:") && v[:len("sha256:")] == "sha256:" {
+			return "sha256:"
+		}
+		return v
+	default:
+		return v
+	}
+}
@@ -0,0 +1,119 @@
+package logging
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"sync"
+)
+
+// rotatingFile is an io.Writer that renames the file to path.1, path.2, ...
+// once it grows beyond maxSize, keeping at most maxBackups old files.
+type rotatingFile struct {
+	path       string
+	maxSize    int64
+	maxBackups int
+
+	mu   sync.Mutex
+	file *os.File
+	size int64
+}
+
+func newRotatingFile(path string, maxSizeMB int, maxBackups int) (*rotatingFile, error) {
+	if maxSizeMB <= 0 {
+		return nil, fmt.Errorf("log file max size must be positive, got %d", maxSizeMB)
+	}
+
+	f := &rotatingFile{
+		path:       path,
+		maxSize:    int64(maxSizeMB) * 1024 * 1024,
+		maxBackups: max(maxBackups, 0),
+	}
+	if err := f.open(); err != nil {
+		return nil, err
+	}
+
+	return f, nil
+}
+
+func (f *rotatingFile) Write(p []byte) (int, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	if f.file == nil {
+		return 0, os.ErrClosed
+	}
+
+	if f.size+int64(len(p)) > f.maxSize && f.size > 0 {
+		// a failed rotation keeps appending to the current file rather than
+		// dropping the record
+		if err := f.rotate(); err != nil && f.file == nil {
+			return 0, err
+		}
+	}
+
+	n, err := f.file.Write(p)
+	f.size += int64(n)
+	return n, err
+}
+
+// Close closes the current file. Writes fail afterwards.
+func (f *rotatingFile) Close() error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	if f.file == nil {
+		return nil
+	}
+	err := f.file.Close()
+	f.file = nil
+	return err
+}
+
+func (f *rotatingFile) open() error {
+	file, err := os.OpenFile(f.path, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0o644)
+	if err != nil {
+		return err
+	}
+
+	info, err := file.Stat()
+	if err != nil {
+		file.Close()
+		return err
+	}
+
+	f.file = file
+	f.size = info.Size()
+	return nil
+}
+
+// rotate moves the current file aside and starts a new one. The file at path
+// is opened again whatever fails on the way, so that later writes go on.
+func (f *rotatingFile) rotate() error {
+	closeErr := f.file.Close()
+	f.file = nil
+
+	err := f.shift()
+	return errors.Join(closeErr, err, f.open())
+}
+
+func (f *rotatingFile) shift() error {
+	if f.maxBackups == 0 {
+		if err := os.Remove(f.path); err != nil && !os.IsNotExist(err) {
+			return err
+		}
+		return nil
+	}
+
+	for i := f.maxBackups - 1; i > 0; i-- {
+		from := fmt.Sprintf("%s.%d", f.path, i)
+		if err := os.Rename(from, fmt.Sprintf("%s.%d", f.path, i+1)); err != nil && !os.IsNotExist(err) {
+			return err
+		}
+	}
+	if err := os.Rename(f.path, f.path+".1"); err != nil && !os.IsNotExist(err) {
+		return err
+	}
+
+	return nil
+}
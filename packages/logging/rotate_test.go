@@ -0,0 +1,74 @@
+package logging
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func newTestRotatingFile(t *testing.T, maxBackups int) (*rotatingFile, string) {
+	path := filepath.Join(t.TempDir(), "app.log")
+	f, err := newRotatingFile(path, 1, maxBackups)
+	require.NoError(t, err)
+	t.Cleanup(func() { f.Close() })
+	f.maxSize = 10
+	return f, path
+}
+
+func readFile(t *testing.T, path string) string {
+	b, err := os.ReadFile(path)
+	require.NoError(t, err)
+	return string(b)
+}
+
+func TestRotatingFile(t *testing.T) {
+	t.Run("正常系: サイズを超えるとバックアップをずらす", func(t *testing.T) {
+		f, path := newTestRotatingFile(t, 2)
+
+		for _, line := range []string{"first\n", "second\n", "third\n"} {
+			_, err := f.Write([]byte(line))
+			require.NoError(t, err)
+		}
+
+		assert.Equal(t, "third\n", readFile(t, path))
+		assert.Equal(t, "second\n", readFile(t, path+".1"))
+		assert.Equal(t, "first\n", readFile(t, path+".2"))
+	})
+
+	t.Run("正常系: バックアップなしなら切り詰める", func(t *testing.T) {
+		f, path := newTestRotatingFile(t, 0)
+
+		for _, line := range []string{"first\n", "second\n"} {
+			_, err := f.Write([]byte(line))
+			require.NoError(t, err)
+		}
+
+		assert.Equal(t, "second\n", readFile(t, path))
+		assert.NoFileExists(t, path+".1")
+	})
+
+	t.Run("異常系: ローテーションに失敗しても書き続ける", func(t *testing.T) {
+		f, path := newTestRotatingFile(t, 1)
+		// a non-empty directory in the way of the backup fails the rename
+		require.NoError(t, os.MkdirAll(filepath.Join(path+".1", "x"), 0o755))
+
+		for _, line := range []string{"first\n", "second\n", "third\n"} {
+			_, err := f.Write([]byte(line))
+			require.NoError(t, err)
+		}
+
+		assert.Equal(t, "first\nsecond\nthird\n", readFile(t, path))
+	})
+
+	t.Run("異常系: 閉じた後は書けない", func(t *testing.T) {
+		f, _ := newTestRotatingFile(t, 1)
+		require.NoError(t, f.Close())
+
+		_, err := f.Write([]byte("late\n"))
+		assert.ErrorIs(t, err, os.ErrClosed)
+		assert.NoError(t, f.Close())
+	})
+}
@@ -0,0 +1,88 @@
+package mail
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"sync"
+	"time"
+)
+
+type writerMailer struct {
+	mu sync.Mutex
+	w  io.Writer
+}
+
+// NewWriter writes one JSON document per message instead of sending it, for
+// development and offline testing.
+func NewWriter(w io.Writer) Mailer {
+	return &writerMailer{w: w}
+}
+
+// File appends messages to a file. It is closed on shutdown.
+type File struct {
+	Mailer
+	f *os.File
+}
+
+// NewFile appends messages to the file at path.
+func NewFile(path string) (*File, error) {
+	f, err := os.OpenFile(path, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0o600)
+	if err != nil {
+		return nil, fmt.Errorf("open mail file: %w", err)
+	}
+	return &File{Mailer: NewWriter(f), f: f}, nil
+}
+
+func (m *File) Close() error {
+	return m.f.Close()
+}
+
+func (m *writerMailer) Send(ctx context.Context, msg Message) error {
+	if err := msg.validate(); err != nil {
+		return err
+	}
+
+	b, err := json.Marshal(struct {
+		Time time.Time `json:"time"`
+		Message
+	}{time.Now(), msg})
+	if err != nil {
+		return err
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	_, err = m.w.Write(append(b, '\n'))
+	return err
+}
+
+// Memory keeps the messages it is sent, for tests.
+type Memory struct {
+	mu       sync.Mutex
+	messages []Message
+}
+
+func NewMemory() *Memory {
+	return &Memory{}
+}
+
+func (m *Memory) Send(ctx context.Context, msg Message) error {
+	if err := msg.validate(); err != nil {
+		return err
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.messages = append(m.messages, msg)
+	return nil
+}
+
+// Messages returns the messages sent so far, oldest first.
+func (m *Memory) Messages() []Message {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return append([]Message(nil), m.messages...)
+}
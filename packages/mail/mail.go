@@ -0,0 +1,102 @@
+package mail
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"strings"
+)
+
+// Message is a plain text email.
+type Message struct {
+	From    string `json:"from"`
+	To      string `json:"to"`
+	Subject string `json:"subject"`
+	Body    string `json:"body"`
+}
+
+type Mailer interface {
+	Send(ctx context.Context, msg Message) error
+}
+
+const (
+	MailerSMTP   = "smtp"
+	MailerFile   = "file"
+	MailerMemory = "memory"
+)
+
+type Options struct {
+	Mailer string
+	// From is used for messages that don't set one.
+	From string
+	File string
+
+	SMTPHost     string
+	SMTPPort     string
+	SMTPUser     string
+	SMTPPassword string
+	// SMTPAllowPlaintext sends without TLS when the relay doesn't offer
+	// STARTTLS instead of failing.
+	SMTPAllowPlaintext bool
+}
+
+// New returns the mailer named by opts. Close it when done, see Close.
+func New(opts Options) (Mailer, error) {
+	var (
+		m   Mailer
+		err error
+	)
+	switch opts.Mailer {
+	case MailerSMTP:
+		m = NewSMTP(opts.SMTPHost, opts.SMTPPort, opts.SMTPUser, opts.SMTPPassword, opts.SMTPAllowPlaintext)
+	case "", MailerFile:
+		var f *File
+		f, err = NewFile(opts.File)
+		m = f
+	case MailerMemory:
+		m = NewMemory()
+	default:
+		return nil, fmt.Errorf("unknown mailer %q", opts.Mailer)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	return &defaultFrom{Mailer: m, from: opts.From}, nil
+}
+
+type defaultFrom struct {
+	Mailer
+	from string
+}
+
+// Close releases what the mailer holds, e.g. the file of the file mailer.
+func Close(m Mailer) error {
+	if d, ok := m.(*defaultFrom); ok {
+		m = d.Mailer
+	}
+	if c, ok := m.(io.Closer); ok {
+		return c.Close()
+	}
+	return nil
+}
+
+func (m *defaultFrom) Send(ctx context.Context, msg Message) error {
+	if msg.From == "" {
+		msg.From = m.from
+	}
+	return m.Mailer.Send(ctx, msg)
+}
+
+// validate rejects header values that would let a caller inject headers.
+func (msg Message) validate() error {
+	for name, v := range map[string]string{"From": msg.From, "To": msg.To, "Subject": msg.Subject} {
+		if strings.ContainsAny(v, "\r\n") {
+			return fmt.Errorf("mail %s contains a line break", name)
+		}
+	}
+	if msg.From == "" || msg.To == "" {
+		return fmt.Errorf("mail needs a sender and a recipient")
+	}
+	return nil
+}
@@ -0,0 +1,140 @@
+package mail
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"net"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestNewDefaultFrom(t *testing.T) {
+	m, err := New(Options{Mailer: MailerMemory, From: "no-reply@example.com"})
+	require.NoError(t, err)
+
+	require.NoError(t, m.Send(context.Background(), Message{To: "taro@example.com", Subject: "hi", Body: "hello"}))
+
+	memory := m.(*defaultFrom).Mailer.(*Memory)
+	assert.Equal(t, []Message{{From: "no-reply@example.com", To: "taro@example.com", Subject: "hi", Body: "hello"}}, memory.Messages())
+}
+
+func TestWriter(t *testing.T) {
+	var buf bytes.Buffer
+	m := NewWriter(&buf)
+
+	require.NoError(t, m.Send(context.Background(), Message{From: "a@example.com", To: "b@example.com", Subject: "hi", Body: "hello"}))
+
+	var got Message
+	require.NoError(t, json.Unmarshal(buf.Bytes(), &got))
+	assert.Equal(t, "b@example.com", got.To)
+	assert.Equal(t, "hello", got.Body)
+}
+
+func TestHeaderInjection(t *testing.T) {
+	err := NewMemory().Send(context.Background(), Message{
+		From:    "a@example.com",
+		To:      "b@example.com",
+		Subject: "hi\r\nBcc: c@example.com",
+	})
+	assert.Error(t, err)
+}
+
+func TestMessageBytes(t *testing.T) {
+	msg := Message{From: "a@example.com", To: "b@example.com", Subject: "メール確認", Body: "hello"}
+	got := string(msg.bytes(time.Date(2024, 4, 1, 0, 0, 0, 0, time.UTC)))
+
+	assert.True(t, strings.HasPrefix(got, "From: a@example.com\r\nTo: b@example.com\r\nSubject: =?utf-8?q?"))
+	assert.Contains(t, got, "Date: Mon, 01 Apr 2024 00:00:00 +0000\r\n")
+	assert.True(t, strings.HasSuffix(got, "\r\n\r\nhello"))
+}
+
+func TestFileClose(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "mail.jsonl")
+	m, err := New(Options{Mailer: MailerFile, File: path, From: "a@example.com"})
+	require.NoError(t, err)
+
+	msg := Message{To: "b@example.com", Subject: "hi", Body: "hello"}
+	require.NoError(t, m.Send(context.Background(), msg))
+	require.NoError(t, Close(m))
+
+	assert.ErrorIs(t, m.Send(context.Background(), msg), os.ErrClosed)
+	b, err := os.ReadFile(path)
+	require.NoError(t, err)
+	assert.Contains(t, string(b), "b@example.com")
+}
+
+// serveSMTP answers like a relay without STARTTLS and returns the messages it
+// was sent.
+func serveSMTP(t *testing.T) (host, port string, messages <-chan string) {
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	t.Cleanup(func() { ln.Close() })
+
+	ch := make(chan string, 1)
+	go func() {
+		for {
+			conn, err := ln.Accept()
+			if err != nil {
+				return
+			}
+			go func() {
+				defer conn.Close()
+				r := bufio.NewReader(conn)
+				reply := func(s string) { _, _ = conn.Write([]byte(s + "\r\n")) }
+
+				reply("220 localhost ESMTP")
+				for {
+					line, err := r.ReadString('\n')
+					if err != nil {
+						return
+					}
+					switch cmd := strings.ToUpper(strings.Fields(line + " ")[0]); cmd {
+					case "EHLO":
+						reply("250-localhost")
+						reply("250 8BITMIME")
+					case "DATA":
+						reply("354 go ahead")
+						var data strings.Builder
+						for {
+							line, err := r.ReadString('\n')
+							if err != nil || line == ".\r\n" {
+								break
+							}
+							data.WriteString(line)
+						}
+						ch <- data.String()
+						reply("250 queued")
+					case "QUIT":
+						reply("221 bye")
+						return
+					default:
+						reply("250 ok")
+					}
+				}
+			}()
+		}
+	}()
+
+	host, port, err = net.SplitHostPort(ln.Addr().String())
+	require.NoError(t, err)
+	return host, port, ch
+}
+
+func TestSMTPRequiresStartTLS(t *testing.T) {
+	host, port, messages := serveSMTP(t)
+	msg := Message{From: "a@example.com", To: "b@example.com", Subject: "hi", Body: "hello"}
+
+	err := NewSMTP(host, port, "", "", false).Send(context.Background(), msg)
+	assert.ErrorIs(t, err, errNoStartTLS)
+
+	require.NoError(t, NewSMTP(host, port, "", "", true).Send(context.Background(), msg))
+	assert.Contains(t, <-messages, "hello")
+}
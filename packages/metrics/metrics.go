@@ -0,0 +1,66 @@
+package metrics
+
+import (
+	"context"
+	"fmt"
+	"go02/packages/config"
+	"net/http"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+	"go.opentelemetry.io/contrib/detectors/gcp"
+	"go.opentelemetry.io/otel/exporters/otlp/otlpmetric/otlpmetricgrpc"
+	otelprometheus "go.opentelemetry.io/otel/exporters/prometheus"
+	sdkmetric "go.opentelemetry.io/otel/sdk/metric"
+	"go.opentelemetry.io/otel/sdk/resource"
+	semconv "go.opentelemetry.io/otel/semconv/v1.9.0"
+)
+
+// InitializeMeter builds a MeterProvider from cfg. Metrics are always served
+// in the Prometheus format through the returned handler and are additionally
+// pushed over OTLP when METRICS_OTLP_ENDPOINT is set. The provider isn't
+// installed as the OTel global.
+func InitializeMeter(ctx context.Context, cfg *config.Config) (*sdkmetric.MeterProvider, http.Handler, error) {
+	registry := prometheus.NewRegistry()
+	promExporter, err := otelprometheus.New(otelprometheus.WithRegisterer(registry))
+	if err != nil {
+		return nil, nil, fmt.Errorf("create prometheus exporter: %w", err)
+	}
+
+	res, err := resource.New(ctx,
+		resource.WithDetectors(gcp.NewDetector()),
+		resource.WithAttributes(
+			semconv.ServiceNameKey.String("go02"),
+			semconv.ServiceVersionKey.String(cfg.AppVersion),
+			semconv.DeploymentEnvironmentKey.String(cfg.Env),
+		),
+	)
+	if err != nil {
+		return nil, nil, fmt.Errorf("create resource: %w", err)
+	}
+
+	opts := []sdkmetric.Option{
+		sdkmetric.WithReader(promExporter),
+		sdkmetric.WithResource(res),
+	}
+
+	if endpoint := cfg.Metrics.OTLPEndpoint; endpoint != "" {
+		exporterOpts := []otlpmetricgrpc.Option{otlpmetricgrpc.WithEndpoint(endpoint)}
+		if cfg.Metrics.OTLPInsecure {
+			exporterOpts = append(exporterOpts, otlpmetricgrpc.WithInsecure())
+		}
+
+		otlpExporter, err := otlpmetricgrpc.New(ctx, exporterOpts...)
+		if err != nil {
+			return nil, nil, fmt.Errorf("create otlp metric exporter: %w", err)
+		}
+
+		opts = append(opts, sdkmetric.WithReader(
+			sdkmetric.NewPeriodicReader(otlpExporter, sdkmetric.WithInterval(cfg.Metrics.ExportInterval)),
+		))
+	}
+
+	mp := sdkmetric.NewMeterProvider(opts...)
+
+	return mp, promhttp.HandlerFor(registry, promhttp.HandlerOpts{}), nil
+}
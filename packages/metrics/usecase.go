@@ -0,0 +1,71 @@
+package metrics
+
+import (
+	"context"
+	"time"
+
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/metric"
+	"go.opentelemetry.io/otel/metric/noop"
+)
+
+// Usecase records the duration and errors of usecase methods.
+type Usecase struct {
+	duration metric.Float64Histogram
+	errors   metric.Int64Counter
+}
+
+// NewUsecase creates the usecase instruments, using a no-op provider when mp
+// is nil.
+func NewUsecase(mp metric.MeterProvider) *Usecase {
+	if mp == nil {
+		mp = noop.NewMeterProvider()
+	}
+	meter := mp.Meter("go02/usecase")
+
+	duration, _ := meter.Float64Histogram("usecase.duration",
+		metric.WithDescription("Duration of usecase method calls."),
+		metric.WithUnit("s"),
+	)
+	errors, _ := meter.Int64Counter("usecase.errors",
+		metric.WithDescription("Number of usecase method calls that returned an error."),
+	)
+
+	return &Usecase{
+		duration: duration,
+		errors:   errors,
+	}
+}
+
+type UsecaseTimer struct {
+	u      *Usecase
+	ctx    context.Context
+	method string
+	start  time.Time
+}
+
+// Start starts timing a usecase method. Call End with a pointer to the
+// method's named error result in a defer.
+//
+//	defer u.metrics.Start(ctx, "userUsecase.CreateUser").End(&err)
+func (u *Usecase) Start(ctx context.Context, method string) UsecaseTimer {
+	return UsecaseTimer{
+		u:      u,
+		ctx:    ctx,
+		method: method,
+		start:  time.Now(),
+	}
+}
+
+func (t UsecaseTimer) End(err *error) {
+	failed := err != nil && *err != nil
+	attrs := metric.WithAttributes(
+		attribute.String("usecase.method", t.method),
+		attribute.Bool("error", failed),
+	)
+
+	t.u.duration.Record(t.ctx, time.Since(t.start).Seconds(), attrs)
+	if failed {
+		t.u.errors.Add(t.ctx, 1, metric.WithAttributes(attribute.String("usecase.method", t.method)))
+	}
+}
@@ -0,0 +1,53 @@
+package tracer
+
+import (
+	"context"
+	"fmt"
+	"go02/packages/logging"
+	"sync"
+	"time"
+
+	"go.opentelemetry.io/otel"
+)
+
+// exportErrorWindow is how long an error reported by the OpenTelemetry SDK
+// keeps the tracer unhealthy.
+const exportErrorWindow = time.Minute
+
+// The SDK reports export errors through a single process wide handler, so
+// unlike the providers this state is shared by every instance in the process.
+var lastError struct {
+	sync.Mutex
+	err error
+	at  time.Time
+}
+
+func recordError(err error) {
+	lastError.Lock()
+	defer lastError.Unlock()
+
+	lastError.err = err
+	lastError.at = time.Now()
+}
+
+// registerErrorHandler logs SDK errors with the logger carried by ctx, so they
+// get the same format and redaction as the rest of the application.
+func registerErrorHandler(ctx context.Context) {
+	ctx = context.WithoutCancel(ctx)
+	otel.SetErrorHandler(otel.ErrorHandlerFunc(func(err error) {
+		logging.Error(ctx, err, "opentelemetry error")
+		recordError(err)
+	}))
+}
+
+// HealthCheck fails while the exporter has reported an error recently.
+func HealthCheck(ctx context.Context) error {
+	lastError.Lock()
+	defer lastError.Unlock()
+
+	if lastError.err != nil && time.Since(lastError.at) < exportErrorWindow {
+		return fmt.Errorf("trace exporter error at %s: %w", lastError.at.Format(time.RFC3339), lastError.err)
+	}
+
+	return nil
+}
@@ -2,42 +2,120 @@ package tracer
 
 import (
 	"context"
-	"log"
+	"fmt"
+	"go02/packages/config"
 	"os"
 
 	texporter "github.com/GoogleCloudPlatform/opentelemetry-operations-go/exporter/trace"
 	"go.opentelemetry.io/contrib/detectors/gcp"
-	"go.opentelemetry.io/otel"
-	"go.opentelemetry.io/otel/propagation"
+	"go.opentelemetry.io/otel/exporters/otlp/otlptrace/otlptracegrpc"
+	"go.opentelemetry.io/otel/exporters/otlp/otlptrace/otlptracehttp"
+	"go.opentelemetry.io/otel/exporters/stdout/stdouttrace"
 	"go.opentelemetry.io/otel/sdk/resource"
 	sdktrace "go.opentelemetry.io/otel/sdk/trace"
 	semconv "go.opentelemetry.io/otel/semconv/v1.9.0"
 )
 
-func InitializeTracer() *sdktrace.TracerProvider {
-	ctx := context.Background()
-	exporter, err := texporter.New(texporter.WithProjectID(os.Getenv("PROJECT_ID")))
+const (
+	ExporterGCP      = "gcp"
+	ExporterOTLPGRPC = "otlp-grpc"
+	ExporterOTLPHTTP = "otlp-http"
+	ExporterStdout   = "stdout"
+	ExporterNone     = "none"
+)
+
+const (
+	SamplerParentBasedRatio = "parentbased_ratio"
+	SamplerAlways           = "always"
+	SamplerNever            = "never"
+)
+
+// InitializeTracer builds a TracerProvider from cfg. It isn't installed as the
+// OTel global; pass it to the components that create spans.
+func InitializeTracer(ctx context.Context, cfg *config.Config) (*sdktrace.TracerProvider, error) {
+	exporter, err := newExporter(ctx, cfg.Tracing)
 	if err != nil {
-		log.Println(err)
+		return nil, fmt.Errorf("create %s trace exporter: %w", cfg.Tracing.Exporter, err)
+	}
+
+	sampler, err := newSampler(cfg.Tracing)
+	if err != nil {
+		return nil, err
 	}
 
 	res, err := resource.New(ctx,
 		resource.WithDetectors(gcp.NewDetector()),
 		resource.WithAttributes(
 			semconv.ServiceNameKey.String("go02"),
+			semconv.ServiceVersionKey.String(cfg.AppVersion),
+			semconv.DeploymentEnvironmentKey.String(cfg.Env),
 		),
 	)
 	if err != nil {
-		log.Fatalln(err)
+		return nil, fmt.Errorf("create resource: %w", err)
 	}
 
-	tp := sdktrace.NewTracerProvider(
-		sdktrace.WithBatcher(exporter),
+	opts := []sdktrace.TracerProviderOption{
+		sdktrace.WithSampler(sampler),
 		sdktrace.WithResource(res),
-	)
+	}
+	if exporter != nil {
+		opts = append(opts, sdktrace.WithBatcher(exporter))
+	}
+
+	tp := sdktrace.NewTracerProvider(opts...)
+
+	registerErrorHandler(ctx)
+
+	return tp, nil
+}
 
-	otel.SetTracerProvider(tp)
-	otel.SetTextMapPropagator(propagation.TraceContext{})
+// newExporter returns nil for the "none" exporter. Spans are still created so
+// that logs keep their trace correlation, they are just never exported.
+func newExporter(ctx context.Context, cfg config.TracingConfig) (sdktrace.SpanExporter, error) {
+	switch cfg.Exporter {
+	case ExporterGCP:
+		return texporter.New(texporter.WithProjectID(cfg.ProjectID))
+	case ExporterOTLPGRPC:
+		opts := []otlptracegrpc.Option{}
+		if endpoint := cfg.OTLPEndpoint; endpoint != "" {
+			opts = append(opts, otlptracegrpc.WithEndpoint(endpoint))
+		}
+		if cfg.OTLPInsecure {
+			opts = append(opts, otlptracegrpc.WithInsecure())
+		}
+		return otlptracegrpc.New(ctx, opts...)
+	case ExporterOTLPHTTP:
+		opts := []otlptracehttp.Option{}
+		if endpoint := cfg.OTLPEndpoint; endpoint != "" {
+			opts = append(opts, otlptracehttp.WithEndpoint(endpoint))
+		}
+		if cfg.OTLPInsecure {
+			opts = append(opts, otlptracehttp.WithInsecure())
+		}
+		return otlptracehttp.New(ctx, opts...)
+	case ExporterStdout:
+		return stdouttrace.New(stdouttrace.WithWriter(os.Stdout))
+	case ExporterNone:
+		return nil, nil
+	default:
+		return nil, fmt.Errorf("unknown trace exporter %q", cfg.Exporter)
+	}
+}
 
-	return tp
+func newSampler(cfg config.TracingConfig) (sdktrace.Sampler, error) {
+	switch cfg.Sampler {
+	case SamplerParentBasedRatio:
+		ratio := cfg.SamplerRatio
+		if ratio < 0 || ratio > 1 {
+			return nil, fmt.Errorf("trace sampler ratio must be between 0 and 1, got %v", ratio)
+		}
+		return sdktrace.ParentBased(sdktrace.TraceIDRatioBased(ratio)), nil
+	case SamplerAlways:
+		return sdktrace.AlwaysSample(), nil
+	case SamplerNever:
+		return sdktrace.NeverSample(), nil
+	default:
+		return nil, fmt.Errorf("unknown trace sampler %q", cfg.Sampler)
+	}
 }
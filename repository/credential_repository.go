@@ -0,0 +1,77 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+
+	"go02/model"
+	"go02/packages/apperrors"
+	"go02/packages/db"
+
+	"github.com/cockroachdb/errors"
+	"github.com/uptrace/bun"
+)
+
+type CredentialRepository interface {
+	Create(ctx context.Context, credential *model.Credential) error
+	GetByUserIDForUpdate(ctx context.Context, userID int) (model.Credential, error)
+	Update(ctx context.Context, credential *model.Credential) error
+	DeleteByUserID(ctx context.Context, userID int) error
+}
+
+type credentialRepository struct {
+	conn *bun.DB
+}
+
+func NewCredentialRepository(conn *bun.DB) CredentialRepository {
+	return &credentialRepository{
+		conn: conn,
+	}
+}
+
+// Create パスワードの保存
+func (r *credentialRepository) Create(ctx context.Context, credential *model.Credential) error {
+	tx := db.GetTxOrDB(ctx, r.conn)
+	if _, err := tx.NewInsert().Model(credential).Exec(ctx); err != nil {
+		return apperrors.WithStack(db.TranslateError(err))
+	}
+
+	return nil
+}
+
+// GetByUserIDForUpdate ユーザーのパスワードを取得し、トランザクションの間ロックする。
+// 同時のログイン失敗を取りこぼさないため
+func (r *credentialRepository) GetByUserIDForUpdate(ctx context.Context, userID int) (model.Credential, error) {
+	var credential model.Credential
+
+	tx := db.GetTxOrDB(ctx, r.conn)
+	err := tx.NewSelect().Model(&credential).Where("user_id = ?", userID).For("UPDATE").Scan(ctx)
+	if errors.Is(err, sql.ErrNoRows) {
+		return model.Credential{}, apperrors.WithStack(apperrors.ErrNotFound)
+	}
+	if err != nil {
+		return model.Credential{}, apperrors.WithStack(err)
+	}
+
+	return credential, nil
+}
+
+// Update パスワードとログイン失敗回数の更新
+func (r *credentialRepository) Update(ctx context.Context, credential *model.Credential) error {
+	tx := db.GetTxOrDB(ctx, r.conn)
+	if _, err := tx.NewUpdate().Model(credential).WherePK().Exec(ctx); err != nil {
+		return apperrors.WithStack(err)
+	}
+
+	return nil
+}
+
+// DeleteByUserID ユーザーのパスワードを削除する
+func (r *credentialRepository) DeleteByUserID(ctx context.Context, userID int) error {
+	tx := db.GetTxOrDB(ctx, r.conn)
+	if _, err := tx.NewDelete().Model((*model.Credential)(nil)).Where("user_id = ?", userID).Exec(ctx); err != nil {
+		return apperrors.WithStack(err)
+	}
+
+	return nil
+}
@@ -0,0 +1,99 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"time"
+
+	"go02/model"
+	"go02/packages/apperrors"
+	"go02/packages/db"
+
+	"github.com/cockroachdb/errors"
+	"github.com/uptrace/bun"
+)
+
+type EmailVerificationRepository interface {
+	Create(ctx context.Context, token *model.EmailVerificationToken) error
+	GetByTokenHashForUpdate(ctx context.Context, hash []byte) (model.EmailVerificationToken, error)
+	GetLatestByUserID(ctx context.Context, userID int) (model.EmailVerificationToken, error)
+	MarkUsed(ctx context.Context, tokenID int, at time.Time) error
+	DeleteByUserID(ctx context.Context, userID int) error
+}
+
+type emailVerificationRepository struct {
+	conn *bun.DB
+}
+
+func NewEmailVerificationRepository(conn *bun.DB) EmailVerificationRepository {
+	return &emailVerificationRepository{
+		conn: conn,
+	}
+}
+
+// Create 確認トークンの保存
+func (r *emailVerificationRepository) Create(ctx context.Context, token *model.EmailVerificationToken) error {
+	tx := db.GetTxOrDB(ctx, r.conn)
+	if _, err := tx.NewInsert().Model(token).Exec(ctx); err != nil {
+		return apperrors.WithStack(db.TranslateError(err))
+	}
+
+	return nil
+}
+
+// GetByTokenHashForUpdate ハッシュで確認トークンを取得し、トランザクションの間ロックする
+func (r *emailVerificationRepository) GetByTokenHashForUpdate(ctx context.Context, hash []byte) (model.EmailVerificationToken, error) {
+	var token model.EmailVerificationToken
+
+	tx := db.GetTxOrDB(ctx, r.conn)
+	err := tx.NewSelect().Model(&token).Where("token_hash = ?", hash).For("UPDATE").Scan(ctx)
+	if errors.Is(err, sql.ErrNoRows) {
+		return model.EmailVerificationToken{}, apperrors.WithStack(apperrors.ErrNotFound)
+	}
+	if err != nil {
+		return model.EmailVerificationToken{}, apperrors.WithStack(err)
+	}
+
+	return token, nil
+}
+
+// GetLatestByUserID ユーザーに最後に発行した確認トークンを取得
+func (r *emailVerificationRepository) GetLatestByUserID(ctx context.Context, userID int) (model.EmailVerificationToken, error) {
+	var token model.EmailVerificationToken
+
+	tx := db.GetTxOrDB(ctx, r.conn)
+	err := tx.NewSelect().Model(&token).Where("user_id = ?", userID).Order("created_at DESC").Limit(1).Scan(ctx)
+	if errors.Is(err, sql.ErrNoRows) {
+		return model.EmailVerificationToken{}, apperrors.WithStack(apperrors.ErrNotFound)
+	}
+	if err != nil {
+		return model.EmailVerificationToken{}, apperrors.WithStack(err)
+	}
+
+	return token, nil
+}
+
+// MarkUsed 確認トークンを使用済みにする
+func (r *emailVerificationRepository) MarkUsed(ctx context.Context, tokenID int, at time.Time) error {
+	tx := db.GetTxOrDB(ctx, r.conn)
+	_, err := tx.NewUpdate().Model((*model.EmailVerificationToken)(nil)).
+		Set("used_at = ?", at.UTC()).
+		Where("id = ?", tokenID).
+		Exec(ctx)
+	if err != nil {
+		return apperrors.WithStack(err)
+	}
+
+	return nil
+}
+
+// DeleteByUserID ユーザーの確認トークンをすべて削除する
+func (r *emailVerificationRepository) DeleteByUserID(ctx context.Context, userID int) error {
+	tx := db.GetTxOrDB(ctx, r.conn)
+	_, err := tx.NewDelete().Model((*model.EmailVerificationToken)(nil)).Where("user_id = ?", userID).Exec(ctx)
+	if err != nil {
+		return apperrors.WithStack(err)
+	}
+
+	return nil
+}
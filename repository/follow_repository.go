@@ -0,0 +1,196 @@
+package repository
+
+import (
+	"context"
+	"go02/model"
+	"go02/packages/apperrors"
+	"go02/packages/db"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/uptrace/bun"
+)
+
+// FollowCursor is the position of the last follow of a page; the next page
+// starts after it. The zero value starts at the newest follow.
+type FollowCursor struct {
+	CreatedAt time.Time
+	UserID    int
+}
+
+type FollowRepository interface {
+	Create(ctx context.Context, follow *model.UserFollow) error
+	Delete(ctx context.Context, followerID int, followeeID int) error
+	GetFollowers(ctx context.Context, userID int, after FollowCursor, limit int) ([]model.UserFollow, error)
+	GetFollowing(ctx context.Context, userID int, after FollowCursor, limit int) ([]model.UserFollow, error)
+	GetMutuals(ctx context.Context, userID int, after FollowCursor, limit int) ([]model.UserFollow, error)
+	MarkChanged(ctx context.Context, userIDs ...int) error
+	ReconcileCounts(ctx context.Context, orgID int) (int, error)
+}
+
+type followRepository struct {
+	conn *bun.DB
+}
+
+func NewFollowRepository(conn *bun.DB) FollowRepository {
+	return &followRepository{
+		conn: conn,
+	}
+}
+
+// Create フォローを作成 (既にフォローしていれば何もしない)
+func (r *followRepository) Create(ctx context.Context, follow *model.UserFollow) error {
+	tx := db.GetTxOrDB(ctx, r.conn)
+	_, err := tx.NewInsert().Model(follow).On("CONFLICT (follower_id, followee_id) DO NOTHING").Exec(ctx)
+	if err != nil {
+		return apperrors.WithStack(db.TranslateError(err))
+	}
+
+	return nil
+}
+
+// Delete フォローを削除 (フォローしていなければ何もしない)
+func (r *followRepository) Delete(ctx context.Context, followerID int, followeeID int) error {
+	tx := db.GetTxOrDB(ctx, r.conn)
+	_, err := tx.NewDelete().Model((*model.UserFollow)(nil)).
+		Where("follower_id = ?", followerID).
+		Where("followee_id = ?", followeeID).
+		Exec(ctx)
+	if err != nil {
+		return apperrors.WithStack(err)
+	}
+
+	return nil
+}
+
+// GetFollowers userIDのフォロワーを新しい順に取得 (Followerを含む)
+func (r *followRepository) GetFollowers(ctx context.Context, userID int, after FollowCursor, limit int) ([]model.UserFollow, error) {
+	return r.list(ctx, "followee_id", "follower_id", "Follower", userID, after, limit, nil)
+}
+
+// GetFollowing userIDがフォローしているユーザーを新しい順に取得 (Followeeを含む)
+func (r *followRepository) GetFollowing(ctx context.Context, userID int, after FollowCursor, limit int) ([]model.UserFollow, error) {
+	return r.list(ctx, "follower_id", "followee_id", "Followee", userID, after, limit, nil)
+}
+
+// GetMutuals userIDと相互フォローのユーザーを、フォローされた新しい順に取得 (Followerを含む)
+func (r *followRepository) GetMutuals(ctx context.Context, userID int, after FollowCursor, limit int) ([]model.UserFollow, error) {
+	return r.list(ctx, "followee_id", "follower_id", "Follower", userID, after, limit, func(q *bun.SelectQuery) *bun.SelectQuery {
+		// the primary key finds the follow back
+		return q.Where("EXISTS (SELECT 1 FROM user_follows AS back WHERE back.follower_id = ? AND back.followee_id = ?TableAlias.follower_id)", userID)
+	})
+}
+
+// list pages through the follows with column = userID by (created_at, other)
+// descending, which the user_follows_*_created_at_idx indexes cover.
+func (r *followRepository) list(
+	ctx context.Context,
+	column string,
+	other string,
+	relation string,
+	userID int,
+	after FollowCursor,
+	limit int,
+	apply func(*bun.SelectQuery) *bun.SelectQuery,
+) ([]model.UserFollow, error) {
+	follows := make([]model.UserFollow, 0, limit)
+
+	tx := db.GetTxOrDB(ctx, r.conn)
+	q := tx.NewSelect().Model(&follows).
+		Relation(relation).
+		Where("?TableAlias.? = ?", bun.Ident(column), userID).
+		// soft deleted users are left out of the join
+		Where("?.id IS NOT NULL", bun.Ident(strings.ToLower(relation))).
+		OrderExpr("?TableAlias.created_at DESC, ?TableAlias.? DESC", bun.Ident(other)).
+		Limit(limit)
+	if !after.CreatedAt.IsZero() {
+		q = q.Where("(?TableAlias.created_at, ?TableAlias.?) < (?, ?)", bun.Ident(other), after.CreatedAt, after.UserID)
+	}
+	if apply != nil {
+		q = apply(q)
+	}
+
+	if err := q.Scan(ctx); err != nil {
+		return nil, apperrors.WithStack(err)
+	}
+
+	return follows, nil
+}
+
+// MarkChanged ユーザーのフォロー数を次の照合で数え直す。
+// 既存の行を更新せず追記するだけなので、人気のユーザーへの同時のフォローも待ち合わない
+func (r *followRepository) MarkChanged(ctx context.Context, userIDs ...int) error {
+	if len(userIDs) == 0 {
+		return nil
+	}
+	userIDs = slices.Clone(userIDs)
+	slices.Sort(userIDs)
+	changes := make([]model.UserFollowCountChange, 0, len(userIDs))
+	for _, id := range slices.Compact(userIDs) {
+		changes = append(changes, model.UserFollowCountChange{UserID: id})
+	}
+
+	tx := db.GetTxOrDB(ctx, r.conn)
+	_, err := tx.NewInsert().Model(&changes).Exec(ctx)
+	if err != nil {
+		return apperrors.WithStack(db.TranslateError(err))
+	}
+
+	return nil
+}
+
+// ReconcileCounts recomputes user_follow_counts from user_follows for the
+// users of the organization marked by MarkChanged, and returns the number of
+// counts that changed. Follows of soft deleted users aren't counted. It runs
+// on a connection of the organization for the row level security policies.
+// Marks of follows not committed yet, or taken by another replica, are left
+// for the next run.
+func (r *followRepository) ReconcileCounts(ctx context.Context, orgID int) (int, error) {
+	var changed int
+
+	err := db.WithTenantConn(db.WithTenant(ctx, orgID), r.conn, func(ctx context.Context) error {
+		res, err := db.GetTxOrDB(ctx, r.conn).NewRaw(`
+			WITH changed AS (
+				DELETE FROM user_follow_count_changes
+				WHERE id IN (
+					SELECT id FROM user_follow_count_changes WHERE org_id = ?
+					FOR UPDATE SKIP LOCKED)
+				RETURNING user_id
+			)
+			INSERT INTO user_follow_counts (user_id, org_id, followers_count, following_count, updated_at)
+			SELECT u.id, u.org_id,
+				(SELECT count(*) FROM user_follows AS f JOIN users AS o ON o.id = f.follower_id AND o.deleted_at IS NULL
+					WHERE f.followee_id = u.id),
+				(SELECT count(*) FROM user_follows AS f JOIN users AS o ON o.id = f.followee_id AND o.deleted_at IS NULL
+					WHERE f.follower_id = u.id),
+				?
+			FROM users AS u
+			WHERE u.id IN (SELECT user_id FROM changed)
+				AND u.deleted_at IS NULL
+			ON CONFLICT (user_id) DO UPDATE
+			SET followers_count = EXCLUDED.followers_count,
+				following_count = EXCLUDED.following_count,
+				updated_at = EXCLUDED.updated_at
+			WHERE (user_follow_counts.followers_count, user_follow_counts.following_count)
+				IS DISTINCT FROM (EXCLUDED.followers_count, EXCLUDED.following_count)`,
+			orgID, time.Now().UTC(),
+		).Exec(ctx)
+		if err != nil {
+			return apperrors.WithStack(err)
+		}
+
+		n, err := res.RowsAffected()
+		if err != nil {
+			return apperrors.WithStack(err)
+		}
+		changed = int(n)
+
+		return nil
+	})
+	if err != nil {
+		return 0, apperrors.WithStack(err)
+	}
+
+	return changed, nil
+}
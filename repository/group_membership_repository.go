@@ -0,0 +1,135 @@
+package repository
+
+import (
+	"context"
+	"go02/model"
+	"go02/packages/apperrors"
+	"go02/packages/db"
+
+	"github.com/uptrace/bun"
+)
+
+type GroupMembershipRepository interface {
+	Upsert(ctx context.Context, memberships []model.GroupMembership) error
+	Delete(ctx context.Context, groupID int, userIDs []int) (int, error)
+	GetByGroupID(ctx context.Context, groupID int) ([]model.GroupMembership, error)
+	GetByUserIDs(ctx context.Context, groupID int, userIDs []int) ([]model.GroupMembership, error)
+	CountOwners(ctx context.Context, groupID int) (int, error)
+	GetByUserID(ctx context.Context, userID int) ([]model.GroupMembership, error)
+}
+
+type groupMembershipRepository struct {
+	conn *bun.DB
+}
+
+func NewGroupMembershipRepository(conn *bun.DB) GroupMembershipRepository {
+	return &groupMembershipRepository{
+		conn: conn,
+	}
+}
+
+// Upsert メンバーを追加する。既にメンバーならroleを更新する
+func (r *groupMembershipRepository) Upsert(ctx context.Context, memberships []model.GroupMembership) error {
+	if len(memberships) == 0 {
+		return nil
+	}
+
+	tx := db.GetTxOrDB(ctx, r.conn)
+	_, err := tx.NewInsert().Model(&memberships).
+		On("CONFLICT (group_id, user_id) DO UPDATE").
+		Set("role = EXCLUDED.role").
+		Set("updated_at = EXCLUDED.updated_at").
+		Exec(ctx)
+	if err != nil {
+		return apperrors.WithStack(db.TranslateError(err))
+	}
+
+	return nil
+}
+
+// Delete メンバーを削除し、削除した件数を返す
+func (r *groupMembershipRepository) Delete(ctx context.Context, groupID int, userIDs []int) (int, error) {
+	if len(userIDs) == 0 {
+		return 0, nil
+	}
+
+	tx := db.GetTxOrDB(ctx, r.conn)
+	res, err := tx.NewDelete().Model((*model.GroupMembership)(nil)).
+		Where("group_id = ?", groupID).
+		Where("user_id IN (?)", bun.In(userIDs)).
+		Exec(ctx)
+	if err != nil {
+		return 0, apperrors.WithStack(err)
+	}
+
+	n, err := res.RowsAffected()
+	if err != nil {
+		return 0, apperrors.WithStack(err)
+	}
+
+	return int(n), nil
+}
+
+// GetByGroupID グループのメンバーを取得
+func (r *groupMembershipRepository) GetByGroupID(ctx context.Context, groupID int) ([]model.GroupMembership, error) {
+	var memberships []model.GroupMembership
+
+	tx := db.GetTxOrDB(ctx, r.conn)
+	err := tx.NewSelect().Model(&memberships).Where("group_id = ?", groupID).Order("user_id").Scan(ctx)
+	if err != nil {
+		return nil, apperrors.WithStack(err)
+	}
+
+	return memberships, nil
+}
+
+// GetByUserIDs グループのメンバーのうち、指定したユーザーのものを取得
+func (r *groupMembershipRepository) GetByUserIDs(ctx context.Context, groupID int, userIDs []int) ([]model.GroupMembership, error) {
+	var memberships []model.GroupMembership
+	if len(userIDs) == 0 {
+		return memberships, nil
+	}
+
+	tx := db.GetTxOrDB(ctx, r.conn)
+	err := tx.NewSelect().Model(&memberships).
+		Where("group_id = ?", groupID).
+		Where("user_id IN (?)", bun.In(userIDs)).
+		Order("user_id").
+		Scan(ctx)
+	if err != nil {
+		return nil, apperrors.WithStack(err)
+	}
+
+	return memberships, nil
+}
+
+// CountOwners グループのオーナーの人数を取得
+func (r *groupMembershipRepository) CountOwners(ctx context.Context, groupID int) (int, error) {
+	tx := db.GetTxOrDB(ctx, r.conn)
+	n, err := tx.NewSelect().Model((*model.GroupMembership)(nil)).
+		Where("group_id = ?", groupID).
+		Where("role = ?", model.RoleOwner).
+		Count(ctx)
+	if err != nil {
+		return 0, apperrors.WithStack(err)
+	}
+
+	return n, nil
+}
+
+// GetByUserID ユーザーが所属するグループをメンバーシップと一緒に取得
+func (r *groupMembershipRepository) GetByUserID(ctx context.Context, userID int) ([]model.GroupMembership, error) {
+	var memberships []model.GroupMembership
+
+	tx := db.GetTxOrDB(ctx, r.conn)
+	err := tx.NewSelect().Model(&memberships).
+		Relation("Group").
+		Where("?TableAlias.user_id = ?", userID).
+		OrderExpr(`"group"."name"`).
+		Scan(ctx)
+	if err != nil {
+		return nil, apperrors.WithStack(err)
+	}
+
+	return memberships, nil
+}
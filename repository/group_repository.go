@@ -0,0 +1,108 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"go02/model"
+	"go02/packages/apperrors"
+	"go02/packages/db"
+
+	"github.com/cockroachdb/errors"
+	"github.com/uptrace/bun"
+)
+
+type GroupRepository interface {
+	Create(ctx context.Context, group *model.Group) (int, error)
+	Update(ctx context.Context, group *model.Group) error
+	Delete(ctx context.Context, groupID int) error
+	GetList(ctx context.Context, limit int, offset int) ([]model.Group, error)
+	GetOne(ctx context.Context, groupID int) (model.Group, error)
+	GetOneForUpdate(ctx context.Context, groupID int) (model.Group, error)
+}
+
+type groupRepository struct {
+	conn *bun.DB
+}
+
+func NewGroupRepository(conn *bun.DB) GroupRepository {
+	return &groupRepository{
+		conn: conn,
+	}
+}
+
+// Create Groupの新規作成
+func (r *groupRepository) Create(ctx context.Context, group *model.Group) (int, error) {
+	tx := db.GetTxOrDB(ctx, r.conn)
+	if _, err := tx.NewInsert().Model(group).Exec(ctx); err != nil {
+		return 0, apperrors.WithStack(db.TranslateError(err))
+	}
+
+	return group.ID, nil
+}
+
+// Update Groupの更新
+func (r *groupRepository) Update(ctx context.Context, group *model.Group) error {
+	tx := db.GetTxOrDB(ctx, r.conn)
+	if _, err := tx.NewUpdate().Model(group).WherePK().Exec(ctx); err != nil {
+		return apperrors.WithStack(db.TranslateError(err))
+	}
+
+	return nil
+}
+
+// Delete Groupの削除 (メンバーシップも削除される)
+func (r *groupRepository) Delete(ctx context.Context, groupID int) error {
+	tx := db.GetTxOrDB(ctx, r.conn)
+	res, err := tx.NewDelete().Model((*model.Group)(nil)).Where("id = ?", groupID).Exec(ctx)
+	if err != nil {
+		return apperrors.WithStack(err)
+	}
+
+	if n, err := res.RowsAffected(); err == nil && n == 0 {
+		return apperrors.WithStack(apperrors.ErrNotFound)
+	}
+
+	return nil
+}
+
+// GetList Groupの複数件取得
+func (r *groupRepository) GetList(ctx context.Context, limit int, offset int) ([]model.Group, error) {
+	groups := make([]model.Group, 0, limit)
+
+	tx := db.GetTxOrDB(ctx, r.conn)
+	if err := tx.NewSelect().Model(&groups).Order("id").Limit(limit).Offset(offset).Scan(ctx); err != nil {
+		return []model.Group{}, apperrors.WithStack(err)
+	}
+
+	return groups, nil
+}
+
+// GetOne Groupを1件取得
+func (r *groupRepository) GetOne(ctx context.Context, groupID int) (model.Group, error) {
+	return r.getOne(ctx, groupID, false)
+}
+
+// GetOneForUpdate Groupを1件取得し、トランザクションの間ロックする
+func (r *groupRepository) GetOneForUpdate(ctx context.Context, groupID int) (model.Group, error) {
+	return r.getOne(ctx, groupID, true)
+}
+
+func (r *groupRepository) getOne(ctx context.Context, groupID int, forUpdate bool) (model.Group, error) {
+	var group model.Group
+
+	tx := db.GetTxOrDB(ctx, r.conn)
+	q := tx.NewSelect().Model(&group).Where("id = ?", groupID)
+	if forUpdate {
+		q = q.For("UPDATE")
+	}
+
+	err := q.Scan(ctx)
+	if errors.Is(err, sql.ErrNoRows) {
+		return model.Group{}, apperrors.WithStack(apperrors.ErrNotFound)
+	}
+	if err != nil {
+		return model.Group{}, apperrors.WithStack(err)
+	}
+
+	return group, nil
+}
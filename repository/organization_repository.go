@@ -0,0 +1,67 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+
+	"go02/model"
+	"go02/packages/apperrors"
+	"go02/packages/db"
+
+	"github.com/cockroachdb/errors"
+	"github.com/uptrace/bun"
+)
+
+type OrganizationRepository interface {
+	Create(ctx context.Context, org *model.Organization) error
+	GetBySlug(ctx context.Context, slug string) (model.Organization, error)
+	GetIDs(ctx context.Context) ([]int, error)
+}
+
+type organizationRepository struct {
+	conn *bun.DB
+}
+
+func NewOrganizationRepository(conn *bun.DB) OrganizationRepository {
+	return &organizationRepository{
+		conn: conn,
+	}
+}
+
+// Create Organizationの新規作成
+func (r *organizationRepository) Create(ctx context.Context, org *model.Organization) error {
+	tx := db.GetTxOrDB(ctx, r.conn)
+	if _, err := tx.NewInsert().Model(org).Exec(ctx); err != nil {
+		return apperrors.WithStack(db.TranslateError(err))
+	}
+
+	return nil
+}
+
+// GetBySlug slugでOrganizationを1件取得
+func (r *organizationRepository) GetBySlug(ctx context.Context, slug string) (model.Organization, error) {
+	var org model.Organization
+
+	tx := db.GetTxOrDB(ctx, r.conn)
+	err := tx.NewSelect().Model(&org).Where("slug = ?", slug).Scan(ctx)
+	if errors.Is(err, sql.ErrNoRows) {
+		return model.Organization{}, apperrors.WithStack(apperrors.ErrNotFound)
+	}
+	if err != nil {
+		return model.Organization{}, apperrors.WithStack(err)
+	}
+
+	return org, nil
+}
+
+// GetIDs 全OrganizationのIDを取得
+func (r *organizationRepository) GetIDs(ctx context.Context) ([]int, error) {
+	var ids []int
+
+	tx := db.GetTxOrDB(ctx, r.conn)
+	if err := tx.NewSelect().Model((*model.Organization)(nil)).Column("id").Order("id").Scan(ctx, &ids); err != nil {
+		return nil, apperrors.WithStack(err)
+	}
+
+	return ids, nil
+}
@@ -49,7 +49,8 @@ func (r *profileRepository) Update(ctx context.Context, profile *model.Profile)
 }
 
 func (r *profileRepository) Delete(ctx context.Context, profileID int) error {
-	_, err := r.conn.NewDelete().Model(&model.Profile{}).Where("id = ?", profileID).Exec(ctx)
+	tx := db.GetTxOrDB(ctx, r.conn)
+	_, err := tx.NewDelete().Model(&model.Profile{}).Where("id = ?", profileID).Exec(ctx)
 	if err != nil {
 		return apperrors.WithStack(err)
 	}
@@ -60,7 +61,8 @@ func (r *profileRepository) Delete(ctx context.Context, profileID int) error {
 func (r *profileRepository) GetProfileByUserID(ctx context.Context, userID int) (model.Profile, error) {
 	var profile model.Profile
 
-	if err := r.conn.NewSelect().Model(&profile).Where("user_id = ?", userID).Scan(ctx); err != nil {
+	tx := db.GetTxOrDB(ctx, r.conn)
+	if err := tx.NewSelect().Model(&profile).Where("user_id = ?", userID).Scan(ctx); err != nil {
 		return model.Profile{}, apperrors.WithStack(err)
 	}
 
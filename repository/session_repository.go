@@ -0,0 +1,108 @@
+package repository
+
+import (
+	"bytes"
+	"context"
+	"database/sql"
+	"time"
+
+	"go02/model"
+	"go02/packages/apperrors"
+	"go02/packages/db"
+
+	"github.com/cockroachdb/errors"
+	"github.com/uptrace/bun"
+)
+
+type SessionRepository interface {
+	Create(ctx context.Context, session *model.Session) error
+	GetOne(ctx context.Context, sessionID int) (model.Session, error)
+	// GetByRefreshTokenHashForUpdate also finds the session by its previous
+	// refresh token, reporting that with reused.
+	GetByRefreshTokenHashForUpdate(ctx context.Context, hash []byte) (session model.Session, reused bool, err error)
+	Update(ctx context.Context, session *model.Session) error
+	RevokeByUserID(ctx context.Context, userID int, now time.Time) error
+}
+
+type sessionRepository struct {
+	conn *bun.DB
+}
+
+func NewSessionRepository(conn *bun.DB) SessionRepository {
+	return &sessionRepository{
+		conn: conn,
+	}
+}
+
+// Create セッションの保存
+func (r *sessionRepository) Create(ctx context.Context, session *model.Session) error {
+	tx := db.GetTxOrDB(ctx, r.conn)
+	if _, err := tx.NewInsert().Model(session).Exec(ctx); err != nil {
+		return apperrors.WithStack(db.TranslateError(err))
+	}
+
+	return nil
+}
+
+// GetOne セッションを1件取得
+func (r *sessionRepository) GetOne(ctx context.Context, sessionID int) (model.Session, error) {
+	var session model.Session
+
+	tx := db.GetTxOrDB(ctx, r.conn)
+	err := tx.NewSelect().Model(&session).Where("id = ?", sessionID).Scan(ctx)
+	if errors.Is(err, sql.ErrNoRows) {
+		return model.Session{}, apperrors.WithStack(apperrors.ErrNotFound)
+	}
+	if err != nil {
+		return model.Session{}, apperrors.WithStack(err)
+	}
+
+	return session, nil
+}
+
+// GetByRefreshTokenHashForUpdate リフレッシュトークンでセッションを取得し、トランザクションの間ロックする
+func (r *sessionRepository) GetByRefreshTokenHashForUpdate(ctx context.Context, hash []byte) (model.Session, bool, error) {
+	var session model.Session
+
+	tx := db.GetTxOrDB(ctx, r.conn)
+	err := tx.NewSelect().Model(&session).
+		Where("refresh_token_hash = ?", hash).
+		WhereOr("previous_refresh_token_hash = ?", hash).
+		Limit(1).
+		For("UPDATE").
+		Scan(ctx)
+	if errors.Is(err, sql.ErrNoRows) {
+		return model.Session{}, false, apperrors.WithStack(apperrors.ErrNotFound)
+	}
+	if err != nil {
+		return model.Session{}, false, apperrors.WithStack(err)
+	}
+
+	return session, !bytes.Equal(session.RefreshTokenHash, hash), nil
+}
+
+// Update セッションの更新
+func (r *sessionRepository) Update(ctx context.Context, session *model.Session) error {
+	tx := db.GetTxOrDB(ctx, r.conn)
+	if _, err := tx.NewUpdate().Model(session).WherePK().Exec(ctx); err != nil {
+		return apperrors.WithStack(err)
+	}
+
+	return nil
+}
+
+// RevokeByUserID ユーザーの有効なセッションをすべて失効させる
+func (r *sessionRepository) RevokeByUserID(ctx context.Context, userID int, now time.Time) error {
+	tx := db.GetTxOrDB(ctx, r.conn)
+	_, err := tx.NewUpdate().Model((*model.Session)(nil)).
+		Set("revoked_at = ?", now.UTC()).
+		Set("updated_at = ?", now.UTC()).
+		Where("user_id = ?", userID).
+		Where("revoked_at IS NULL").
+		Exec(ctx)
+	if err != nil {
+		return apperrors.WithStack(err)
+	}
+
+	return nil
+}
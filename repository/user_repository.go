@@ -2,14 +2,14 @@ package repository
 
 import (
 	"context"
+	"database/sql"
 	"go02/model"
 	"go02/packages/apperrors"
 	"go02/packages/db"
+	"time"
 
 	"github.com/cockroachdb/errors"
 	"github.com/uptrace/bun"
-	"go.opentelemetry.io/otel"
-	"go.opentelemetry.io/otel/attribute"
 )
 
 type UserRepository interface {
@@ -18,6 +18,10 @@ type UserRepository interface {
 	Delete(ctx context.Context, userID int) error
 	GetList(ctx context.Context, limit int, offset int) ([]model.User, error)
 	GetOne(ctx context.Context, userID int) (model.User, error)
+	GetOneForUpdate(ctx context.Context, userID int) (model.User, error)
+	GetByIDs(ctx context.Context, userIDs []int) ([]model.User, error)
+	GetByHandle(ctx context.Context, handle string) (model.User, error)
+	GetByEmail(ctx context.Context, email string) (model.User, error)
 }
 
 type userRepository struct {
@@ -36,7 +40,7 @@ func (r *userRepository) Create(ctx context.Context, user *model.User) (int, err
 	tx := db.GetTxOrDB(ctx, r.conn)
 	_, err := tx.NewInsert().Model(user).Exec(ctx)
 	if err != nil {
-		return 0, apperrors.WithStack(err)
+		return 0, apperrors.WithStack(db.TranslateError(err))
 	}
 
 	return user.ID, nil
@@ -48,15 +52,33 @@ func (r *userRepository) Update(ctx context.Context, user *model.User) error {
 	tx := db.GetTxOrDB(ctx, r.conn)
 	_, err := tx.NewUpdate().Model(user).WherePK().Exec(ctx)
 	if err != nil {
-		return apperrors.WithStack(err)
+		return apperrors.WithStack(db.TranslateError(err))
 	}
 
 	return nil
 }
 
-// Delete Userの削除
+// Delete Userの削除。フォローしていた・されていたユーザーのフォロー数は次の照合で数え直す
 func (r *userRepository) Delete(ctx context.Context, userID int) error {
-	_, err := r.conn.NewDelete().Model(&model.User{}).Where("id = ?", userID).Exec(ctx)
+	tx := db.GetTxOrDB(ctx, r.conn)
+	res, err := tx.NewDelete().Model(&model.User{}).Where("id = ?", userID).Exec(ctx)
+	if err != nil {
+		return apperrors.WithStack(err)
+	}
+	if n, err := res.RowsAffected(); err != nil || n == 0 {
+		return apperrors.WithStack(err)
+	}
+
+	// follows of soft deleted users aren't counted, see
+	// FollowRepository.ReconcileCounts
+	_, err = tx.NewRaw(`
+		INSERT INTO user_follow_count_changes (user_id, org_id, changed_at)
+		SELECT u.id, u.org_id, ? FROM users AS u
+		WHERE u.id IN (
+			SELECT followee_id FROM user_follows WHERE follower_id = ?
+			UNION SELECT follower_id FROM user_follows WHERE followee_id = ?)`,
+		time.Now().UTC(), userID, userID,
+	).Exec(ctx)
 	if err != nil {
 		return apperrors.WithStack(err)
 	}
@@ -64,18 +86,12 @@ func (r *userRepository) Delete(ctx context.Context, userID int) error {
 	return nil
 }
 
-// GetList Userの複数件取得
+// GetList Userをフォロー数と一緒に複数件取得
 func (r *userRepository) GetList(ctx context.Context, limit int, offset int) ([]model.User, error) {
-	tracer := otel.Tracer("repository")
-	ctx, span := tracer.Start(ctx, "userRepository.GetList")
-	defer span.End()
-
-	span.SetAttributes(attribute.String("db.operation", "select"))
-	span.SetAttributes(attribute.String("db.table", "users"))
-
 	users := make([]model.User, 0, limit)
 
-	if err := r.conn.NewSelect().Model(&users).Limit(limit).Offset(offset).Scan(ctx); err != nil {
+	tx := db.GetTxOrDB(ctx, r.conn)
+	if err := tx.NewSelect().Model(&users).Relation("FollowCount").Limit(limit).Offset(offset).Scan(ctx); err != nil {
 		return []model.User{}, errors.WithStack(err)
 	}
 
@@ -86,11 +102,81 @@ func (r *userRepository) GetList(ctx context.Context, limit int, offset int) ([]
 	return users, nil
 }
 
-// GetOne Userを1件取得
+// GetOne Userをフォロー数と一緒に1件取得
 func (r *userRepository) GetOne(ctx context.Context, userID int) (model.User, error) {
 	var user model.User
 
-	if err := r.conn.NewSelect().Model(&user).Where("id = ?", userID).Scan(ctx); err != nil {
+	tx := db.GetTxOrDB(ctx, r.conn)
+	err := tx.NewSelect().Model(&user).Relation("FollowCount").Where("id = ?", userID).Scan(ctx)
+	if errors.Is(err, sql.ErrNoRows) {
+		return model.User{}, apperrors.WithStack(apperrors.ErrNotFound)
+	}
+	if err != nil {
+		return model.User{}, apperrors.WithStack(err)
+	}
+
+	return user, nil
+}
+
+// GetOneForUpdate Userを1件取得し、トランザクションの間ロックする
+func (r *userRepository) GetOneForUpdate(ctx context.Context, userID int) (model.User, error) {
+	var user model.User
+
+	tx := db.GetTxOrDB(ctx, r.conn)
+	err := tx.NewSelect().Model(&user).Where("id = ?", userID).For("UPDATE").Scan(ctx)
+	if errors.Is(err, sql.ErrNoRows) {
+		return model.User{}, apperrors.WithStack(apperrors.ErrNotFound)
+	}
+	if err != nil {
+		return model.User{}, apperrors.WithStack(err)
+	}
+
+	return user, nil
+}
+
+// GetByIDs idでUserを複数件取得 (存在しないidは無視する)
+func (r *userRepository) GetByIDs(ctx context.Context, userIDs []int) ([]model.User, error) {
+	users := make([]model.User, 0, len(userIDs))
+	if len(userIDs) == 0 {
+		return users, nil
+	}
+
+	tx := db.GetTxOrDB(ctx, r.conn)
+	if err := tx.NewSelect().Model(&users).Where("id IN (?)", bun.In(userIDs)).Scan(ctx); err != nil {
+		return nil, apperrors.WithStack(err)
+	}
+
+	return users, nil
+}
+
+// GetByHandle handleでUserを1件取得 (大文字小文字を区別しない)
+func (r *userRepository) GetByHandle(ctx context.Context, handle string) (model.User, error) {
+	var user model.User
+
+	// lower(handle) and the tenant filter match the users_org_id_handle_key index
+	tx := db.GetTxOrDB(ctx, r.conn)
+	err := tx.NewSelect().Model(&user).Relation("FollowCount").Where("lower(handle) = lower(?)", handle).Scan(ctx)
+	if errors.Is(err, sql.ErrNoRows) {
+		return model.User{}, apperrors.WithStack(apperrors.ErrNotFound)
+	}
+	if err != nil {
+		return model.User{}, apperrors.WithStack(err)
+	}
+
+	return user, nil
+}
+
+// GetByEmail emailでUserを1件取得 (大文字小文字を区別しない)
+func (r *userRepository) GetByEmail(ctx context.Context, email string) (model.User, error) {
+	var user model.User
+
+	// lower(email) and the tenant filter match the users_org_id_email_key index
+	tx := db.GetTxOrDB(ctx, r.conn)
+	err := tx.NewSelect().Model(&user).Where("lower(email) = lower(?)", email).Scan(ctx)
+	if errors.Is(err, sql.ErrNoRows) {
+		return model.User{}, apperrors.WithStack(apperrors.ErrNotFound)
+	}
+	if err != nil {
 		return model.User{}, apperrors.WithStack(err)
 	}
 
@@ -0,0 +1,45 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"go02/model"
+	"go02/packages/db"
+
+	"github.com/cockroachdb/errors"
+)
+
+func schemaCommand(ctx context.Context, args []string) error {
+	if len(args) != 1 || args[0] != "check" {
+		return errors.Newf("expected schema check\n\n%s", usage)
+	}
+
+	cfg, ctx, err := loadForCommand(ctx)
+	if err != nil {
+		return err
+	}
+
+	conn, err := db.Open(db.Options{Config: cfg.DB})
+	if err != nil {
+		return err
+	}
+	defer conn.Close()
+
+	if err := db.WaitReady(ctx, conn, cfg.DB); err != nil {
+		return err
+	}
+
+	issues, err := db.CheckSchema(ctx, conn, model.All()...)
+	if err != nil {
+		return err
+	}
+	for _, issue := range issues {
+		fmt.Println(issue)
+	}
+	if len(issues) > 0 {
+		return errors.Newf("schema has drifted from the models: %d issues", len(issues))
+	}
+
+	fmt.Println("schema matches the models")
+	return nil
+}
@@ -3,13 +3,13 @@ package testutils
 import (
 	"context"
 	"database/sql"
+	"go02/model"
+	"go02/packages/db"
 	"log"
-	"path/filepath"
-	"runtime"
+	"net/url"
 	"testing"
 	"time"
 
-	"github.com/golang-migrate/migrate/v4"
 	"github.com/testcontainers/testcontainers-go"
 	"github.com/testcontainers/testcontainers-go/modules/postgres"
 	"github.com/testcontainers/testcontainers-go/wait"
@@ -82,18 +82,43 @@ func OpenDBForTest(t *testing.T, dsn string) (*bun.DB, error) {
 func MigrateUp(t *testing.T, dsn string) error {
 	t.Helper()
 
-	_, filename, _, _ := runtime.Caller(0)
-	projectRoot := filepath.Dir(filepath.Dir(filename))
-	migrationsPath := filepath.Join(projectRoot, "db-migration", "migrations")
-	sourceURL := "file://" + migrationsPath
-
-	m, err := migrate.New(sourceURL, dsn)
+	m, err := db.NewMigrator(context.Background(), dsn)
 	if err != nil {
 		return err
 	}
-	if err := m.Up(); err != nil && err != migrate.ErrNoChange {
-		return err
+	defer m.Close()
+
+	return m.Up()
+}
+
+// OpenTenantRoleForTest creates a role that owns no tables and connects as
+// it. Unlike the owner of the container, the row level security policies
+// apply to it, as they do to the api in production.
+func OpenTenantRoleForTest(t *testing.T, conn *bun.DB, dsn string) (*bun.DB, error) {
+	t.Helper()
+
+	ctx := context.Background()
+	for _, query := range []string{
+		"CREATE ROLE app LOGIN PASSWORD 'app'",
+		"GRANT SELECT, INSERT, UPDATE, DELETE ON ALL TABLES IN SCHEMA public TO app",
+		"GRANT USAGE ON ALL SEQUENCES IN SCHEMA public TO app",
+	} {
+		if _, err := conn.ExecContext(ctx, query); err != nil {
+			return nil, err
+		}
 	}
 
-	return nil
+	u, err := url.Parse(dsn)
+	if err != nil {
+		return nil, err
+	}
+	u.User = url.UserPassword("app", "app")
+
+	return OpenDBForTest(t, u.String())
+}
+
+// DefaultTenant scopes the queries made with ctx to the default organization,
+// which every migrated database has.
+func DefaultTenant(ctx context.Context) context.Context {
+	return db.WithTenant(ctx, model.DefaultOrgID)
 }
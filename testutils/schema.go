@@ -0,0 +1,24 @@
+package testutils
+
+import (
+	"context"
+	"go02/model"
+	"go02/packages/db"
+	"testing"
+
+	"github.com/uptrace/bun"
+)
+
+// AssertSchema fails the test for every difference between the migrated
+// database and the bun models.
+func AssertSchema(t *testing.T, conn *bun.DB) {
+	t.Helper()
+
+	issues, err := db.CheckSchema(context.Background(), conn, model.All()...)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, issue := range issues {
+		t.Errorf("schema drift: %s", issue)
+	}
+}
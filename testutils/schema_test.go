@@ -0,0 +1,21 @@
+package testutils_test
+
+import (
+	"context"
+	"go02/testutils"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestMigratedSchemaMatchesModels(t *testing.T) {
+	ctx := context.Background()
+	container := testutils.PrepareContainer(ctx, t)
+	defer container.TearDown()
+
+	conn, err := testutils.OpenDBForTest(t, container.DSN)
+	require.NoError(t, err)
+	require.NoError(t, testutils.MigrateUp(t, container.DSN))
+
+	testutils.AssertSchema(t, conn)
+}
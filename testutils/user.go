@@ -15,7 +15,7 @@ func PrepareTestDataForTestGetUserList(t *testing.T, db *bun.DB, users []model.U
 		return
 	}
 
-	ctx := context.Background()
+	ctx := DefaultTenant(context.Background())
 	if _, err := db.NewInsert().Model(&users).Exec(ctx); err != nil {
 		t.Fatal(err)
 	}
@@ -0,0 +1,271 @@
+package usecase
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"time"
+
+	"go02/model"
+	"go02/packages/apperrors"
+	"go02/packages/auth"
+	"go02/packages/logging"
+	"go02/packages/metrics"
+	"go02/repository"
+	"log/slog"
+)
+
+// AuthUsecase ログインとセッションのusecaseのinterface
+type AuthUsecase interface {
+	Login(ctx context.Context, login string, password string) (ResToken, error)
+	Refresh(ctx context.Context, refreshToken string) (ResToken, error)
+	Logout(ctx context.Context, sessionID int) error
+	Authenticate(ctx context.Context, accessToken string) (auth.Principal, error)
+}
+
+// LoginPolicy is how long sessions last and when failed logins lock a
+// credential.
+type LoginPolicy struct {
+	SessionTTL        time.Duration
+	MaxFailedAttempts int
+	LockoutDuration   time.Duration
+}
+
+type authUsecase struct {
+	transactionRepository repository.TransactionRepository
+	userRepository        repository.UserRepository
+	credentialRepository  repository.CredentialRepository
+	sessionRepository     repository.SessionRepository
+	signer                *auth.Signer
+	policy                LoginPolicy
+	metrics               *metrics.Usecase
+}
+
+// NewAuthUsecase Auth usecaseのコンストラクタ
+func NewAuthUsecase(
+	transactionRepository repository.TransactionRepository,
+	userRepository repository.UserRepository,
+	credentialRepository repository.CredentialRepository,
+	sessionRepository repository.SessionRepository,
+	signer *auth.Signer,
+	policy LoginPolicy,
+	metrics *metrics.Usecase,
+) AuthUsecase {
+	return &authUsecase{
+		transactionRepository: transactionRepository,
+		userRepository:        userRepository,
+		credentialRepository:  credentialRepository,
+		sessionRepository:     sessionRepository,
+		signer:                signer,
+		policy:                policy,
+		metrics:               metrics,
+	}
+}
+
+type ResToken struct {
+	AccessToken  string `json:"access_token" log:"drop"`
+	TokenType    string `json:"token_type" log:"allow"`
+	ExpiresIn    int    `json:"expires_in" log:"allow"`
+	RefreshToken string `json:"refresh_token" log:"drop"`
+}
+
+// Login checks the password of the user with the login handle or email and
+// starts a session.
+func (u *authUsecase) Login(ctx context.Context, login string, password string) (_ ResToken, err error) {
+	defer u.metrics.Start(ctx, "authUsecase.Login").End(&err)
+
+	user, err := u.findUser(ctx, login)
+	if errors.Is(err, apperrors.ErrNotFound) {
+		auth.VerifyDummy(password)
+		return ResToken{}, apperrors.WithStack(apperrors.ErrUnauthorized)
+	}
+	if err != nil {
+		return ResToken{}, apperrors.WithStack(err)
+	}
+
+	var (
+		res     ResToken
+		loginOK bool
+	)
+	// a failed attempt is committed too, the error is returned afterwards
+	err = u.transactionRepository.WithinTransaction(ctx, func(ctx context.Context) error {
+		credential, err := u.credentialRepository.GetByUserIDForUpdate(ctx, user.ID)
+		if errors.Is(err, apperrors.ErrNotFound) {
+			auth.VerifyDummy(password)
+			return nil
+		}
+		if err != nil {
+			return apperrors.WithStack(err)
+		}
+
+		now := time.Now()
+		ok, err := auth.VerifyPassword(credential.PasswordHash, password)
+		if err != nil {
+			return apperrors.WithStack(err)
+		}
+		// checked after the password, so that a locked account answers like
+		// a wrong password and in the same time: the response doesn't tell
+		// that the account exists
+		if credential.Locked(now) {
+			logging.Info(ctx, "rejected login of a locked credential", slog.Int("user_id", user.ID))
+			return nil
+		}
+		if !ok {
+			credential.RecordFailure(now, u.policy.MaxFailedAttempts, u.policy.LockoutDuration)
+			if credential.Locked(now) {
+				logging.Warn(ctx, "locked credential after failed logins", slog.Int("user_id", user.ID))
+			}
+			return apperrors.WithStack(u.credentialRepository.Update(ctx, &credential))
+		}
+
+		credential.RecordSuccess()
+		if err := u.credentialRepository.Update(ctx, &credential); err != nil {
+			return apperrors.WithStack(err)
+		}
+
+		session, refreshToken, err := model.NewSession(user.ID, u.policy.SessionTTL)
+		if err != nil {
+			return apperrors.WithStack(err)
+		}
+		if err := u.sessionRepository.Create(ctx, session); err != nil {
+			return apperrors.WithStack(err)
+		}
+
+		res, err = u.issue(session, user.OrgID, refreshToken, now)
+		loginOK = err == nil
+		return apperrors.WithStack(err)
+	})
+	if err != nil {
+		return ResToken{}, apperrors.WithStack(err)
+	}
+	if !loginOK {
+		return ResToken{}, apperrors.WithStack(apperrors.ErrUnauthorized)
+	}
+
+	return res, nil
+}
+
+func (u *authUsecase) findUser(ctx context.Context, login string) (model.User, error) {
+	if strings.Contains(login, "@") {
+		email, err := model.NormalizeEmail(login)
+		if err != nil {
+			return model.User{}, apperrors.WithStack(apperrors.ErrNotFound)
+		}
+		return u.userRepository.GetByEmail(ctx, email)
+	}
+	return u.userRepository.GetByHandle(ctx, login)
+}
+
+// Refresh exchanges a refresh token for new access and refresh tokens. A
+// refresh token that was already exchanged means it leaked, so the session is
+// revoked.
+func (u *authUsecase) Refresh(ctx context.Context, refreshToken string) (_ ResToken, err error) {
+	defer u.metrics.Start(ctx, "authUsecase.Refresh").End(&err)
+
+	var (
+		res    ResToken
+		reused bool
+	)
+	err = u.transactionRepository.WithinTransaction(ctx, func(ctx context.Context) error {
+		var (
+			session model.Session
+			err     error
+		)
+		session, reused, err = u.sessionRepository.GetByRefreshTokenHashForUpdate(ctx, model.HashToken(refreshToken))
+		if errors.Is(err, apperrors.ErrNotFound) {
+			return apperrors.WithStack(apperrors.ErrUnauthorized)
+		}
+		if err != nil {
+			return apperrors.WithStack(err)
+		}
+
+		now := time.Now()
+		if reused {
+			if session.Active(now) {
+				logging.Warn(ctx, "revoked session after refresh token reuse", slog.Int("session_id", session.ID))
+			}
+			session.Revoke(now)
+			return apperrors.WithStack(u.sessionRepository.Update(ctx, &session))
+		}
+		if !session.Active(now) {
+			return apperrors.WithStack(apperrors.ErrUnauthorized)
+		}
+
+		// the user isn't found when refreshing in another organization
+		user, err := u.userRepository.GetOne(ctx, session.UserID)
+		if errors.Is(err, apperrors.ErrNotFound) {
+			return apperrors.WithStack(apperrors.ErrUnauthorized)
+		}
+		if err != nil {
+			return apperrors.WithStack(err)
+		}
+
+		newToken, err := session.Rotate()
+		if err != nil {
+			return apperrors.WithStack(err)
+		}
+		if err := u.sessionRepository.Update(ctx, &session); err != nil {
+			return apperrors.WithStack(err)
+		}
+
+		res, err = u.issue(&session, user.OrgID, newToken, now)
+		return apperrors.WithStack(err)
+	})
+	if err != nil {
+		return ResToken{}, apperrors.WithStack(err)
+	}
+	if reused {
+		return ResToken{}, apperrors.WithStack(apperrors.ErrUnauthorized)
+	}
+
+	return res, nil
+}
+
+func (u *authUsecase) issue(session *model.Session, orgID int, refreshToken string, now time.Time) (ResToken, error) {
+	accessToken, err := u.signer.Sign(auth.Principal{UserID: session.UserID, OrgID: orgID, SessionID: session.ID}, now)
+	if err != nil {
+		return ResToken{}, err
+	}
+
+	return ResToken{
+		AccessToken:  accessToken,
+		TokenType:    "Bearer",
+		ExpiresIn:    int(u.signer.TTL().Seconds()),
+		RefreshToken: refreshToken,
+	}, nil
+}
+
+// Logout revokes the session. Its access tokens stop working right away.
+func (u *authUsecase) Logout(ctx context.Context, sessionID int) (err error) {
+	defer u.metrics.Start(ctx, "authUsecase.Logout").End(&err)
+
+	session, err := u.sessionRepository.GetOne(ctx, sessionID)
+	if err != nil {
+		return apperrors.WithStack(err)
+	}
+
+	session.Revoke(time.Now())
+	return apperrors.WithStack(u.sessionRepository.Update(ctx, &session))
+}
+
+// Authenticate verifies an access token and checks that its session is still
+// active.
+func (u *authUsecase) Authenticate(ctx context.Context, accessToken string) (auth.Principal, error) {
+	p, err := u.signer.Verify(accessToken)
+	if err != nil {
+		return auth.Principal{}, apperrors.WithStack(errors.Join(apperrors.ErrUnauthorized, err))
+	}
+
+	session, err := u.sessionRepository.GetOne(ctx, p.SessionID)
+	if errors.Is(err, apperrors.ErrNotFound) {
+		return auth.Principal{}, apperrors.WithStack(apperrors.ErrUnauthorized)
+	}
+	if err != nil {
+		return auth.Principal{}, apperrors.WithStack(err)
+	}
+	if session.UserID != p.UserID || !session.Active(time.Now()) {
+		return auth.Principal{}, apperrors.WithStack(apperrors.ErrUnauthorized)
+	}
+
+	return p, nil
+}
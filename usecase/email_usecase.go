@@ -0,0 +1,180 @@
+package usecase
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/url"
+	"time"
+
+	"go02/model"
+	"go02/packages/apperrors"
+	"go02/packages/logging"
+	"go02/packages/mail"
+	"go02/packages/metrics"
+	"go02/repository"
+)
+
+// EmailUsecase メールアドレス確認のusecaseのinterface
+type EmailUsecase interface {
+	RequestVerification(ctx context.Context, userID int) error
+	Verify(ctx context.Context, token string) error
+}
+
+type emailUsecase struct {
+	transactionRepository       repository.TransactionRepository
+	userRepository              repository.UserRepository
+	emailVerificationRepository repository.EmailVerificationRepository
+	mailer                      mail.Mailer
+	verifyURL                   string
+	tokenTTL                    time.Duration
+	requestInterval             time.Duration
+	metrics                     *metrics.Usecase
+}
+
+// NewEmailUsecase Email usecaseのコンストラクタ。verifyURL is the page the
+// token is linked to, tokenTTL how long a token stays usable and
+// requestInterval how long a user waits before another token is mailed.
+func NewEmailUsecase(
+	transactionRepository repository.TransactionRepository,
+	userRepository repository.UserRepository,
+	emailVerificationRepository repository.EmailVerificationRepository,
+	mailer mail.Mailer,
+	verifyURL string,
+	tokenTTL time.Duration,
+	requestInterval time.Duration,
+	metrics *metrics.Usecase,
+) EmailUsecase {
+	return &emailUsecase{
+		transactionRepository:       transactionRepository,
+		userRepository:              userRepository,
+		emailVerificationRepository: emailVerificationRepository,
+		mailer:                      mailer,
+		verifyURL:                   verifyURL,
+		tokenTTL:                    tokenTTL,
+		requestInterval:             requestInterval,
+		metrics:                     metrics,
+	}
+}
+
+var errInvalidToken = &apperrors.ValidationError{Field: "token", Reason: "is invalid or expired"}
+
+// RequestVerification mails a new token to the user's email. Tokens issued
+// before stop working. A user asking again within the request interval is
+// apperrors.ErrRateLimited.
+func (u *emailUsecase) RequestVerification(ctx context.Context, userID int) (err error) {
+	defer u.metrics.Start(ctx, "emailUsecase.RequestVerification").End(&err)
+
+	var (
+		user model.User
+		raw  string
+	)
+	err = u.transactionRepository.WithinTransaction(ctx, func(ctx context.Context) error {
+		var err error
+		// concurrent requests of the user wait here, so that only one of them
+		// mails a token
+		user, err = u.userRepository.GetOneForUpdate(ctx, userID)
+		if err != nil {
+			return apperrors.WithStack(err)
+		}
+		switch {
+		case user.Email == "":
+			return apperrors.WithStack(&apperrors.ValidationError{Field: "email", Reason: "is not set"})
+		case user.EmailVerified():
+			return apperrors.WithStack(&apperrors.ValidationError{Field: "email", Reason: "is already verified"})
+		}
+
+		latest, err := u.emailVerificationRepository.GetLatestByUserID(ctx, user.ID)
+		if err != nil && !errors.Is(err, apperrors.ErrNotFound) {
+			return apperrors.WithStack(err)
+		}
+		if err == nil && time.Since(latest.CreatedAt) < u.requestInterval {
+			return apperrors.WithStack(apperrors.ErrRateLimited)
+		}
+
+		var token *model.EmailVerificationToken
+		token, raw, err = model.NewEmailVerificationToken(user.ID, user.Email, u.tokenTTL)
+		if err != nil {
+			return apperrors.WithStack(err)
+		}
+		if err := u.emailVerificationRepository.DeleteByUserID(ctx, user.ID); err != nil {
+			return apperrors.WithStack(err)
+		}
+		return apperrors.WithStack(u.emailVerificationRepository.Create(ctx, token))
+	})
+	if err != nil {
+		return apperrors.WithStack(err)
+	}
+
+	link, err := url.Parse(u.verifyURL)
+	if err != nil {
+		return apperrors.WithStack(err)
+	}
+	q := link.Query()
+	q.Set("token", raw)
+	link.RawQuery = q.Encode()
+
+	err = u.mailer.Send(ctx, mail.Message{
+		To:      user.Email,
+		Subject: "Verify your email address",
+		Body: fmt.Sprintf("Open the link below to verify your email address. It expires in %s.\n\n%s\n",
+			u.tokenTTL, link),
+	})
+	if err != nil {
+		return apperrors.WithStack(err)
+	}
+
+	logging.Info(ctx, "sent email verification")
+
+	return nil
+}
+
+// Verify marks the email the token was sent to as verified, as long as it is
+// still the user's email.
+func (u *emailUsecase) Verify(ctx context.Context, raw string) (err error) {
+	defer u.metrics.Start(ctx, "emailUsecase.Verify").End(&err)
+
+	if raw == "" {
+		return apperrors.WithStack(errInvalidToken)
+	}
+
+	err = u.transactionRepository.WithinTransaction(ctx, func(ctx context.Context) error {
+		token, err := u.emailVerificationRepository.GetByTokenHashForUpdate(ctx, model.HashToken(raw))
+		if errors.Is(err, apperrors.ErrNotFound) {
+			return apperrors.WithStack(errInvalidToken)
+		}
+		if err != nil {
+			return apperrors.WithStack(err)
+		}
+
+		now := time.Now().UTC()
+		if !token.Usable(now) {
+			return apperrors.WithStack(errInvalidToken)
+		}
+
+		// a user of another organization isn't found in this one
+		user, err := u.userRepository.GetOne(ctx, token.UserID)
+		if errors.Is(err, apperrors.ErrNotFound) {
+			return apperrors.WithStack(errInvalidToken)
+		}
+		if err != nil {
+			return apperrors.WithStack(err)
+		}
+		// the email changed after the token was sent
+		if user.Email != token.Email {
+			return apperrors.WithStack(errInvalidToken)
+		}
+
+		if err := u.emailVerificationRepository.MarkUsed(ctx, token.ID, now); err != nil {
+			return apperrors.WithStack(err)
+		}
+
+		user.EmailVerifiedAt = now
+		return apperrors.WithStack(u.userRepository.Update(ctx, &user))
+	})
+	if err != nil {
+		return apperrors.WithStack(err)
+	}
+
+	return nil
+}
@@ -0,0 +1,288 @@
+package usecase
+
+import (
+	"context"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"time"
+
+	"go02/model"
+	"go02/packages/apperrors"
+	"go02/packages/logging"
+	"go02/packages/metrics"
+	"go02/repository"
+
+	"github.com/samber/lo"
+)
+
+const (
+	defaultFollowPageSize = 20
+	maxFollowPageSize     = 100
+)
+
+// FollowUsecase フォロー関係のusecaseのinterface
+type FollowUsecase interface {
+	Follow(ctx context.Context, followerID int, followeeID int) error
+	Unfollow(ctx context.Context, followerID int, followeeID int) error
+	GetFollowers(ctx context.Context, userID int, cursor string, limit int) (ResFollowList, error)
+	GetFollowing(ctx context.Context, userID int, cursor string, limit int) (ResFollowList, error)
+	GetMutuals(ctx context.Context, userID int, cursor string, limit int) (ResFollowList, error)
+	ReconcileCounts(ctx context.Context) (int, error)
+}
+
+type followUsecase struct {
+	transactionRepository  repository.TransactionRepository
+	followRepository       repository.FollowRepository
+	userRepository         repository.UserRepository
+	organizationRepository repository.OrganizationRepository
+	metrics                *metrics.Usecase
+}
+
+// NewFollowUsecase Follow usecaseのコンストラクタ
+func NewFollowUsecase(
+	transactionRepository repository.TransactionRepository,
+	followRepository repository.FollowRepository,
+	userRepository repository.UserRepository,
+	organizationRepository repository.OrganizationRepository,
+	metrics *metrics.Usecase,
+) FollowUsecase {
+	return &followUsecase{
+		transactionRepository:  transactionRepository,
+		followRepository:       followRepository,
+		userRepository:         userRepository,
+		organizationRepository: organizationRepository,
+		metrics:                metrics,
+	}
+}
+
+type ResFollowUser struct {
+	ID         int       `json:"id" log:"allow"`
+	Name       string    `json:"name" log:"mask"`
+	Handle     string    `json:"handle" log:"allow"`
+	FollowedAt time.Time `json:"followed_at" log:"allow"`
+}
+type ResFollowList struct {
+	Users []ResFollowUser `json:"users" log:"allow"`
+	// pass as cursor for the next page; empty on the last page
+	NextCursor string `json:"next_cursor,omitempty" log:"allow"`
+}
+
+// Follow makes followerID follow followeeID. Following twice is a no-op.
+func (u *followUsecase) Follow(ctx context.Context, followerID int, followeeID int) (err error) {
+	defer u.metrics.Start(ctx, "followUsecase.Follow").End(&err)
+
+	follow, err := model.NewUserFollow(followerID, followeeID)
+	if err != nil {
+		return apperrors.WithStack(err)
+	}
+
+	// users of other organizations aren't found
+	if _, err := u.userRepository.GetOne(ctx, followeeID); err != nil {
+		return apperrors.WithStack(err)
+	}
+
+	err = u.transactionRepository.WithinTransaction(ctx, func(ctx context.Context) error {
+		if err := u.followRepository.Create(ctx, follow); err != nil {
+			return apperrors.WithStack(err)
+		}
+		return apperrors.WithStack(u.followRepository.MarkChanged(ctx, followerID, followeeID))
+	})
+	if err != nil {
+		return apperrors.WithStack(err)
+	}
+
+	return nil
+}
+
+// Unfollow makes followerID stop following followeeID. Unfollowing a user
+// that isn't followed is a no-op.
+func (u *followUsecase) Unfollow(ctx context.Context, followerID int, followeeID int) (err error) {
+	defer u.metrics.Start(ctx, "followUsecase.Unfollow").End(&err)
+
+	err = u.transactionRepository.WithinTransaction(ctx, func(ctx context.Context) error {
+		if err := u.followRepository.Delete(ctx, followerID, followeeID); err != nil {
+			return apperrors.WithStack(err)
+		}
+		return apperrors.WithStack(u.followRepository.MarkChanged(ctx, followerID, followeeID))
+	})
+	if err != nil {
+		return apperrors.WithStack(err)
+	}
+
+	return nil
+}
+
+func (u *followUsecase) GetFollowers(ctx context.Context, userID int, cursor string, limit int) (_ ResFollowList, err error) {
+	defer u.metrics.Start(ctx, "followUsecase.GetFollowers").End(&err)
+
+	return u.list(ctx, userID, cursor, limit, u.followRepository.GetFollowers, func(f model.UserFollow) *model.User {
+		return f.Follower
+	})
+}
+
+func (u *followUsecase) GetFollowing(ctx context.Context, userID int, cursor string, limit int) (_ ResFollowList, err error) {
+	defer u.metrics.Start(ctx, "followUsecase.GetFollowing").End(&err)
+
+	return u.list(ctx, userID, cursor, limit, u.followRepository.GetFollowing, func(f model.UserFollow) *model.User {
+		return f.Followee
+	})
+}
+
+// GetMutuals returns the users that follow userID and are followed back.
+func (u *followUsecase) GetMutuals(ctx context.Context, userID int, cursor string, limit int) (_ ResFollowList, err error) {
+	defer u.metrics.Start(ctx, "followUsecase.GetMutuals").End(&err)
+
+	return u.list(ctx, userID, cursor, limit, u.followRepository.GetMutuals, func(f model.UserFollow) *model.User {
+		return f.Follower
+	})
+}
+
+type followPage func(ctx context.Context, userID int, after repository.FollowCursor, limit int) ([]model.UserFollow, error)
+
+func (u *followUsecase) list(
+	ctx context.Context,
+	userID int,
+	cursor string,
+	limit int,
+	page followPage,
+	other func(model.UserFollow) *model.User,
+) (ResFollowList, error) {
+	res := ResFollowList{Users: []ResFollowUser{}}
+
+	if limit == 0 {
+		limit = defaultFollowPageSize
+	}
+	if limit < 0 || limit > maxFollowPageSize {
+		return res, apperrors.WithStack(&apperrors.ValidationError{Field: "limit", Reason: "must be 1 to 100"})
+	}
+
+	after, err := decodeFollowCursor(cursor)
+	if err != nil {
+		return res, apperrors.WithStack(err)
+	}
+
+	if _, err := u.userRepository.GetOne(ctx, userID); err != nil {
+		return res, apperrors.WithStack(err)
+	}
+
+	// one more than asked tells whether there is a next page
+	follows, err := page(ctx, userID, after, limit+1)
+	if err != nil {
+		return res, apperrors.WithStack(err)
+	}
+
+	if len(follows) > limit {
+		follows = follows[:limit]
+		last := follows[limit-1]
+		res.NextCursor = encodeFollowCursor(repository.FollowCursor{CreatedAt: last.CreatedAt, UserID: other(last).ID})
+	}
+
+	res.Users = lo.Map(follows, func(f model.UserFollow, _ int) ResFollowUser {
+		user := other(f)
+		return ResFollowUser{
+			ID:         user.ID,
+			Name:       user.Name,
+			Handle:     user.Handle,
+			FollowedAt: f.CreatedAt,
+		}
+	})
+
+	return res, nil
+}
+
+// ReconcileCounts brings the follower and following counts of every
+// organization up to date and returns how many users' counts changed. An
+// organization that fails doesn't hold up the others.
+func (u *followUsecase) ReconcileCounts(ctx context.Context) (_ int, err error) {
+	defer u.metrics.Start(ctx, "followUsecase.ReconcileCounts").End(&err)
+
+	orgIDs, err := u.organizationRepository.GetIDs(ctx)
+	if err != nil {
+		return 0, apperrors.WithStack(err)
+	}
+
+	var (
+		changed int
+		errs    []error
+	)
+	for _, orgID := range orgIDs {
+		n, err := u.followRepository.ReconcileCounts(ctx, orgID)
+		if err != nil {
+			errs = append(errs, fmt.Errorf("organization %d: %w", orgID, err))
+			continue
+		}
+		changed += n
+	}
+
+	return changed, apperrors.WithStack(errors.Join(errs...))
+}
+
+// encodeFollowCursor makes an opaque cursor of the position, the time in
+// microseconds like PostgreSQL stores it.
+func encodeFollowCursor(c repository.FollowCursor) string {
+	return base64.RawURLEncoding.EncodeToString(fmt.Appendf(nil, "%d.%d", c.CreatedAt.UnixMicro(), c.UserID))
+}
+
+func decodeFollowCursor(cursor string) (repository.FollowCursor, error) {
+	if cursor == "" {
+		return repository.FollowCursor{}, nil
+	}
+
+	invalid := &apperrors.ValidationError{Field: "cursor", Reason: "is not a cursor of this list"}
+
+	b, err := base64.RawURLEncoding.DecodeString(cursor)
+	if err != nil {
+		return repository.FollowCursor{}, invalid
+	}
+
+	var micro int64
+	var userID int
+	if n, err := fmt.Sscanf(string(b), "%d.%d", &micro, &userID); err != nil || n != 2 || micro <= 0 {
+		return repository.FollowCursor{}, invalid
+	}
+
+	return repository.FollowCursor{CreatedAt: time.UnixMicro(micro).UTC(), UserID: userID}, nil
+}
+
+// FollowCountReconciler periodically reconciles the follow counts, see
+// FollowUsecase.ReconcileCounts.
+type FollowCountReconciler struct {
+	follow   FollowUsecase
+	interval time.Duration
+}
+
+func NewFollowCountReconciler(follow FollowUsecase, interval time.Duration) *FollowCountReconciler {
+	return &FollowCountReconciler{
+		follow:   follow,
+		interval: interval,
+	}
+}
+
+// Start reconciles the counts every interval until ctx is cancelled.
+func (r *FollowCountReconciler) Start(ctx context.Context) {
+	go func() {
+		ticker := time.NewTicker(r.interval)
+		defer ticker.Stop()
+
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+				r.reconcile(ctx)
+			}
+		}
+	}()
+}
+
+func (r *FollowCountReconciler) reconcile(ctx context.Context) {
+	changed, err := r.follow.ReconcileCounts(ctx)
+	if err != nil {
+		logging.Errorf(ctx, err, "failed to reconcile follow counts: %s", err.Error())
+		return
+	}
+	if changed > 0 {
+		logging.Debugf(ctx, "reconciled the follow counts of %d users", changed)
+	}
+}
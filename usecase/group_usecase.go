@@ -0,0 +1,401 @@
+package usecase
+
+import (
+	"context"
+	"time"
+
+	"go02/model"
+	"go02/packages/apperrors"
+	"go02/packages/auth"
+	"go02/packages/metrics"
+	"go02/repository"
+
+	"github.com/samber/lo"
+)
+
+const (
+	// maxMembersPerRequest 1回のリクエストで追加・削除できるメンバーの上限
+	maxMembersPerRequest = 100
+	// maxGroupPageSize グループ一覧の1ページの上限
+	maxGroupPageSize = 100
+)
+
+// GroupUsecase Group 関係のusecaseのinterface
+//
+// The changes are made by actor: groups are created by a user, who becomes
+// their owner. Owners and admins of a group may change it and its members,
+// only owners may grant or revoke the owner role, and a group always keeps
+// an owner. An admin principal may do anything but create groups.
+type GroupUsecase interface {
+	CreateGroup(ctx context.Context, actor auth.Principal, name string, description string) (ResGroup, error)
+	UpdateGroup(ctx context.Context, actor auth.Principal, ID int, name string, description string) error
+	DeleteGroup(ctx context.Context, actor auth.Principal, ID int) error
+	GetGroupList(ctx context.Context, limit int, offset int) (ResGetGroupList, error)
+	GetGroup(ctx context.Context, ID int) (ResGetGroup, error)
+	AddMembers(ctx context.Context, actor auth.Principal, ID int, members []ReqMember) error
+	RemoveMembers(ctx context.Context, actor auth.Principal, ID int, userIDs []int) error
+	GetUserGroups(ctx context.Context, userID int) (ResGetUserGroups, error)
+}
+
+type groupUsecase struct {
+	transactionRepository     repository.TransactionRepository
+	groupRepository           repository.GroupRepository
+	groupMembershipRepository repository.GroupMembershipRepository
+	userRepository            repository.UserRepository
+	metrics                   *metrics.Usecase
+}
+
+// NewGroupUsecase Group usecaseのコンストラクタ
+func NewGroupUsecase(
+	transactionRepository repository.TransactionRepository,
+	groupRepository repository.GroupRepository,
+	groupMembershipRepository repository.GroupMembershipRepository,
+	userRepository repository.UserRepository,
+	metrics *metrics.Usecase,
+) GroupUsecase {
+	return &groupUsecase{
+		transactionRepository:     transactionRepository,
+		groupRepository:           groupRepository,
+		groupMembershipRepository: groupMembershipRepository,
+		userRepository:            userRepository,
+		metrics:                   metrics,
+	}
+}
+
+type ReqMember struct {
+	UserID int    `json:"user_id"`
+	Role   string `json:"role"`
+}
+type ResGroup struct {
+	ID          int       `json:"id" log:"allow"`
+	Name        string    `json:"name" log:"allow"`
+	Description string    `json:"description" log:"allow"`
+	CreatedAt   time.Time `json:"created_at" log:"allow"`
+	UpdatedAt   time.Time `json:"updated_at" log:"allow"`
+}
+type ResGetGroupList struct {
+	Groups []ResGroup `json:"groups" log:"allow"`
+}
+type ResMember struct {
+	UserID    int       `json:"user_id" log:"allow"`
+	Role      string    `json:"role" log:"allow"`
+	CreatedAt time.Time `json:"created_at" log:"allow"`
+}
+type ResGetGroup struct {
+	ResGroup
+	Members []ResMember `json:"members" log:"allow"`
+}
+type ResUserGroup struct {
+	ResGroup
+	Role string `json:"role" log:"allow"`
+}
+type ResGetUserGroups struct {
+	Groups []ResUserGroup `json:"groups" log:"allow"`
+}
+
+func newResGroup(g model.Group) ResGroup {
+	return ResGroup{
+		ID:          g.ID,
+		Name:        g.Name,
+		Description: g.Description,
+		CreatedAt:   g.CreatedAt,
+		UpdatedAt:   g.UpdatedAt,
+	}
+}
+
+// CreateGroup creates the group with actor as its owner.
+func (u *groupUsecase) CreateGroup(ctx context.Context, actor auth.Principal, name string, description string) (_ ResGroup, err error) {
+	defer u.metrics.Start(ctx, "groupUsecase.CreateGroup").End(&err)
+
+	// the owner is a user
+	if actor.Admin {
+		return ResGroup{}, apperrors.WithStack(apperrors.ErrForbidden)
+	}
+
+	group, err := model.NewGroup(name, description)
+	if err != nil {
+		return ResGroup{}, apperrors.WithStack(err)
+	}
+
+	err = u.transactionRepository.WithinTransaction(ctx, func(ctx context.Context) error {
+		if _, err := u.groupRepository.Create(ctx, group); err != nil {
+			return apperrors.WithStack(err)
+		}
+
+		owner, err := model.NewGroupMembership(group.ID, actor.UserID, model.RoleOwner)
+		if err != nil {
+			return apperrors.WithStack(err)
+		}
+		if err := u.groupMembershipRepository.Upsert(ctx, []model.GroupMembership{*owner}); err != nil {
+			return apperrors.WithStack(err)
+		}
+
+		return nil
+	})
+	if err != nil {
+		return ResGroup{}, apperrors.WithStack(err)
+	}
+
+	return newResGroup(*group), nil
+}
+
+func (u *groupUsecase) UpdateGroup(ctx context.Context, actor auth.Principal, ID int, name string, description string) (err error) {
+	defer u.metrics.Start(ctx, "groupUsecase.UpdateGroup").End(&err)
+
+	err = u.transactionRepository.WithinTransaction(ctx, func(ctx context.Context) error {
+		group, err := u.groupRepository.GetOneForUpdate(ctx, ID)
+		if err != nil {
+			return apperrors.WithStack(err)
+		}
+		if _, err := u.authorize(ctx, actor, ID); err != nil {
+			return apperrors.WithStack(err)
+		}
+
+		if err := group.Set(name, description); err != nil {
+			return apperrors.WithStack(err)
+		}
+
+		return apperrors.WithStack(u.groupRepository.Update(ctx, &group))
+	})
+	if err != nil {
+		return apperrors.WithStack(err)
+	}
+
+	return nil
+}
+
+func (u *groupUsecase) DeleteGroup(ctx context.Context, actor auth.Principal, ID int) (err error) {
+	defer u.metrics.Start(ctx, "groupUsecase.DeleteGroup").End(&err)
+
+	err = u.transactionRepository.WithinTransaction(ctx, func(ctx context.Context) error {
+		if _, err := u.groupRepository.GetOneForUpdate(ctx, ID); err != nil {
+			return apperrors.WithStack(err)
+		}
+		if _, err := u.authorize(ctx, actor, ID); err != nil {
+			return apperrors.WithStack(err)
+		}
+
+		return apperrors.WithStack(u.groupRepository.Delete(ctx, ID))
+	})
+	if err != nil {
+		return apperrors.WithStack(err)
+	}
+
+	return nil
+}
+
+// authorize returns the role actor acts with in the group, owner for an
+// admin principal. Members that are neither owners nor admins are
+// apperrors.ErrForbidden.
+func (u *groupUsecase) authorize(ctx context.Context, actor auth.Principal, groupID int) (string, error) {
+	if actor.Admin {
+		return model.RoleOwner, nil
+	}
+
+	memberships, err := u.groupMembershipRepository.GetByUserIDs(ctx, groupID, []int{actor.UserID})
+	if err != nil {
+		return "", apperrors.WithStack(err)
+	}
+	if len(memberships) == 0 || memberships[0].Role == model.RoleMember {
+		return "", apperrors.WithStack(apperrors.ErrForbidden)
+	}
+
+	return memberships[0].Role, nil
+}
+
+// notOwners is apperrors.ErrForbidden when one of the users is an owner of the
+// group.
+func (u *groupUsecase) notOwners(ctx context.Context, groupID int, userIDs []int) error {
+	memberships, err := u.groupMembershipRepository.GetByUserIDs(ctx, groupID, userIDs)
+	if err != nil {
+		return apperrors.WithStack(err)
+	}
+	if lo.ContainsBy(memberships, func(m model.GroupMembership) bool { return m.Role == model.RoleOwner }) {
+		return apperrors.WithStack(apperrors.ErrForbidden)
+	}
+	return nil
+}
+
+// keepsOwner fails the change that left the group without an owner, which
+// rolls back the transaction.
+func (u *groupUsecase) keepsOwner(ctx context.Context, groupID int, field string) error {
+	n, err := u.groupMembershipRepository.CountOwners(ctx, groupID)
+	if err != nil {
+		return apperrors.WithStack(err)
+	}
+	if n == 0 {
+		return apperrors.WithStack(&apperrors.ValidationError{Field: field, Reason: "must leave the group an owner"})
+	}
+	return nil
+}
+
+func (u *groupUsecase) GetGroupList(ctx context.Context, limit int, offset int) (_ ResGetGroupList, err error) {
+	defer u.metrics.Start(ctx, "groupUsecase.GetGroupList").End(&err)
+
+	if limit == 0 {
+		limit = maxGroupPageSize
+	}
+	if limit < 0 || limit > maxGroupPageSize {
+		return ResGetGroupList{Groups: []ResGroup{}}, apperrors.WithStack(&apperrors.ValidationError{Field: "limit", Reason: "must be 1 to 100"})
+	}
+	if offset < 0 {
+		return ResGetGroupList{Groups: []ResGroup{}}, apperrors.WithStack(&apperrors.ValidationError{Field: "offset", Reason: "must not be negative"})
+	}
+
+	groups, err := u.groupRepository.GetList(ctx, limit, offset)
+	if err != nil {
+		return ResGetGroupList{Groups: []ResGroup{}}, apperrors.WithStack(err)
+	}
+
+	return ResGetGroupList{
+		Groups: lo.Map(groups, func(g model.Group, _ int) ResGroup {
+			return newResGroup(g)
+		}),
+	}, nil
+}
+
+func (u *groupUsecase) GetGroup(ctx context.Context, ID int) (_ ResGetGroup, err error) {
+	defer u.metrics.Start(ctx, "groupUsecase.GetGroup").End(&err)
+
+	group, err := u.groupRepository.GetOne(ctx, ID)
+	if err != nil {
+		return ResGetGroup{}, apperrors.WithStack(err)
+	}
+
+	memberships, err := u.groupMembershipRepository.GetByGroupID(ctx, ID)
+	if err != nil {
+		return ResGetGroup{}, apperrors.WithStack(err)
+	}
+
+	return ResGetGroup{
+		ResGroup: newResGroup(group),
+		Members: lo.Map(memberships, func(m model.GroupMembership, _ int) ResMember {
+			return ResMember{UserID: m.UserID, Role: m.Role, CreatedAt: m.CreatedAt}
+		}),
+	}, nil
+}
+
+// AddMembers adds the users to the group, or changes their role when they
+// already are members. Either all of them are added or none.
+func (u *groupUsecase) AddMembers(ctx context.Context, actor auth.Principal, ID int, members []ReqMember) (err error) {
+	defer u.metrics.Start(ctx, "groupUsecase.AddMembers").End(&err)
+
+	// the last entry of a user wins
+	last := make(map[int]int, len(members))
+	for i, m := range members {
+		last[m.UserID] = i
+	}
+	members = lo.Filter(members, func(m ReqMember, i int) bool { return last[m.UserID] == i })
+	if len(members) == 0 || len(members) > maxMembersPerRequest {
+		return apperrors.WithStack(&apperrors.ValidationError{Field: "members", Reason: "must be 1 to 100 users"})
+	}
+
+	memberships := make([]model.GroupMembership, 0, len(members))
+	for _, m := range members {
+		membership, err := model.NewGroupMembership(ID, m.UserID, m.Role)
+		if err != nil {
+			return apperrors.WithStack(err)
+		}
+		memberships = append(memberships, *membership)
+	}
+
+	err = u.transactionRepository.WithinTransaction(ctx, func(ctx context.Context) error {
+		// locks the group so that it isn't deleted in between, and serializes
+		// the changes of its members
+		if _, err := u.groupRepository.GetOneForUpdate(ctx, ID); err != nil {
+			return apperrors.WithStack(err)
+		}
+		role, err := u.authorize(ctx, actor, ID)
+		if err != nil {
+			return apperrors.WithStack(err)
+		}
+
+		userIDs := lo.Map(members, func(m ReqMember, _ int) int { return m.UserID })
+		// only owners grant the owner role or change the role of an owner
+		if role != model.RoleOwner {
+			if lo.ContainsBy(memberships, func(m model.GroupMembership) bool { return m.Role == model.RoleOwner }) {
+				return apperrors.WithStack(apperrors.ErrForbidden)
+			}
+			if err := u.notOwners(ctx, ID, userIDs); err != nil {
+				return apperrors.WithStack(err)
+			}
+		}
+
+		users, err := u.userRepository.GetByIDs(ctx, userIDs)
+		if err != nil {
+			return apperrors.WithStack(err)
+		}
+		// users of other organizations aren't found either
+		if len(users) != len(userIDs) {
+			return apperrors.WithStack(&apperrors.ValidationError{Field: "user_id", Reason: "must be users of the organization"})
+		}
+
+		if err := u.groupMembershipRepository.Upsert(ctx, memberships); err != nil {
+			return apperrors.WithStack(err)
+		}
+
+		return u.keepsOwner(ctx, ID, "role")
+	})
+	if err != nil {
+		return apperrors.WithStack(err)
+	}
+
+	return nil
+}
+
+// RemoveMembers removes the users from the group. Users that aren't members
+// are ignored.
+func (u *groupUsecase) RemoveMembers(ctx context.Context, actor auth.Principal, ID int, userIDs []int) (err error) {
+	defer u.metrics.Start(ctx, "groupUsecase.RemoveMembers").End(&err)
+
+	userIDs = lo.Uniq(userIDs)
+	if len(userIDs) == 0 || len(userIDs) > maxMembersPerRequest {
+		return apperrors.WithStack(&apperrors.ValidationError{Field: "user_id", Reason: "must be 1 to 100 users"})
+	}
+
+	err = u.transactionRepository.WithinTransaction(ctx, func(ctx context.Context) error {
+		if _, err := u.groupRepository.GetOneForUpdate(ctx, ID); err != nil {
+			return apperrors.WithStack(err)
+		}
+		role, err := u.authorize(ctx, actor, ID)
+		if err != nil {
+			return apperrors.WithStack(err)
+		}
+		// only owners remove owners
+		if role != model.RoleOwner {
+			if err := u.notOwners(ctx, ID, userIDs); err != nil {
+				return apperrors.WithStack(err)
+			}
+		}
+
+		if _, err := u.groupMembershipRepository.Delete(ctx, ID, userIDs); err != nil {
+			return apperrors.WithStack(err)
+		}
+
+		return u.keepsOwner(ctx, ID, "user_id")
+	})
+	if err != nil {
+		return apperrors.WithStack(err)
+	}
+
+	return nil
+}
+
+func (u *groupUsecase) GetUserGroups(ctx context.Context, userID int) (_ ResGetUserGroups, err error) {
+	defer u.metrics.Start(ctx, "groupUsecase.GetUserGroups").End(&err)
+
+	if _, err := u.userRepository.GetOne(ctx, userID); err != nil {
+		return ResGetUserGroups{}, apperrors.WithStack(err)
+	}
+
+	memberships, err := u.groupMembershipRepository.GetByUserID(ctx, userID)
+	if err != nil {
+		return ResGetUserGroups{}, apperrors.WithStack(err)
+	}
+
+	return ResGetUserGroups{
+		Groups: lo.Map(memberships, func(m model.GroupMembership, _ int) ResUserGroup {
+			return ResUserGroup{ResGroup: newResGroup(*m.Group), Role: m.Role}
+		}),
+	}, nil
+}
@@ -0,0 +1,78 @@
+package usecase
+
+import (
+	"context"
+	"time"
+
+	"go02/model"
+	"go02/packages/apperrors"
+	"go02/packages/metrics"
+	"go02/repository"
+)
+
+// OrganizationUsecase テナントのusecaseのinterface
+type OrganizationUsecase interface {
+	CreateOrganization(ctx context.Context, slug string, name string) (ResOrganization, error)
+	ResolveOrganization(ctx context.Context, slug string) (int, error)
+}
+
+type organizationUsecase struct {
+	organizationRepository repository.OrganizationRepository
+	metrics                *metrics.Usecase
+}
+
+// NewOrganizationUsecase Organization usecaseのコンストラクタ
+func NewOrganizationUsecase(
+	organizationRepository repository.OrganizationRepository,
+	metrics *metrics.Usecase,
+) OrganizationUsecase {
+	return &organizationUsecase{
+		organizationRepository: organizationRepository,
+		metrics:                metrics,
+	}
+}
+
+type ResOrganization struct {
+	ID        int       `json:"id" log:"allow"`
+	Slug      string    `json:"slug" log:"allow"`
+	Name      string    `json:"name" log:"allow"`
+	CreatedAt time.Time `json:"created_at" log:"allow"`
+}
+
+func (u *organizationUsecase) CreateOrganization(ctx context.Context, slug string, name string) (_ ResOrganization, err error) {
+	defer u.metrics.Start(ctx, "organizationUsecase.CreateOrganization").End(&err)
+
+	org, err := model.NewOrganization(slug, name)
+	if err != nil {
+		return ResOrganization{}, apperrors.WithStack(err)
+	}
+
+	if err := u.organizationRepository.Create(ctx, org); err != nil {
+		return ResOrganization{}, apperrors.WithStack(err)
+	}
+
+	return ResOrganization{
+		ID:        org.ID,
+		Slug:      org.Slug,
+		Name:      org.Name,
+		CreatedAt: org.CreatedAt,
+	}, nil
+}
+
+// ResolveOrganization returns the id of the organization with the slug, or
+// apperrors.ErrNotFound.
+func (u *organizationUsecase) ResolveOrganization(ctx context.Context, slug string) (_ int, err error) {
+	defer u.metrics.Start(ctx, "organizationUsecase.ResolveOrganization").End(&err)
+
+	// not a slug, so not an organization either
+	if model.ValidateSlug(slug) != nil {
+		return 0, apperrors.WithStack(apperrors.ErrNotFound)
+	}
+
+	org, err := u.organizationRepository.GetBySlug(ctx, slug)
+	if err != nil {
+		return 0, apperrors.WithStack(err)
+	}
+
+	return org.ID, nil
+}
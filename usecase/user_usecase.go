@@ -5,26 +5,32 @@ import (
 	"go02/model"
 	"go02/packages/apperrors"
 	"go02/packages/logging"
+	"go02/packages/metrics"
 	"go02/repository"
 	"log/slog"
+	"time"
 
 	"github.com/samber/lo"
-	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/trace"
 )
 
 // UserUsecase User 関係のusecaseのinterface
 type UserUsecase interface {
-	CreateUser(ctx context.Context, name string, age int, bio string, avatarURL string) error
-	UpdateUser(ctx context.Context, ID int, name string, age int, bio string, avatarURL string) error
+	CreateUser(ctx context.Context, name string, handle string, email string, password string, age int, bio string, avatarURL string) error
+	UpdateUser(ctx context.Context, ID int, name string, handle string, email string, age int, bio string, avatarURL string) error
 	DeleteUser(ctx context.Context, ID int) error
 	GetUserList(ctx context.Context, limit int, offset int) (ResGetUserList, error)
 	GetUserOne(ctx context.Context, ID int) (ResGetUser, error)
+	GetUserByHandle(ctx context.Context, handle string) (ResGetUser, error)
 }
 
 type userUsecase struct {
 	transactionRepository repository.TransactionRepository
 	userRepository        repository.UserRepository
 	profileRepository     repository.ProfileRepository
+	credentialRepository  repository.CredentialRepository
+	sessionRepository     repository.SessionRepository
+	metrics               *metrics.Usecase
 }
 
 // NewUserUsecase User usecaseのコンストラクタ
@@ -32,11 +38,17 @@ func NewUserUsecase(
 	transactionRepository repository.TransactionRepository,
 	userRepository repository.UserRepository,
 	profileRepository repository.ProfileRepository,
+	credentialRepository repository.CredentialRepository,
+	sessionRepository repository.SessionRepository,
+	metrics *metrics.Usecase,
 ) UserUsecase {
 	return &userUsecase{
 		transactionRepository: transactionRepository,
 		userRepository:        userRepository,
 		profileRepository:     profileRepository,
+		credentialRepository:  credentialRepository,
+		sessionRepository:     sessionRepository,
+		metrics:               metrics,
 	}
 }
 
@@ -49,18 +61,63 @@ type ReqGetUserList struct {
 	Offset int `query:"offset"`
 }
 type ResGetUserList struct {
-	Users []ResGetUser `json:"users"`
+	Users []ResGetUser `json:"users" log:"allow"`
 }
 type ResGetUser struct {
-	ID   int    `json:"id"`
-	Name string `json:"name"`
-	Age  int    `json:"age"`
+	ID        int       `json:"id" log:"allow"`
+	Name      string    `json:"name" log:"mask"`
+	Handle    string    `json:"handle" log:"allow"`
+	Age       int       `json:"age" log:"drop"`
+	CreatedAt time.Time `json:"created_at" log:"allow"`
+	UpdatedAt time.Time `json:"updated_at" log:"allow"`
+
+	// as of the last reconciliation of the counts, see FollowUsecase
+	FollowersCount int `json:"followers_count" log:"allow"`
+	FollowingCount int `json:"following_count" log:"allow"`
+}
+
+func newResGetUser(u model.User) ResGetUser {
+	res := ResGetUser{
+		ID:        u.ID,
+		Name:      u.Name,
+		Handle:    u.Handle,
+		Age:       u.Age,
+		CreatedAt: u.CreatedAt,
+		UpdatedAt: u.UpdatedAt,
+	}
+	if u.FollowCount != nil {
+		res.FollowersCount = u.FollowCount.FollowersCount
+		res.FollowingCount = u.FollowCount.FollowingCount
+	}
+	return res
+}
+
+// In returns the user with its timestamps rendered in loc.
+func (r ResGetUser) In(loc *time.Location) ResGetUser {
+	r.CreatedAt = r.CreatedAt.In(loc)
+	r.UpdatedAt = r.UpdatedAt.In(loc)
+	return r
 }
 
-func (u *userUsecase) CreateUser(ctx context.Context, name string, age int, bio string, avatarURL string) error {
+// In returns the users with their timestamps rendered in loc.
+func (r ResGetUserList) In(loc *time.Location) ResGetUserList {
+	users := make([]ResGetUser, len(r.Users))
+	for i, u := range r.Users {
+		users[i] = u.In(loc)
+	}
+	return ResGetUserList{Users: users}
+}
 
-	err := u.transactionRepository.WithinTransaction(ctx, func(ctx context.Context) error {
-		user, err := model.NewUser(name, age)
+func (u *userUsecase) CreateUser(ctx context.Context, name string, handle string, email string, password string, age int, bio string, avatarURL string) (err error) {
+	defer u.metrics.Start(ctx, "userUsecase.CreateUser").End(&err)
+
+	err = u.transactionRepository.WithinTransaction(ctx, func(ctx context.Context) error {
+		user, err := model.NewUser(name, handle, email, age)
+		if err != nil {
+			return apperrors.WithStack(err)
+		}
+		// the password policy is checked before anything is written
+		credential, err := model.NewCredential(user, password)
 		if err != nil {
 			return apperrors.WithStack(err)
 		}
@@ -70,6 +127,11 @@ func (u *userUsecase) CreateUser(ctx context.Context, name string, age int, bio
 			return apperrors.WithStack(err)
 		}
 
+		credential.UserID = user.ID
+		if err := u.credentialRepository.Create(ctx, credential); err != nil {
+			return apperrors.WithStack(err)
+		}
+
 		profile, err := model.NewProfile(user.ID, bio, avatarURL)
 		if err != nil {
 			return apperrors.WithStack(err)
@@ -89,9 +151,10 @@ func (u *userUsecase) CreateUser(ctx context.Context, name string, age int, bio
 	return nil
 }
 
-func (u *userUsecase) UpdateUser(ctx context.Context, ID int, name string, age int, bio string, avatarURL string) error {
+func (u *userUsecase) UpdateUser(ctx context.Context, ID int, name string, handle string, email string, age int, bio string, avatarURL string) (err error) {
+	defer u.metrics.Start(ctx, "userUsecase.UpdateUser").End(&err)
 
-	err := u.transactionRepository.WithinTransaction(ctx, func(ctx context.Context) error {
+	err = u.transactionRepository.WithinTransaction(ctx, func(ctx context.Context) error {
 		user, err := u.userRepository.GetOne(ctx, ID)
 		if err != nil {
 			return apperrors.WithStack(err)
@@ -100,6 +163,21 @@ func (u *userUsecase) UpdateUser(ctx context.Context, ID int, name string, age i
 		user.Name = name
 		user.Age = age
 
+		// an empty handle keeps the current one
+		if handle != "" {
+			if err := model.ValidateHandle(handle); err != nil {
+				return apperrors.WithStack(err)
+			}
+			user.Handle = handle
+		}
+
+		// an empty email keeps the current one as well
+		if email != "" {
+			if err := user.SetEmail(email); err != nil {
+				return apperrors.WithStack(err)
+			}
+		}
+
 		err = u.userRepository.Update(ctx, &user)
 		if err != nil {
 			return apperrors.WithStack(err)
@@ -127,9 +205,20 @@ func (u *userUsecase) UpdateUser(ctx context.Context, ID int, name string, age i
 	return nil
 }
 
-func (u *userUsecase) DeleteUser(ctx context.Context, ID int) error {
+func (u *userUsecase) DeleteUser(ctx context.Context, ID int) (err error) {
+	defer u.metrics.Start(ctx, "userUsecase.DeleteUser").End(&err)
 
-	err := u.userRepository.Delete(ctx, ID)
+	// the access tokens of the user stop working with its sessions, and it
+	// can't log in again
+	err = u.transactionRepository.WithinTransaction(ctx, func(ctx context.Context) error {
+		if err := u.userRepository.Delete(ctx, ID); err != nil {
+			return apperrors.WithStack(err)
+		}
+		if err := u.sessionRepository.RevokeByUserID(ctx, ID, time.Now()); err != nil {
+			return apperrors.WithStack(err)
+		}
+		return apperrors.WithStack(u.credentialRepository.DeleteByUserID(ctx, ID))
+	})
 	if err != nil {
 		return apperrors.WithStack(err)
 	}
@@ -137,8 +226,10 @@ func (u *userUsecase) DeleteUser(ctx context.Context, ID int) error {
 	return nil
 }
 
-func (u *userUsecase) GetUserList(ctx context.Context, limit int, offset int) (ResGetUserList, error) {
-	tracer := otel.Tracer("usecase")
+func (u *userUsecase) GetUserList(ctx context.Context, limit int, offset int) (_ ResGetUserList, err error) {
+	defer u.metrics.Start(ctx, "userUsecase.GetUserList").End(&err)
+
+	tracer := trace.SpanFromContext(ctx).TracerProvider().Tracer("usecase")
 	ctx, span := tracer.Start(ctx, "userUsecase.GetUserList")
 	defer span.End()
 
@@ -159,11 +250,7 @@ func (u *userUsecase) GetUserList(ctx context.Context, limit int, offset int) (R
 
 	resUsers = ResGetUserList{
 		Users: lo.Map(users, func(u model.User, _ int) ResGetUser {
-			return ResGetUser{
-				ID:   u.ID,
-				Name: u.Name,
-				Age:  u.Age,
-			}
+			return newResGetUser(u)
 		}),
 	}
 
@@ -172,7 +259,9 @@ func (u *userUsecase) GetUserList(ctx context.Context, limit int, offset int) (R
 	return resUsers, nil
 }
 
-func (u *userUsecase) GetUserOne(ctx context.Context, ID int) (ResGetUser, error) {
+func (u *userUsecase) GetUserOne(ctx context.Context, ID int) (_ ResGetUser, err error) {
+	defer u.metrics.Start(ctx, "userUsecase.GetUserOne").End(&err)
+
 	var resUser ResGetUser
 
 	user, err := u.userRepository.GetOne(ctx, ID)
@@ -180,11 +269,18 @@ func (u *userUsecase) GetUserOne(ctx context.Context, ID int) (ResGetUser, error
 		return resUser, apperrors.WithStack(err)
 	}
 
-	resUser = ResGetUser{
-		ID:   user.ID,
-		Name: user.Name,
-		Age:  user.Age,
-	}
+	resUser = newResGetUser(user)
 
 	return resUser, nil
 }
+
+func (u *userUsecase) GetUserByHandle(ctx context.Context, handle string) (_ ResGetUser, err error) {
+	defer u.metrics.Start(ctx, "userUsecase.GetUserByHandle").End(&err)
+
+	user, err := u.userRepository.GetByHandle(ctx, handle)
+	if err != nil {
+		return ResGetUser{}, apperrors.WithStack(err)
+	}
+
+	return newResGetUser(user), nil
+}